@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/phrase/phraseapp-client/internal/print"
+	"github.com/phrase/phraseapp-client/internal/spinner"
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+// defaultBranchBase is the branch_base config option, the base of branches
+// created by push --create-branch without --branch-base.
+var defaultBranchBase string
+
+// createBranch creates the branch in the project unless it exists, and waits
+// until it's ready. With base the branch is created from base, otherwise from
+// the main branch of the project.
+func createBranch(client *phraseapp.Client, projectID, name, base string) error {
+	_, err := client.BranchShow(projectID, name)
+	if err == nil {
+		return nil
+	}
+	if !phraseapp.IsErrNotFound(err) {
+		return err
+	}
+
+	branch, err := withBranchBase(client, base).BranchCreate(projectID, &phraseapp.BranchParams{Name: &name})
+	if err != nil {
+		return fmt.Errorf("creating branch %q in project %q failed: %s", name, projectID, err)
+	}
+
+	fmt.Println()
+
+	taskResult := make(chan string, 1)
+	taskErr := make(chan error, 1)
+
+	fmt.Printf("Waiting for branch %s is created!", branch.Name)
+	spinner.While(func() {
+		branchCreateResult, err := getBranchCreateResult(client, projectID, branch)
+		taskResult <- branchCreateResult
+		taskErr <- err
+	})
+	fmt.Println()
+
+	if err := <-taskErr; err != nil {
+		return err
+	}
+
+	if <-taskResult == "error" {
+		return fmt.Errorf("There was an error creating branch %s.", branch.Name)
+	}
+	print.Success("Successfully created branch %s", branch.Name)
+	return nil
+}
+
+// withBranchBase returns a copy of client adding base to the parameters of
+// branch creations, which the API client has no field for. Without base the
+// client is returned as is.
+func withBranchBase(client *phraseapp.Client, base string) *phraseapp.Client {
+	if base == "" {
+		return client
+	}
+	c := *client
+	c.Transport = &branchBaseTransport{base: base, next: c.Transport}
+	return &c
+}
+
+type branchBaseTransport struct {
+	base string
+	next http.RoundTripper
+}
+
+func (t *branchBaseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := t.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if req.Method != "POST" || !strings.HasSuffix(req.URL.Path, "/branches") || req.Body == nil {
+		return next.RoundTrip(req)
+	}
+
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	params := map[string]interface{}{}
+	if err := json.Unmarshal(body, &params); err != nil {
+		return nil, fmt.Errorf("unexpected branch parameters: %s", err)
+	}
+	params["base"] = t.base
+	if body, err = json.Marshal(params); err != nil {
+		return nil, err
+	}
+
+	clone := *req
+	clone.Body = ioutil.NopCloser(bytes.NewReader(body))
+	clone.ContentLength = int64(len(body))
+	return next.RoundTrip(&clone)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func TestCreateBranch(t *testing.T) {
+	created := false
+	var params map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v2/projects/project-id/branches/existing":
+			io.WriteString(w, `{"name": "existing", "state": "success"}`)
+		case r.Method == "GET" && r.URL.Path == "/v2/projects/project-id/branches/feature":
+			if !created {
+				w.WriteHeader(http.StatusNotFound)
+				io.WriteString(w, `{"message": "Not Found"}`)
+				return
+			}
+			io.WriteString(w, `{"name": "feature", "state": "success"}`)
+		case r.Method == "POST" && r.URL.Path == "/v2/projects/project-id/branches":
+			if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+				t.Errorf("expected JSON parameters, got: %s", err)
+			}
+			created = true
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"name": "feature", "state": "running"}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	if err := createBranch(client, "project-id", "existing", "release"); err != nil || created {
+		t.Fatalf("expected an existing branch not to be created, got %v", err)
+	}
+
+	if err := createBranch(client, "project-id", "feature", "release"); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if !created || params["name"] != "feature" || params["base"] != "release" {
+		t.Errorf("expected the branch to be created from the base, got %v", params)
+	}
+}
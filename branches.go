@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/phrase/phraseapp-client/internal/stringz"
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+type BranchesCommand struct {
+	phraseapp.Config
+	ProjectID string `cli:"opt --project-id desc='Project to list the branches of, defaults to the project of the config'"`
+	Format    string `cli:"opt --format default=table desc='Output format, table or json'"`
+}
+
+// branchInfo is a branch as printed by the branches command.
+type branchInfo struct {
+	Name      string     `json:"name"`
+	CreatedAt *time.Time `json:"created_at"`
+	State     string     `json:"state"`
+}
+
+func (cmd *BranchesCommand) Run() error {
+	if cmd.Config.Debug {
+		// suppresses content output
+		cmd.Config.Debug = false
+		Debug = true
+	}
+
+	projectID := cmd.ProjectID
+	if projectID == "" {
+		projectID = cmd.Config.DefaultProjectID
+	}
+	if projectID == "" {
+		return fmt.Errorf("No project given. Please specify one using --project-id.")
+	}
+
+	client, err := newClient(cmd.Config.Credentials, cmd.Config.Debug)
+	if err != nil {
+		return err
+	}
+
+	branches, err := projectBranches(client, projectID)
+	if err != nil {
+		return err
+	}
+	return printBranches(os.Stdout, branches, cmd.Format)
+}
+
+// printBranches writes the name, creation date and state of the branches as
+// table or json.
+func printBranches(w io.Writer, branches []*phraseapp.Branch, format string) error {
+	infos := []*branchInfo{}
+	for _, branch := range branches {
+		infos = append(infos, &branchInfo{Name: branch.Name, CreatedAt: branch.CreatedAt, State: branch.State})
+	}
+
+	switch format {
+	case "json":
+		return json.NewEncoder(w).Encode(infos)
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tCREATED AT\tSTATE")
+		for _, info := range infos {
+			createdAt := ""
+			if info.CreatedAt != nil {
+				createdAt = info.CreatedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", info.Name, createdAt, info.State)
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q, use table or json", format)
+	}
+}
+
+// projectBranches returns all branches of the project.
+func projectBranches(client *phraseapp.Client, projectID string) ([]*phraseapp.Branch, error) {
+	page := 1
+	branches, err := client.BranchesList(projectID, page, 100)
+	if err != nil {
+		return nil, err
+	}
+	result := branches
+	for len(branches) == 100 {
+		page++
+		branches, err = client.BranchesList(projectID, page, 100)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, branches...)
+	}
+	return result, nil
+}
+
+// defaultBranch is the branch config option, used by pull and push without
+// --branch.
+var defaultBranch string
+
+// branchOrDefault returns branch, or the default branch if it's empty.
+func branchOrDefault(branch string) string {
+	if branch == "" {
+		return defaultBranch
+	}
+	return branch
+}
+
+// validateBranch checks that branch exists in all projects, so a misspelled
+// branch fails early with the list of available branches instead of failing
+// for every file. Without branch nothing is validated.
+func validateBranch(client *phraseapp.Client, projectIDs []string, branch string) error {
+	if branch == "" {
+		return nil
+	}
+
+	for _, projectID := range stringz.RemoveDuplicates(projectIDs) {
+		branches, err := projectBranches(client, projectID)
+		if err != nil {
+			return err
+		}
+
+		names := []string{}
+		found := false
+		for _, b := range branches {
+			names = append(names, b.Name)
+			found = found || b.Name == branch
+		}
+		if found {
+			continue
+		}
+
+		sort.Strings(names)
+		return &branchNotFoundError{branch: branch, projectID: projectID, available: names}
+	}
+	return nil
+}
+
+// branchNotFoundError is returned by validateBranch for a branch missing in
+// a project.
+type branchNotFoundError struct {
+	branch    string
+	projectID string
+	available []string
+}
+
+func (err *branchNotFoundError) Error() string {
+	if len(err.available) == 0 {
+		return fmt.Sprintf("Branch %q does not exist in project %q, the project has no branches", err.branch, err.projectID)
+	}
+	return fmt.Sprintf("Branch %q does not exist in project %q. Available branches: %s", err.branch, err.projectID, strings.Join(err.available, ", "))
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func TestValidateBranch(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if !strings.HasSuffix(r.URL.Path, "/projects/project-id/branches") {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		io.WriteString(w, `[{"name": "release", "state": "success"}, {"name": "feature", "state": "success"}]`)
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	if err := validateBranch(client, []string{"project-id"}, ""); err != nil || requests != 0 {
+		t.Errorf("expected no validation without branch, got %v after %d requests", err, requests)
+	}
+
+	if err := validateBranch(client, []string{"project-id", "project-id"}, "feature"); err != nil {
+		t.Errorf("didn't expect an error for an existing branch, got: %s", err)
+	}
+	if requests != 1 {
+		t.Errorf("expected the branches of each project to be requested once, got %d requests", requests)
+	}
+
+	err := validateBranch(client, []string{"project-id"}, "featrue")
+	if err == nil {
+		t.Fatalf("expected an error for a missing branch")
+	}
+	if !strings.Contains(err.Error(), `Branch "featrue" does not exist`) || !strings.Contains(err.Error(), "Available branches: feature, release") {
+		t.Errorf("expected the error to list the available branches, got: %s", err)
+	}
+	if _, ok := err.(*branchNotFoundError); !ok {
+		t.Errorf("expected a branchNotFoundError, got %T", err)
+	}
+}
+
+func TestValidateBranchRequestError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Unauthorized"}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	err := validateBranch(client, []string{"other-project-id"}, "feature")
+	if err == nil {
+		t.Fatalf("expected an error for the failed request")
+	}
+	if _, ok := err.(*branchNotFoundError); ok {
+		t.Errorf("didn't expect a failed request to be reported as a missing branch, got: %s", err)
+	}
+}
+
+func TestDefaultBranch(t *testing.T) {
+	_, clientCfg, err := parseConfig([]byte("phraseapp:\n  project_id: project-id\n  branch: feature\n"), "", nil)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if clientCfg.Branch != "feature" {
+		t.Fatalf("expected the branch to be read from the config, got %q", clientCfg.Branch)
+	}
+
+	defer func(original string) { defaultBranch = original }(defaultBranch)
+	defaultBranch = clientCfg.Branch
+
+	if branch := branchOrDefault(""); branch != "feature" {
+		t.Errorf("expected the branch of the config without --branch, got %q", branch)
+	}
+	if branch := branchOrDefault("release"); branch != "release" {
+		t.Errorf("expected --branch to override the config, got %q", branch)
+	}
+}
+
+func TestPrintBranches(t *testing.T) {
+	createdAt := time.Date(2018, 5, 1, 12, 0, 0, 0, time.UTC)
+	branches := []*phraseapp.Branch{{Name: "feature", CreatedAt: &createdAt, State: "success"}, {Name: "release", State: "running"}}
+
+	buf := &bytes.Buffer{}
+	if err := printBranches(buf, branches, "table"); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "NAME") || !strings.Contains(lines[1], "2018-05-01T12:00:00Z") || !strings.Contains(lines[2], "running") {
+		t.Errorf("expected a table of the branches, got:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := printBranches(buf, branches, "json"); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	infos := []map[string]interface{}{}
+	if err := json.Unmarshal(buf.Bytes(), &infos); err != nil {
+		t.Fatalf("expected JSON, got %q: %s", buf.String(), err)
+	}
+	if len(infos) != 2 || infos[0]["name"] != "feature" || infos[0]["created_at"] != "2018-05-01T12:00:00Z" || infos[1]["state"] != "running" {
+		t.Errorf("expected name, created_at and state of the branches, got %v", infos)
+	}
+
+	if err := printBranches(buf, branches, "xml"); err == nil {
+		t.Errorf("expected an error for an unknown output format")
+	}
+}
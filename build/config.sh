@@ -10,6 +10,8 @@ export LIBRARY_REVISION=$(cat Gopkg.lock | grep github.com/phrase/phraseapp-go -
 export VERSION=$(cat ${wd}/.version)
 export SOURCE_DATE_EPOCH=$(git log -1 --format=%ct)
 export LAST_CHANGE=$(git log -1 --format=%cd)
+# GNU date takes -d, BSD date (macOS) -r
+export BUILD_DATE=$(date -u -d "@${SOURCE_DATE_EPOCH}" +%Y-%m-%dT%H:%M:%SZ 2>/dev/null || date -u -r "${SOURCE_DATE_EPOCH}" +%Y-%m-%dT%H:%M:%SZ)
 
 if [[ -z $LIBRARY_REVISION ]]; then
   echo "unable to get library revision"
@@ -1,20 +1,37 @@
 package main
 
 import (
+	"bytes"
 	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httputil"
 	"os"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/phrase/phraseapp-client/internal/print"
+	"github.com/phrase/phraseapp-client/internal/ratelimit"
 	"github.com/phrase/phraseapp-go/phraseapp"
 )
 
+// requestHeaders are the headers given with --header, sent with every request
+// of all commands.
+var requestHeaders []string
+
 func newClient(creds phraseapp.Credentials, debug bool) (*phraseapp.Client, error) {
+	print.Mask(creds.Token)
+
 	c, err := phraseapp.NewClient(creds, debug)
 	if err != nil {
 		return nil, err
 	}
+	formatsHost = c.Credentials.Host
 	if os.Getenv("PHRASEAPP_INSECURE_SKIP_VERIFY") == "true" {
 		tr := &http.Transport{
 			Proxy: http.ProxyFromEnvironment,
@@ -28,5 +45,179 @@ func newClient(creds phraseapp.Credentials, debug bool) (*phraseapp.Client, erro
 		}
 		c.Client = http.Client{Transport: tr}
 	}
+	if err := addRequestHeaders(c, requestHeaders); err != nil {
+		return nil, fmt.Errorf("--header: %s", err)
+	}
 	return c, nil
 }
+
+var headerNameRegexp = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+// protectedHeaders are set by the client itself and must not be overridden.
+var protectedHeaders = []string{"Authorization", "X-Phraseapp-Otp"}
+
+// parseHeaders parses header specifications of the form "Name: value".
+func parseHeaders(specs []string) (http.Header, error) {
+	headers := http.Header{}
+	for _, spec := range specs {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+
+		parts := strings.SplitN(spec, ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid header %q, expected format is 'Name: value'", spec)
+		}
+
+		name, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if !headerNameRegexp.MatchString(name) {
+			return nil, fmt.Errorf("invalid header name %q", name)
+		}
+
+		name = http.CanonicalHeaderKey(name)
+		for _, protected := range protectedHeaders {
+			if name == protected {
+				return nil, fmt.Errorf("header %q must not be overridden", name)
+			}
+		}
+
+		headers.Add(name, value)
+	}
+	return headers, nil
+}
+
+// addRequestHeaders makes the client send the given headers with every request.
+func addRequestHeaders(client *phraseapp.Client, specs []string) error {
+	headers, err := parseHeaders(specs)
+	if err != nil {
+		return err
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+
+	client.Transport = &headerTransport{headers: headers, base: client.Transport}
+	return nil
+}
+
+// limitRequestRate limits the requests of the client to rps requests per
+// second. Zero means unlimited.
+func limitRequestRate(client *phraseapp.Client, rps int) error {
+	if rps < 0 {
+		return fmt.Errorf("requests per second must not be negative, got %d", rps)
+	}
+	if rps == 0 {
+		return nil
+	}
+
+	client.Transport = &ratelimit.Transport{Bucket: ratelimit.New(rps), Base: client.Transport}
+	return nil
+}
+
+// dumpErrorResponses makes the client write the complete response of every
+// failed request, with status, headers and body, to w. Secrets are masked.
+func dumpErrorResponses(client *phraseapp.Client, w io.Writer) {
+	client.Transport = &errorDumpTransport{w: print.SanitizingWriter(w), base: client.Transport}
+}
+
+type errorDumpTransport struct {
+	w    io.Writer
+	base http.RoundTripper
+}
+
+func (t *errorDumpTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode < 400 {
+		return resp, err
+	}
+
+	// DumpResponse restores the body, so the response can still be read
+	dump, dumpErr := httputil.DumpResponse(resp, true)
+	if dumpErr != nil {
+		// the body may be read partially, the response can't be used
+		resp.Body.Close()
+		return nil, dumpErr
+	}
+	fmt.Fprintf(t.w, "%s %s failed:\n%s\n", req.Method, req.URL, dump)
+	return resp, nil
+}
+
+type headerTransport struct {
+	headers http.Header
+	base    http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// a RoundTripper must not modify the given request
+	r := new(http.Request)
+	*r = *req
+	r.Header = http.Header{}
+	for name, values := range req.Header {
+		r.Header[name] = values
+	}
+	for name, values := range t.headers {
+		r.Header[name] = values
+	}
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(r)
+}
+
+// responseRecorder records the header and body of the last response sent
+// through base, for details the API client doesn't decode.
+type responseRecorder struct {
+	base http.RoundTripper
+
+	mu     sync.Mutex
+	header http.Header
+	body   []byte
+}
+
+// recordResponses makes client record its responses in the returned recorder.
+func recordResponses(client *phraseapp.Client) *responseRecorder {
+	recorder := &responseRecorder{base: client.Transport}
+	client.Transport = recorder
+	return recorder
+}
+
+func (r *responseRecorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := r.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	r.header, r.body = resp.Header, body
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// last returns the header and body of the last response.
+func (r *responseRecorder) last() (http.Header, []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.header == nil {
+		return http.Header{}, nil
+	}
+	return r.header, r.body
+}
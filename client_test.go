@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/phrase/phraseapp-client/internal/print"
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func TestDumpErrorResponses(t *testing.T) {
+	token := "dump-error-responses-token"
+	print.Mask(token)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "42")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		fmt.Fprintf(w, `{"message":"invalid token %s"}`, token)
+	}))
+	defer server.Close()
+
+	client := &phraseapp.Client{}
+	buf := &bytes.Buffer{}
+	dumpErrorResponses(client, buf)
+
+	resp, err := client.Get(server.URL + "/api/v2/projects")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "invalid token") {
+		t.Errorf("expected the body to be readable after the dump, got %q", body)
+	}
+
+	dump := buf.String()
+	for _, expected := range []string{"GET " + server.URL + "/api/v2/projects", "422 Unprocessable Entity", "X-Request-Id: 42", `"message":"invalid token`} {
+		if !strings.Contains(dump, expected) {
+			t.Errorf("expected dump to contain %q, got:\n%s", expected, dump)
+		}
+	}
+	if strings.Contains(dump, token) {
+		t.Errorf("expected the token to be masked, got:\n%s", dump)
+	}
+}
+
+type failingBody struct{ closed bool }
+
+func (b *failingBody) Read([]byte) (int, error) { return 0, fmt.Errorf("connection reset") }
+func (b *failingBody) Close() error             { b.closed = true; return nil }
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestDumpErrorResponsesFailedDump(t *testing.T) {
+	body := &failingBody{}
+	transport := &errorDumpTransport{w: ioutil.Discard, base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusInternalServerError, Body: body, Header: http.Header{}}, nil
+	})}
+
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	if _, err := transport.RoundTrip(req); err == nil {
+		t.Errorf("expected the error of the dump")
+	}
+	if !body.closed {
+		t.Errorf("expected the response body to be closed")
+	}
+}
+
+func TestDebugOutputMasked(t *testing.T) {
+	token := "debug-output-masked-token"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[]`)
+	}))
+	defer server.Close()
+
+	out, err := ioutil.TempFile("", "phraseapp-debug-output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	defer func(original *os.File) { os.Stderr = original }(os.Stderr)
+	os.Stderr = out
+	restore, err := print.SanitizeFile(&os.Stderr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := newClient(phraseapp.Credentials{Host: server.URL, Token: token}, true)
+	if err != nil {
+		restore()
+		t.Fatal(err)
+	}
+	_, err = client.LocalesList("project-id", 1, 25, &phraseapp.LocalesListParams{})
+	restore()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	dump, err := ioutil.ReadFile(out.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(dump), token) {
+		t.Errorf("expected the token to be masked in the debug output, got %s", dump)
+	}
+	if !strings.Contains(string(dump), "Authorization: token [MASKED]") {
+		t.Errorf("expected the masked header in the debug output, got %s", dump)
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers, err := parseHeaders([]string{"X-Team-Id: 42", "accept-language: en, de;q=0.8", " "})
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if headers.Get("X-Team-Id") != "42" || headers.Get("Accept-Language") != "en, de;q=0.8" || len(headers) != 2 {
+		t.Errorf("expected two headers with their values, got %v", headers)
+	}
+
+	for _, spec := range []string{"X-Team-Id", "X Team: 42", "Authorization: token other"} {
+		if _, err := parseHeaders([]string{spec}); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
+
+func TestHeaderFlags(t *testing.T) {
+	defer func(original []string) { requestHeaders = original }(requestHeaders)
+
+	headers, args, err := headerFlags([]string{"locales", "list", "--header", "Accept-Language: en, de", "--header=X-Team-Id: 42"})
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if len(headers) != 2 || headers[0] != "Accept-Language: en, de" || len(args) != 2 {
+		t.Fatalf("expected both headers to be extracted, got %v and %v", headers, args)
+	}
+
+	var received http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+		w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+
+	requestHeaders = headers
+	client, err := newClient(phraseapp.Credentials{Host: srv.URL, Token: "some_token"}, false)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if _, err := client.ProjectsList(1, 25); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if received.Get("Accept-Language") != "en, de" || received.Get("X-Team-Id") != "42" {
+		t.Errorf("expected the headers to be sent, got %v", received)
+	}
+}
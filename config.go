@@ -0,0 +1,323 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+
+	"github.com/phrase/phraseapp-client/internal/placeholders"
+	"github.com/phrase/phraseapp-client/internal/stringz"
+	"github.com/phrase/phraseapp-client/internal/versions"
+	"github.com/phrase/phraseapp-go/phraseapp"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const configName = ".phraseapp.yml"
+
+// ClientConfig contains the options of the .phraseapp.yml config file that
+// are handled by the client itself instead of phraseapp.Config.
+type ClientConfig struct {
+	// RequiredVersion is a constraint the client version must satisfy,
+	// e.g. ">=2.3.0 <3.0.0".
+	RequiredVersion string
+	// TmpDir is the directory for intermediate files.
+	TmpDir string
+	// ProjectName is the default project, given by name instead of ID.
+	ProjectName string
+	// Branch is the default branch of pull and push.
+	Branch string
+	// BranchBase is the base of branches created by push --create-branch.
+	BranchBase string
+	// LocalesCacheFile and LocalesCacheTTL configure the cache of locale
+	// listings, see localesCacheTTL.
+	LocalesCacheFile string
+	LocalesCacheTTL  string
+	// EmptyLocalesRetries overrides the retries of locale listings without
+	// locales, see emptyLocalesRetries.
+	EmptyLocalesRetries *int
+	// FormatOptionsPresets are named format options, see
+	// formatOptionsPresets.
+	FormatOptionsPresets map[string]map[string]string
+	// FormatByExtension maps file extensions to formats, see
+	// formatByExtension.
+	FormatByExtension map[string]string
+}
+
+// clientConfigKeys returns the config keys mapped to the ClientConfig fields.
+func (cfg *ClientConfig) clientConfigKeys() map[string]interface{} {
+	return map[string]interface{}{
+		"required_version": &cfg.RequiredVersion,
+		"tmp_dir":          &cfg.TmpDir,
+		"project_name":     &cfg.ProjectName,
+		"branch":           &cfg.Branch,
+		"branch_base":      &cfg.BranchBase,
+
+		"locales_cache_file": &cfg.LocalesCacheFile,
+		"locales_cache_ttl":  &cfg.LocalesCacheTTL,
+
+		"empty_locales_retries": &cfg.EmptyLocalesRetries,
+
+		"format_options_presets": &cfg.FormatOptionsPresets,
+
+		"format_by_extension": &cfg.FormatByExtension,
+	}
+}
+
+// ReadConfig reads the .phraseapp.yml config file. The client options are
+// removed before the remaining config is parsed by phraseapp.Config, which
+// rejects unknown keys.
+// The config is read from location if given, which may be a file or a URL.
+// With an environment, its block of the environments key is applied, see
+// applyEnvironment. The overrides of --set are applied last, see
+// applyConfigOverrides.
+func ReadConfig(location, environment string, overrides []string) (*phraseapp.Config, *ClientConfig, error) {
+	content, err := configContent(location)
+	if err != nil {
+		return nil, nil, err
+	}
+	if content == nil {
+		if environment != "" {
+			return nil, nil, fmt.Errorf("environment %q selected, but there is no config", environment)
+		}
+		if len(overrides) > 0 {
+			return nil, nil, fmt.Errorf("--set used, but there is no config")
+		}
+		return &phraseapp.Config{}, &ClientConfig{}, nil
+	}
+	return parseConfig(content, environment, overrides)
+}
+
+func parseConfig(content []byte, environment string, overrides []string) (*phraseapp.Config, *ClientConfig, error) {
+	cfg := &phraseapp.Config{}
+	clientCfg := &ClientConfig{}
+
+	var err error
+	raw := struct {
+		PhraseApp map[string]interface{} `yaml:"phraseapp"`
+	}{}
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, nil, err
+	}
+	if raw.PhraseApp, err = applyEnvironment(raw.PhraseApp, environment); err != nil {
+		return nil, nil, err
+	}
+	if err := applyConfigOverrides(raw.PhraseApp, overrides); err != nil {
+		return nil, nil, err
+	}
+	if err := checkConfigKeys(raw.PhraseApp); err != nil {
+		return nil, nil, err
+	}
+
+	for key, field := range clientCfg.clientConfigKeys() {
+		value, found := raw.PhraseApp[key]
+		if !found {
+			continue
+		}
+		delete(raw.PhraseApp, key)
+
+		switch field := field.(type) {
+		case *string:
+			if *field, err = phraseapp.ValidateIsString(key, value); err != nil {
+				return nil, nil, err
+			}
+		case **int:
+			retries, err := phraseapp.ValidateIsInt(key, value)
+			if err != nil {
+				return nil, nil, err
+			}
+			*field = &retries
+		case *map[string]map[string]string:
+			if *field, err = parseFormatOptionsPresets(value); err != nil {
+				return nil, nil, err
+			}
+		case *map[string]string:
+			if *field, err = parseFormatByExtension(value); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	content, err = yaml.Marshal(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rawCfg := struct{ PhraseApp *phraseapp.Config }{PhraseApp: cfg}
+	if err := yaml.Unmarshal(content, rawCfg); err != nil {
+		return nil, nil, err
+	}
+	return cfg, clientCfg, nil
+}
+
+// configContent returns the content of the config at location. Without a
+// location, the config file phraseapp.ReadConfig would read is used. It
+// returns nil if there is none.
+func configContent(location string) ([]byte, error) {
+	source := "--config option"
+	if location == "" {
+		location = os.Getenv("PHRASEAPP_CONFIG")
+		source = "PHRASEAPP_CONFIG environment variable"
+	}
+
+	if isRemoteConfig(location) {
+		return remoteConfigContent(location, remoteConfigTTL)
+	}
+
+	if location != "" {
+		content, err := ioutil.ReadFile(location)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("file %q (from %s) doesn't exist", location, source)
+		}
+		return content, err
+	}
+
+	paths := []string{}
+	if workingDir, err := os.Getwd(); err == nil {
+		paths = append(paths, filepath.Join(workingDir, configName))
+	}
+	if home := homeDir(); home != "" {
+		paths = append(paths, filepath.Join(home, configName))
+	}
+
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return ioutil.ReadFile(path)
+		}
+	}
+	return nil, nil
+}
+
+// homeDir returns the home directory of the user, empty if it's unknown. HOME
+// is not set on Windows, where USERPROFILE is used instead.
+func homeDir() string {
+	for _, name := range []string{"HOME", "USERPROFILE"} {
+		if dir := os.Getenv(name); dir != "" {
+			return dir
+		}
+	}
+	if u, err := user.Current(); err == nil {
+		return u.HomeDir
+	}
+	return ""
+}
+
+// ValidateVersion checks that the running client satisfies the required
+// version of the config. Development builds are not checked.
+func ValidateVersion(cfg *ClientConfig) error {
+	if cfg == nil || cfg.RequiredVersion == "" {
+		return nil
+	}
+
+	if stringz.ContainsAnySub(strings.ToLower(PHRASEAPP_CLIENT_VERSION), []string{"dev", "test"}) {
+		return nil
+	}
+
+	ok, err := versions.Satisfies(PHRASEAPP_CLIENT_VERSION, cfg.RequiredVersion)
+	if err != nil {
+		return fmt.Errorf("required_version: %s", err)
+	}
+	if !ok {
+		return fmt.Errorf("This configuration requires a PhraseApp client version %s, but you're running %s. Please update from https://phraseapp.com/en/cli", cfg.RequiredVersion, PHRASEAPP_CLIENT_VERSION)
+	}
+	return nil
+}
+
+// configFlag removes the --config option from args and returns its value
+// along with the remaining arguments. The config is read before the command
+// line is parsed, so the option is handled for all commands here.
+func configFlag(args []string) (string, []string, error) {
+	return globalFlag(args, "config", "a file or URL")
+}
+
+// envFlag removes the --env option from args and returns its value along
+// with the remaining arguments. Like --config it's needed to read the config.
+func envFlag(args []string) (string, []string, error) {
+	return globalFlag(args, "env", "an environment name")
+}
+
+// setFlags removes all --set options from args and returns their values
+// along with the remaining arguments.
+func setFlags(args []string) ([]string, []string, error) {
+	return globalFlagValues(args, "set", "a key=value pair")
+}
+
+// headerFlags removes all --header options from args and returns their
+// values along with the remaining arguments. Each option is one header, so
+// values may contain commas.
+func headerFlags(args []string) ([]string, []string, error) {
+	return globalFlagValues(args, "header", "a header like 'Name: value'")
+}
+
+// refreshFlag removes the --refresh option from args and returns whether it
+// was given along with the remaining arguments.
+func refreshFlag(args []string) (bool, []string) {
+	return globalBoolFlag(args, "refresh")
+}
+
+// noColorFlag removes the --no-color option from args and returns whether it
+// was given along with the remaining arguments.
+func noColorFlag(args []string) (bool, []string) {
+	return globalBoolFlag(args, "no-color")
+}
+
+// errorPolicyFlag removes the --error-policy option from args and returns its
+// value along with the remaining arguments.
+func errorPolicyFlag(args []string) (string, []string, error) {
+	return globalFlag(args, "error-policy", "fail-fast or collect")
+}
+
+func globalBoolFlag(args []string, name string) (bool, []string) {
+	value := false
+	rest := []string{}
+	for _, arg := range args {
+		if arg == "--"+name || strings.HasPrefix(arg, "--"+name+"=") {
+			value = arg == "--"+name || arg == "--"+name+"=true"
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return value, rest
+}
+
+func globalFlag(args []string, name, valueDescription string) (string, []string, error) {
+	values, rest, err := globalFlagValues(args, name, valueDescription)
+	if err != nil || len(values) == 0 {
+		return "", rest, err
+	}
+	return values[len(values)-1], rest, nil
+}
+
+// globalFlagValues returns the values of all occurrences of the option.
+func globalFlagValues(args []string, name, valueDescription string) ([]string, []string, error) {
+	values := []string{}
+	rest := []string{}
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; {
+		case arg == "--"+name:
+			if i+1 >= len(args) {
+				return nil, nil, fmt.Errorf("--%s requires %s", name, valueDescription)
+			}
+			values = append(values, args[i+1])
+			i++
+		case strings.HasPrefix(arg, "--"+name+"="):
+			values = append(values, strings.TrimPrefix(arg, "--"+name+"="))
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return values, rest, nil
+}
+
+// translateLegacyPlaceholders converts placeholders of the legacy :locale:
+// syntax in the file pattern of a source or target, so old configs keep
+// working, and warns about it.
+func translateLegacyPlaceholders(file string) string {
+	translated, ok := placeholders.TranslateLegacy(file)
+	if ok {
+		warn("%s uses the legacy placeholder syntax, please change it to %s", file, translated)
+	}
+	return translated
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// environmentsKey is the key of the phraseapp section holding the blocks of
+// the environments selectable with --env.
+const environmentsKey = "environments"
+
+// applyEnvironment returns the phraseapp section of the config with the
+// block of the given environment applied. The block has the same structure
+// as the section and is merged into it: mappings like push or pull are
+// merged key by key, all other values, including the lists of sources and
+// targets, are replaced by the ones of the environment. The environments
+// themselves are removed from the section. Without an environment the
+// section is used as is.
+func applyEnvironment(section map[string]interface{}, environment string) (map[string]interface{}, error) {
+	rawEnvironments, found := section[environmentsKey]
+	base := map[string]interface{}{}
+	for key, value := range section {
+		if key != environmentsKey {
+			base[key] = value
+		}
+	}
+	if environment == "" {
+		return base, nil
+	}
+
+	environments, ok := stringKeyMap(rawEnvironments)
+	if !found || !ok {
+		return nil, fmt.Errorf("environment %q selected, but the config defines no environments", environment)
+	}
+
+	rawBlock, found := environments[environment]
+	if !found {
+		names := sortedKeys(environments)
+		return nil, fmt.Errorf("environment %q is not defined in the config, available environments: %s", environment, strings.Join(names, ", "))
+	}
+	if rawBlock == nil {
+		return base, nil
+	}
+	block, ok := stringKeyMap(rawBlock)
+	if !ok {
+		return nil, fmt.Errorf("environments.%s must be a mapping of config keys, got %T", environment, rawBlock)
+	}
+	if _, found := block[environmentsKey]; found {
+		return nil, fmt.Errorf("environments.%s must not define environments", environment)
+	}
+	return mergeConfigSection(base, block), nil
+}
+
+// mergeConfigSection returns base with the values of override applied.
+// Mappings are merged recursively, other values are replaced.
+func mergeConfigSection(base, override map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		baseChild, baseIsMap := stringKeyMap(merged[key])
+		overrideChild, overrideIsMap := stringKeyMap(value)
+		if baseIsMap && overrideIsMap {
+			merged[key] = mergeConfigSection(baseChild, overrideChild)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
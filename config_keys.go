@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// configKeysHint is appended to errors of unknown config keys.
+const configKeysHint = "see https://phraseapp.com/docs/developers/cli/configuration/"
+
+// topLevelConfigKeys are the keys of the phraseapp section, parsed by
+// phraseapp.Config and ClientConfig.
+func topLevelConfigKeys() []string {
+	keys := []string{
+		"access_token", "host", "debug", "page", "per_page",
+		"project_id", "file_format", "push", "pull", "defaults",
+	}
+	for key := range new(ClientConfig).clientConfigKeys() {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// misplacedConfigKeys are keys used at the wrong level of the config, mapped
+// to where they belong.
+var misplacedConfigKeys = map[string]string{
+	"sources": "push.sources",
+	"targets": "pull.targets",
+}
+
+// checkConfigKeys looks for unknown keys in the phraseapp section of the
+// config which are likely typos of known keys, like file-format instead of
+// file_format. Unmarshalling would otherwise fail without pointing to the
+// fix, or silently ignore the key. Unknown keys without a suggestion are
+// left to the regular parsing.
+func checkConfigKeys(section map[string]interface{}) error {
+	hints := []string{}
+	add := func(path string, raw map[string]interface{}, known []string) {
+		hints = append(hints, configKeyHints(path, raw, known)...)
+	}
+
+	add("phraseapp", section, topLevelConfigKeys())
+
+	if push, ok := stringKeyMap(section["push"]); ok {
+		add("phraseapp.push", push, []string{"sources", "defaults"})
+		sourceKeys := keysOf(new(Source).configKeys(nil))
+		for i, source := range listOf(push["sources"]) {
+			if raw, ok := stringKeyMap(source); ok {
+				add(fmt.Sprintf("phraseapp.push.sources[%d]", i), raw, sourceKeys)
+			}
+		}
+	}
+
+	if pull, ok := stringKeyMap(section["pull"]); ok {
+		add("phraseapp.pull", pull, []string{"targets"})
+		targetKeys := keysOf(new(Target).configKeys(nil, nil, nil))
+		for i, target := range listOf(pull["targets"]) {
+			if raw, ok := stringKeyMap(target); ok {
+				add(fmt.Sprintf("phraseapp.pull.targets[%d]", i), raw, targetKeys)
+			}
+		}
+	}
+
+	if len(hints) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s\n%s", strings.Join(hints, "\n"), configKeysHint)
+}
+
+// configKeyHints returns a hint for each key of raw which isn't known, but
+// resembles a known key.
+func configKeyHints(path string, raw map[string]interface{}, known []string) []string {
+	isKnown := map[string]bool{}
+	for _, key := range known {
+		isKnown[key] = true
+	}
+
+	hints := []string{}
+	for _, key := range sortedKeys(raw) {
+		if isKnown[key] {
+			continue
+		}
+		if path == "phraseapp" {
+			if location, found := misplacedConfigKeys[key]; found {
+				hints = append(hints, fmt.Sprintf("configuration key %q unknown in %s, did you mean %q?", key, path, location))
+				continue
+			}
+		}
+		if suggestion := suggestConfigKey(key, known); suggestion != "" {
+			hints = append(hints, fmt.Sprintf("configuration key %q unknown in %s, did you mean %q?", key, path, suggestion))
+		}
+	}
+	return hints
+}
+
+// suggestConfigKey returns the known key most similar to key, or an empty
+// string if none is similar enough.
+func suggestConfigKey(key string, known []string) string {
+	normalized := strings.NewReplacer("-", "_", " ", "_").Replace(strings.ToLower(key))
+
+	sorted := append([]string{}, known...)
+	sort.Strings(sorted)
+	for _, candidate := range sorted {
+		if normalized == candidate || normalized+"s" == candidate || normalized == candidate+"s" {
+			return candidate
+		}
+	}
+
+	if len(normalized) <= 3 {
+		return ""
+	}
+	best, bestDistance := "", 3
+	for _, candidate := range sorted {
+		if distance := levenshtein(normalized, candidate); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// levenshtein returns the number of single character edits to change a into b.
+func levenshtein(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, minInt(current[j-1]+1, previous[j-1]+cost))
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// stringKeyMap converts a mapping decoded from YAML to a map with string keys.
+func stringKeyMap(value interface{}) (map[string]interface{}, bool) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		return value, true
+	case map[interface{}]interface{}:
+		result := map[string]interface{}{}
+		for key, child := range value {
+			result[fmt.Sprint(key)] = child
+		}
+		return result, true
+	}
+	return nil, false
+}
+
+func listOf(value interface{}) []interface{} {
+	list, _ := value.([]interface{})
+	return list
+}
+
+func keysOf(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := keysOf(m)
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// applyConfigOverrides applies the --set overrides to the phraseapp section
+// of the config. Each override has the form path=value, the path is the
+// dotted path of a config key below phraseapp, with list indexes as
+// segments, e.g. push.sources.0.file_format=xliff.
+//
+// All segments but the last must exist. The last one must either exist or be
+// a known key at its level, e.g. a source option that isn't set yet. Values
+// replacing strings, booleans or numbers must be of the same type, mappings
+// and lists can't be replaced. New keys get the value as YAML scalar.
+func applyConfigOverrides(section map[string]interface{}, overrides []string) error {
+	for _, override := range overrides {
+		path, value, err := splitConfigOverride(override)
+		if err != nil {
+			return err
+		}
+		if err := setConfigValue(section, path, value); err != nil {
+			return fmt.Errorf("--set %s: %s", override, err)
+		}
+	}
+	return nil
+}
+
+func splitConfigOverride(override string) ([]string, string, error) {
+	parts := strings.SplitN(override, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return nil, "", fmt.Errorf("--set %q must have the form key=value, e.g. push.sources.0.file_format=xliff", override)
+	}
+	path := strings.Split(strings.TrimPrefix(parts[0], "phraseapp."), ".")
+	for _, segment := range path {
+		if segment == "" {
+			return nil, "", fmt.Errorf("--set %q has an empty key segment", override)
+		}
+	}
+	return path, parts[1], nil
+}
+
+// setConfigValue sets the value at path below section. Mappings and lists on
+// the path are modified in place.
+func setConfigValue(section map[string]interface{}, path []string, raw string) error {
+	var current interface{} = section
+	for i, segment := range path {
+		last := i == len(path)-1
+		parentPath := strings.Join(path[:i], ".")
+
+		switch container := current.(type) {
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(container) {
+				return fmt.Errorf("%s has %d entries, %q is no valid index", parentPath, len(container), segment)
+			}
+			if !last {
+				current = container[index]
+				continue
+			}
+			value, err := overrideValue(container[index], raw, configKeyType(path))
+			if err != nil {
+				return err
+			}
+			container[index] = value
+		case map[string]interface{}, map[interface{}]interface{}:
+			existing, found := mapValue(container, segment)
+			if !last {
+				if !found {
+					return fmt.Errorf("%s doesn't exist in the config", strings.Join(path[:i+1], "."))
+				}
+				current = existing
+				continue
+			}
+			if !found && !knownConfigKey(path) {
+				return fmt.Errorf("%s doesn't exist in the config and is no known key", strings.Join(path, "."))
+			}
+			value, err := overrideValue(existing, raw, configKeyType(path))
+			if err != nil {
+				return err
+			}
+			setMapValue(container, segment, value)
+		default:
+			return fmt.Errorf("%s is no mapping or list", parentPath)
+		}
+	}
+	return nil
+}
+
+// overrideValue converts raw to the type of the existing value. Without an
+// existing value, raw is converted to the type of the config key template if
+// known, or parsed as YAML scalar.
+func overrideValue(existing interface{}, raw string, template interface{}) (interface{}, error) {
+	if existing == nil {
+		existing = template
+	}
+
+	switch existing.(type) {
+	case nil:
+		var value interface{}
+		if err := yaml.Unmarshal([]byte(raw), &value); err != nil {
+			return nil, fmt.Errorf("invalid value: %s", err)
+		}
+		switch value.(type) {
+		case map[interface{}]interface{}, []interface{}:
+			return nil, fmt.Errorf("only scalar values can be set")
+		}
+		return value, nil
+	case string:
+		return raw, nil
+	case bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("expected a boolean, got %q", raw)
+		}
+		return value, nil
+	case int:
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("expected an integer, got %q", raw)
+		}
+		return value, nil
+	case float64:
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected a number, got %q", raw)
+		}
+		return value, nil
+	case map[string]interface{}, map[interface{}]interface{}:
+		return nil, fmt.Errorf("a mapping can't be set, set its keys instead")
+	case []interface{}:
+		return nil, fmt.Errorf("a list can't be set, set its entries instead")
+	}
+	return nil, fmt.Errorf("values of type %T can't be set", existing)
+}
+
+// knownConfigKey returns true if the last segment of path is a known key at
+// its level. Keys below mappings without a fixed set of keys, like params,
+// are always known.
+func knownConfigKey(path []string) bool {
+	known := knownConfigKeys(path[:len(path)-1])
+	if known == nil {
+		return true
+	}
+	for _, key := range known {
+		if key == path[len(path)-1] {
+			return true
+		}
+	}
+	return false
+}
+
+// knownConfigKeys returns the keys of the mapping at path, or nil if it has
+// no fixed set of keys.
+func knownConfigKeys(path []string) []string {
+	switch {
+	case len(path) == 0:
+		return topLevelConfigKeys()
+	case len(path) == 1 && path[0] == "push":
+		return []string{"sources", "defaults"}
+	case len(path) == 1 && path[0] == "pull":
+		return []string{"targets"}
+	case len(path) == 3 && path[0] == "push" && path[1] == "sources":
+		return keysOf(new(Source).configKeys(nil))
+	case len(path) == 3 && path[0] == "pull" && path[1] == "targets":
+		return keysOf(new(Target).configKeys(nil, nil, nil))
+	}
+	return nil
+}
+
+// configKeyType returns the zero value of the type of the source or target
+// option at path, or nil if unknown.
+func configKeyType(path []string) interface{} {
+	if len(path) != 4 {
+		return nil
+	}
+	var keys map[string]interface{}
+	switch {
+	case path[0] == "push" && path[1] == "sources":
+		keys = new(Source).configKeys(nil)
+	case path[0] == "pull" && path[1] == "targets":
+		keys = new(Target).configKeys(nil, nil, nil)
+	default:
+		return nil
+	}
+	switch keys[path[3]].(type) {
+	case *string:
+		return ""
+	case *bool:
+		return false
+	case *int:
+		return 0
+	case *float64:
+		return 0.0
+	}
+	return nil
+}
+
+func mapValue(container interface{}, key string) (interface{}, bool) {
+	switch container := container.(type) {
+	case map[string]interface{}:
+		value, found := container[key]
+		return value, found
+	case map[interface{}]interface{}:
+		for k, value := range container {
+			if fmt.Sprint(k) == key {
+				return value, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func setMapValue(container interface{}, key string, value interface{}) {
+	switch container := container.(type) {
+	case map[string]interface{}:
+		container[key] = value
+	case map[interface{}]interface{}:
+		for k := range container {
+			if fmt.Sprint(k) == key {
+				container[k] = value
+				return
+			}
+		}
+		container[key] = value
+	}
+}
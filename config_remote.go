@@ -0,0 +1,85 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// remoteConfigTTL is the time a config fetched from a URL is used without
+// fetching it again.
+const remoteConfigTTL = 15 * time.Minute
+
+var remoteConfigClient = &http.Client{Timeout: 30 * time.Second}
+
+func isRemoteConfig(location string) bool {
+	return strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://")
+}
+
+// remoteConfigCachePath returns the cache of the config at url. As the config
+// is fetched before its tmp_dir option applies, this is only in a configured
+// temp dir if one is set already.
+func remoteConfigCachePath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return tempFilePath(".phraseapp-config-" + hex.EncodeToString(sum[:])[:16] + ".yml")
+}
+
+// remoteConfigContent fetches the config at url. The config is cached for ttl
+// and the cached copy is used regardless of its age if fetching fails. The
+// value of PHRASEAPP_CONFIG_AUTHORIZATION is sent as Authorization header.
+func remoteConfigContent(url string, ttl time.Duration) ([]byte, error) {
+	cachePath := remoteConfigCachePath(url)
+	if stat, err := os.Stat(cachePath); err == nil && time.Since(stat.ModTime()) < ttl {
+		if content, err := ioutil.ReadFile(cachePath); err == nil {
+			return content, nil
+		}
+	}
+
+	content, err := fetchRemoteConfig(url)
+	if err == nil {
+		ioutil.WriteFile(cachePath, content, 0600)
+		return content, nil
+	}
+
+	cached, cacheErr := ioutil.ReadFile(cachePath)
+	if cacheErr != nil {
+		return nil, err
+	}
+	fmt.Fprintf(os.Stderr, "Using cached config, as %s\n", err)
+	return cached, nil
+}
+
+func fetchRemoteConfig(url string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if auth := os.Getenv("PHRASEAPP_CONFIG_AUTHORIZATION"); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	resp, err := remoteConfigClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetching config from %s failed: %s", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching config from %s failed: %s", url, resp.Status)
+	}
+
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("fetching config from %s failed: %s", url, err)
+	}
+
+	if _, _, err := parseConfig(content, "", nil); err != nil {
+		return nil, fmt.Errorf("config fetched from %s is invalid: %s", url, err)
+	}
+	return content, nil
+}
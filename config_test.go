@@ -0,0 +1,551 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadConfigClientOptions(t *testing.T) {
+	f, err := ioutil.TempFile("", "phraseapp-config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	f.WriteString(`phraseapp:
+  project_id: project-id
+  required_version: ">=1.0.0 <2.0.0"
+  push:
+    sources:
+    - file: ./<locale_code>.json
+`)
+	f.Close()
+
+	defer os.Setenv("PHRASEAPP_CONFIG", os.Getenv("PHRASEAPP_CONFIG"))
+	os.Setenv("PHRASEAPP_CONFIG", f.Name())
+
+	cfg, clientCfg, err := ReadConfig("", "", nil)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	if cfg.DefaultProjectID != "project-id" {
+		t.Errorf("expected project id to be read, got %q", cfg.DefaultProjectID)
+	}
+	if len(cfg.Sources) == 0 {
+		t.Errorf("expected push sources to be read")
+	}
+	if clientCfg.RequiredVersion != ">=1.0.0 <2.0.0" {
+		t.Errorf("expected required version to be read, got %q", clientCfg.RequiredVersion)
+	}
+}
+
+func TestFormatOptionsPreset(t *testing.T) {
+	cfg, clientCfg, err := parseConfig([]byte(`phraseapp:
+  project_id: project-id
+  format_options_presets:
+    xliff_strict:
+      include_translation_state: true
+      enclose_in_cdata: true
+  push:
+    sources:
+    - file: ./<locale_code>.xlf
+      format_options_preset: xliff_strict
+      params:
+        format_options:
+          enclose_in_cdata: false
+  pull:
+    targets:
+    - file: ./<locale_code>.xlf
+      format_options_preset: xliff_strict
+`), "", nil)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	defer func(original map[string]map[string]string) { formatOptionsPresets = original }(formatOptionsPresets)
+	formatOptionsPresets = clientCfg.FormatOptionsPresets
+
+	sources, err := SourcesFromConfig(*cfg)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	exp := map[string]string{"include_translation_state": "true", "enclose_in_cdata": "false"}
+	if !reflect.DeepEqual(sources[0].Params.FormatOptions, exp) {
+		t.Errorf("expected source format options %v, got %v", exp, sources[0].Params.FormatOptions)
+	}
+
+	targets, err := TargetsFromConfig(*cfg)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	exp = map[string]string{"include_translation_state": "true", "enclose_in_cdata": "true"}
+	if !reflect.DeepEqual(targets[0].Params.FormatOptions, exp) {
+		t.Errorf("expected target format options %v, got %v", exp, targets[0].Params.FormatOptions)
+	}
+
+	formatOptionsPresets = nil
+	if _, err := TargetsFromConfig(*cfg); err == nil {
+		t.Errorf("expected an error for an undefined preset")
+	}
+}
+
+func TestValidateVersion(t *testing.T) {
+	defer func(version string) { PHRASEAPP_CLIENT_VERSION = version }(PHRASEAPP_CLIENT_VERSION)
+
+	PHRASEAPP_CLIENT_VERSION = "1.17.1"
+	if err := ValidateVersion(&ClientConfig{RequiredVersion: ">=1.17.0 <2.0.0"}); err != nil {
+		t.Errorf("didn't expect an error, got: %s", err)
+	}
+	if err := ValidateVersion(&ClientConfig{RequiredVersion: ">=2.0.0"}); err == nil {
+		t.Errorf("expected an error for an unsatisfied version")
+	}
+
+	PHRASEAPP_CLIENT_VERSION = "DEV"
+	if err := ValidateVersion(&ClientConfig{RequiredVersion: ">=2.0.0"}); err != nil {
+		t.Errorf("didn't expect an error for development versions, got: %s", err)
+	}
+}
+
+func TestRemoteConfigContent(t *testing.T) {
+	config := "phraseapp:\n  project_id: remote-project\n"
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		io.WriteString(w, config)
+	}))
+	defer server.Close()
+	defer os.Remove(remoteConfigCachePath(server.URL))
+
+	defer os.Setenv("PHRASEAPP_CONFIG_AUTHORIZATION", os.Getenv("PHRASEAPP_CONFIG_AUTHORIZATION"))
+	os.Setenv("PHRASEAPP_CONFIG_AUTHORIZATION", "Bearer secret")
+
+	cfg, _, err := ReadConfig(server.URL, "", nil)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if cfg.DefaultProjectID != "remote-project" {
+		t.Errorf("expected project id from remote config, got %q", cfg.DefaultProjectID)
+	}
+
+	// the expired cache is used if the config can't be fetched
+	fail = true
+	content, err := remoteConfigContent(server.URL, 0)
+	if err != nil {
+		t.Fatalf("expected the cached config to be used, got: %s", err)
+	}
+	if string(content) != config {
+		t.Errorf("expected cached config %q, got %q", config, content)
+	}
+
+	os.Remove(remoteConfigCachePath(server.URL))
+	if _, err := remoteConfigContent(server.URL, 0); err == nil {
+		t.Errorf("expected an error without cached config")
+	}
+}
+
+func TestConfigFlag(t *testing.T) {
+	location, args, err := configFlag([]string{"pull", "--config", "https://example.com/.phraseapp.yml", "--branch", "main"})
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if location != "https://example.com/.phraseapp.yml" {
+		t.Errorf("expected config location to be extracted, got %q", location)
+	}
+	if strings.Join(args, " ") != "pull --branch main" {
+		t.Errorf("expected remaining arguments, got %v", args)
+	}
+}
+
+func TestLegacyPlaceholders(t *testing.T) {
+	defer func(original *warningCollector) { warnings = original }(warnings)
+	warnings = &warningCollector{}
+
+	cfg, _, err := parseConfig([]byte(`phraseapp:
+  project_id: project-id
+  push:
+    sources:
+    - file: ./locales/:tag:/:locale:.yml
+  pull:
+    targets:
+    - file: ./locales/:locale_name:.yml
+`), "", nil)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	sources, err := SourcesFromConfig(*cfg)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if exp := "./locales/<tag>/<locale_code>.yml"; sources[0].File != exp {
+		t.Errorf("expected source file %q, got %q", exp, sources[0].File)
+	}
+
+	targets, err := TargetsFromConfig(*cfg)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if exp := "./locales/<locale_name>.yml"; targets[0].File != exp {
+		t.Errorf("expected target file %q, got %q", exp, targets[0].File)
+	}
+
+	if messages := warnings.list(); len(messages) != 2 {
+		t.Errorf("expected a warning per legacy pattern, got %v", messages)
+	}
+
+	legacy, current := new(LocaleFile), new(LocaleFile)
+	if err := legacy.fillFromPath("locales/web/en.yml", sources[0].File); err != nil {
+		t.Fatal(err)
+	}
+	if err := current.fillFromPath("locales/web/en.yml", "./locales/<tag>/<locale_code>.yml"); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(legacy, current) || legacy.Code != "en" || legacy.Tag != "web" {
+		t.Errorf("expected the legacy pattern to resolve like the current one, got %+v and %+v", legacy, current)
+	}
+}
+
+func TestConfigKeyTypos(t *testing.T) {
+	for name, tc := range map[string]struct {
+		config   string
+		expected []string
+	}{
+		"dash instead of underscore": {
+			config:   "phraseapp:\n  file-format: yml\n",
+			expected: []string{`configuration key "file-format" unknown in phraseapp, did you mean "file_format"?`},
+		},
+		"singular sources": {
+			config:   "phraseapp:\n  push:\n    source:\n    - file: ./<locale_code>.yml\n",
+			expected: []string{`configuration key "source" unknown in phraseapp.push, did you mean "sources"?`},
+		},
+		"misplaced targets": {
+			config:   "phraseapp:\n  targets:\n  - file: ./<locale_code>.yml\n",
+			expected: []string{`configuration key "targets" unknown in phraseapp, did you mean "pull.targets"?`},
+		},
+		"typos in targets": {
+			config: "phraseapp:\n  pull:\n    targets:\n    - file: ./<locale_code>.yml\n    - file: ./<locale_code>.json\n      project-id: abcd\n      ouptut_template: x\n",
+			expected: []string{
+				`configuration key "ouptut_template" unknown in phraseapp.pull.targets[1], did you mean "output_template"?`,
+				`configuration key "project-id" unknown in phraseapp.pull.targets[1], did you mean "project_id"?`,
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := parseConfig([]byte(tc.config), "", nil)
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+			for _, exp := range tc.expected {
+				if !strings.Contains(err.Error(), exp) {
+					t.Errorf("expected error to contain %q, got: %s", exp, err)
+				}
+			}
+		})
+	}
+
+	if _, _, err := parseConfig([]byte("phraseapp:\n  project_id: abcd\n  pull:\n    targets:\n    - file: ./<locale_code>.yml\n      write_locale_details: true\n"), "", nil); err != nil {
+		t.Errorf("didn't expect an error for known keys, got: %s", err)
+	}
+}
+
+func TestConfigEnvironments(t *testing.T) {
+	config := []byte(`phraseapp:
+  access_token: base-token
+  project_id: base-project
+  file_format: yml
+  push:
+    sources:
+    - file: ./locales/<locale_code>.yml
+  pull:
+    targets:
+    - file: ./locales/<locale_code>.yml
+  environments:
+    staging:
+      project_id: staging-project
+    prod:
+      access_token: prod-token
+      project_id: prod-project
+      pull:
+        targets:
+        - file: ./dist/<locale_code>.json
+          file_format: nested_json
+`)
+
+	for environment, exp := range map[string]struct {
+		token, projectID, sourceFile, targetFile string
+	}{
+		"":        {"base-token", "base-project", "./locales/<locale_code>.yml", "./locales/<locale_code>.yml"},
+		"staging": {"base-token", "staging-project", "./locales/<locale_code>.yml", "./locales/<locale_code>.yml"},
+		"prod":    {"prod-token", "prod-project", "./locales/<locale_code>.yml", "./dist/<locale_code>.json"},
+	} {
+		cfg, _, err := parseConfig(config, environment, nil)
+		if err != nil {
+			t.Fatalf("%q: didn't expect an error, got: %s", environment, err)
+		}
+		if cfg.Credentials.Token != exp.token || cfg.DefaultProjectID != exp.projectID {
+			t.Errorf("%q: expected token %q and project %q, got %q and %q", environment, exp.token, exp.projectID, cfg.Credentials.Token, cfg.DefaultProjectID)
+		}
+
+		sources, err := SourcesFromConfig(*cfg)
+		if err != nil {
+			t.Fatalf("%q: didn't expect an error, got: %s", environment, err)
+		}
+		if len(sources) != 1 || sources[0].File != exp.sourceFile || sources[0].ProjectID != exp.projectID {
+			t.Errorf("%q: expected source %q of project %q, got %+v", environment, exp.sourceFile, exp.projectID, sources[0])
+		}
+
+		targets, err := TargetsFromConfig(*cfg)
+		if err != nil {
+			t.Fatalf("%q: didn't expect an error, got: %s", environment, err)
+		}
+		if len(targets) != 1 || targets[0].File != exp.targetFile || targets[0].ProjectID != exp.projectID {
+			t.Errorf("%q: expected target %q of project %q, got %+v", environment, exp.targetFile, exp.projectID, targets[0])
+		}
+	}
+
+	_, _, err := parseConfig(config, "qa", nil)
+	if err == nil || !strings.Contains(err.Error(), "available environments: prod, staging") {
+		t.Errorf("expected an error listing the environments, got: %v", err)
+	}
+}
+
+func TestConfigOverrides(t *testing.T) {
+	config := []byte(`phraseapp:
+  access_token: token
+  project_id: project-id
+  push:
+    sources:
+    - file: ./locales/<locale_code>.yml
+      file_format: yml
+      merge: false
+  pull:
+    targets:
+    - file: ./locales/<locale_code>.yml
+      params:
+        file_format: yml
+`)
+
+	cfg, _, err := parseConfig(config, "", []string{
+		"project_id=other-project",
+		"push.sources.0.file_format=xliff",
+		"push.sources.0.min_keys=5",
+		"pull.targets.0.params.tags=web",
+		"pull.targets.0.parallel=4",
+	})
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if cfg.DefaultProjectID != "other-project" {
+		t.Errorf("expected the project to be overridden, got %q", cfg.DefaultProjectID)
+	}
+
+	sources, err := SourcesFromConfig(*cfg)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if sources[0].FileFormat != "xliff" || sources[0].MinKeys != 5 {
+		t.Errorf("expected file format xliff and min keys 5, got %q and %d", sources[0].FileFormat, sources[0].MinKeys)
+	}
+
+	targets, err := TargetsFromConfig(*cfg)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if tags := targets[0].GetTags(); len(tags) != 1 || tags[0] != "web" || targets[0].Parallel != 4 {
+		t.Errorf("expected tag web and parallel 4, got %v and %d", tags, targets[0].Parallel)
+	}
+
+	for override, msg := range map[string]string{
+		"project_id":                   "must have the form key=value",
+		"push.sources.1.file=x.yml":    "no valid index",
+		"push.sources.0.fille=x.yml":   "no known key",
+		"pull.defaults.file_format=x":  "doesn't exist",
+		"push.sources.0.merge=maybe":   "expected a boolean",
+		"pull.targets.0.parallel=many": "expected an integer",
+		"push.sources=x":               "a list can't be set",
+		"pull=x":                       "a mapping can't be set",
+	} {
+		_, _, err := parseConfig(config, "", []string{override})
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("%s: expected an error containing %q, got: %v", override, msg, err)
+		}
+	}
+}
+
+func TestSetFlags(t *testing.T) {
+	overrides, args, err := setFlags([]string{"push", "--set", "project_id=a", "--wait", "--set=push.sources.0.file_format=xliff"})
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if strings.Join(overrides, " ") != "project_id=a push.sources.0.file_format=xliff" || strings.Join(args, " ") != "push --wait" {
+		t.Errorf("expected overrides to be extracted, got %v and %v", overrides, args)
+	}
+}
+
+func TestEnvFlag(t *testing.T) {
+	environment, args, err := envFlag([]string{"push", "--env=staging", "--wait"})
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if environment != "staging" || strings.Join(args, " ") != "push --wait" {
+		t.Errorf("expected environment to be extracted, got %q and %v", environment, args)
+	}
+	if _, _, err := envFlag([]string{"pull", "--env"}); err == nil {
+		t.Errorf("expected an error without environment name")
+	}
+}
+
+func TestNoColorFlag(t *testing.T) {
+	noColor, args := noColorFlag([]string{"pull", "--no-color", "--branch", "feature"})
+	if !noColor || len(args) != 3 {
+		t.Errorf("expected --no-color to be extracted, got %v and %v", noColor, args)
+	}
+	if noColor, _ := noColorFlag([]string{"push", "--no-color=false"}); noColor {
+		t.Errorf("expected color with --no-color=false")
+	}
+}
+
+func TestFormatByExtension(t *testing.T) {
+	cfg, clientCfg, err := parseConfig([]byte(`phraseapp:
+  project_id: project-id
+  file_format: json
+  format_by_extension:
+    arb: flutter_arb
+    .XLIFF: xliff
+  push:
+    sources:
+    - file: ./<locale_code>.arb
+    - file: ./<locale_code>.xliff
+    - file: ./<locale_code>.json
+    - file: ./<locale_code>.arb
+      params:
+        file_format: arb
+  pull:
+    targets:
+    - file: ./<locale_code>.arb
+    - file: ./<locale_code>.Xliff
+    - file: ./<locale_code>.json
+    - file: ./<locale_code>.arb
+      file_format: arb
+`), "", nil)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	defer func(original map[string]string) { formatByExtension = original }(formatByExtension)
+	formatByExtension = clientCfg.FormatByExtension
+
+	exp := []string{"flutter_arb", "xliff", "json", "arb"}
+
+	sources, err := SourcesFromConfig(*cfg)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	formats := []string{}
+	for _, source := range sources {
+		formats = append(formats, source.GetFileFormat())
+	}
+	if !reflect.DeepEqual(formats, exp) {
+		t.Errorf("expected source formats %v, got %v", exp, formats)
+	}
+
+	targets, err := TargetsFromConfig(*cfg)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	formats = []string{}
+	for _, target := range targets {
+		formats = append(formats, target.GetFormat())
+	}
+	if !reflect.DeepEqual(formats, exp) {
+		t.Errorf("expected target formats %v, got %v", exp, formats)
+	}
+
+	if _, _, err := parseConfig([]byte("phraseapp:\n  format_by_extension:\n    arb: [flutter_arb]\n"), "", nil); err == nil {
+		t.Errorf("expected an error for a format which isn't a name")
+	}
+}
+
+func TestFormatOptionsJSON(t *testing.T) {
+	options, err := parseFormatOptionsJSON(`{"enclose_in_cdata": true, "indent_size": 4, "indent_style": "tab"}`)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	exp := map[string]string{"enclose_in_cdata": "true", "indent_size": "4", "indent_style": "tab"}
+	if !reflect.DeepEqual(options, exp) {
+		t.Errorf("expected format options %v, got %v", exp, options)
+	}
+
+	for _, invalid := range []string{`{"enclose_in_cdata": tru}`, `["enclose_in_cdata"]`, `{"nested": {"a": 1}}`, `{"list": [1]}`, `{"a": null}`, `{} {}`} {
+		if _, err := parseFormatOptionsJSON(invalid); err == nil {
+			t.Errorf("expected an error for %s", invalid)
+		}
+	}
+
+	cfg, _, err := parseConfig([]byte(`phraseapp:
+  project_id: project-id
+  pull:
+    targets:
+    - file: ./<locale_code>.xlf
+      params:
+        format_options:
+          enclose_in_cdata: false
+          include_notes: true
+`), "", nil)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	targets, err := TargetsFromConfig(*cfg)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	merged := withFormatOptions(targets[0].Params.FormatOptions, options)
+	exp = map[string]string{"enclose_in_cdata": "true", "include_notes": "true", "indent_size": "4", "indent_style": "tab"}
+	if !reflect.DeepEqual(merged, exp) {
+		t.Errorf("expected the JSON options to take precedence over the config, got %v", merged)
+	}
+	if targets[0].Params.FormatOptions["enclose_in_cdata"] != "false" {
+		t.Errorf("expected the options of the config to be left untouched")
+	}
+}
+
+func TestConfigContentHomeDir(t *testing.T) {
+	home := setupFiles(t)
+	defer os.RemoveAll(home)
+	wd := setupFiles(t)
+	defer os.RemoveAll(wd)
+	defer pushd(t, wd)()
+
+	if err := ioutil.WriteFile(filepath.Join(home, configName), []byte("phraseapp:\n  project_id: home\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, value := range map[string]string{"PHRASEAPP_CONFIG": "", "HOME": "", "USERPROFILE": home} {
+		defer os.Setenv(name, os.Getenv(name))
+		os.Setenv(name, value)
+	}
+
+	content, err := configContent("")
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if !strings.Contains(string(content), "project_id: home") {
+		t.Errorf("expected the config of USERPROFILE to be read, got %q", content)
+	}
+}
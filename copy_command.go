@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/phrase/phraseapp-client/internal/print"
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+type CopyCommand struct {
+	phraseapp.Config
+	FromProject string   `cli:"opt --from-project required desc='Project to copy translations from'"`
+	ToProject   string   `cli:"opt --to-project required desc='Project to copy translations to'"`
+	Locales     []string `cli:"opt --locale required desc='Locales to copy by code, name or ID, comma separated'"`
+	Tags        string   `cli:"opt --tag desc='Only copy keys with these tags, comma separated'"`
+	FileFormat  string   `cli:"opt --file-format default=simple_json desc='Format used to transfer the translations'"`
+}
+
+func (cmd *CopyCommand) Run() error {
+	if cmd.Config.Debug {
+		// suppresses content output
+		cmd.Config.Debug = false
+		Debug = true
+	}
+
+	if cmd.FromProject == cmd.ToProject {
+		return fmt.Errorf("--from-project and --to-project must be different projects")
+	}
+
+	client, err := newClient(cmd.Config.Credentials, cmd.Config.Debug)
+	if err != nil {
+		return err
+	}
+
+	if err := checkWriteAccess(client, nil); err != nil {
+		return err
+	}
+
+	formatMap, err := formatsByApiName(client)
+	if err != nil {
+		return fmt.Errorf("Error retrieving format list from PhraseApp: %s", err)
+	}
+	format, ok := formatMap[cmd.FileFormat]
+	if !ok || !format.Importable || !format.Exportable {
+		return fmt.Errorf("Format %q can't be used to copy translations, as it must support uploads and downloads", cmd.FileFormat)
+	}
+
+	remoteLocales, err := RemoteLocales(client, LocaleCacheKey{ProjectID: cmd.FromProject})
+	if err != nil {
+		return err
+	}
+
+	dir, err := ioutil.TempDir(tempDirFor(""), "phraseapp-copy")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	target := &Target{
+		ProjectID: cmd.FromProject,
+		Params:    &PullParams{},
+	}
+	target.Params.FileFormat = &cmd.FileFormat
+
+	source := &Source{
+		ProjectID: cmd.ToProject,
+		Format:    format,
+		Params:    &phraseapp.UploadParams{FileFormat: &cmd.FileFormat},
+	}
+
+	if cmd.Tags != "" {
+		target.Params.Tags = &cmd.Tags
+		source.Params.Tags = &cmd.Tags
+	}
+
+	errs := newErrorCollector(errorPolicyCollect)
+	for _, identifier := range cmd.Locales {
+		locale := findLocale(remoteLocales, identifier)
+		if locale == nil {
+			err := fmt.Errorf("no such locale in project %q", cmd.FromProject)
+			print.Failure("%s: %s", identifier, err)
+			if errs.add(identifier, err) {
+				break
+			}
+			continue
+		}
+
+		upload, err := cmd.copyLocale(client, target, source, locale, filepath.Join(dir, locale.ID+"."+format.Extension))
+		if err != nil {
+			print.Failure("%s: %s", locale.Name, err)
+			if errs.add(locale.Name, err) {
+				break
+			}
+			continue
+		}
+		print.Success("Copied %s to project %s (upload ID: %s)", locale.Name, cmd.ToProject, upload.ID)
+	}
+
+	if failed := errs.count(); failed > 0 {
+		return fmt.Errorf("%d of %d locales could not be copied", failed, len(cmd.Locales))
+	}
+	return nil
+}
+
+// copyLocale downloads locale from the project of target to path and uploads
+// it to the project of source, creating the locale there if necessary.
+func (cmd *CopyCommand) copyLocale(client *phraseapp.Client, target *Target, source *Source, locale *phraseapp.Locale, path string) (*phraseapp.Upload, error) {
+	localeFile := &LocaleFile{
+		Name:       locale.Name,
+		Code:       locale.Code,
+		ID:         locale.ID,
+		FileFormat: cmd.FileFormat,
+		Path:       path,
+	}
+
+	if err := target.DownloadAndWriteToFile(client, localeFile, ""); err != nil {
+		return nil, fmt.Errorf("download failed: %s", err)
+	}
+
+	localeDetails, err := source.createLocale(client, localeFile, "")
+	if err != nil {
+		return nil, fmt.Errorf("locale could not be created: %s", err)
+	}
+	localeFile.ID = localeDetails.ID
+
+	upload, err := source.uploadFile(client, localeFile, "")
+	if err != nil {
+		return nil, fmt.Errorf("upload failed: %s", err)
+	}
+	printUploadWarnings(localeFile, upload)
+	return upload.Upload, nil
+}
+
+// findLocale returns the locale with the given code, name or ID.
+func findLocale(locales []*phraseapp.Locale, identifier string) *phraseapp.Locale {
+	for _, locale := range locales {
+		if locale.Code == identifier || locale.Name == identifier || locale.ID == identifier {
+			return locale
+		}
+	}
+	return nil
+}
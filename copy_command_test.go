@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func TestCopyLocale(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-copy-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	uploads := &testHandler{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/projects/from/locales/de-id/download", func(w http.ResponseWriter, r *http.Request) {
+		params := phraseapp.LocaleDownloadParams{}
+		json.NewDecoder(r.Body).Decode(&params)
+		if params.Tags == nil || *params.Tags != "app" {
+			t.Errorf("expected download to be filtered by tag, got %v", params.Tags)
+		}
+		io.WriteString(w, `{"hello": "hallo"}`)
+	})
+	mux.HandleFunc("/v2/projects/to/locales/german", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"id": "to-de-id", "name": "german", "code": "de"}`)
+	})
+	mux.Handle("/v2/projects/to/uploads", uploads)
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	cmd := &CopyCommand{FileFormat: "simple_json", Tags: "app"}
+	target := &Target{ProjectID: "from", Params: &PullParams{}}
+	target.Params.FileFormat = &cmd.FileFormat
+	target.Params.Tags = &cmd.Tags
+	source := &Source{ProjectID: "to", Params: &phraseapp.UploadParams{FileFormat: &cmd.FileFormat}}
+
+	locale := &phraseapp.Locale{ID: "de-id", Name: "german", Code: "de"}
+	if _, err := cmd.copyLocale(client, target, source, locale, filepath.Join(dir, "de-id.json")); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	if uploads.lastLocaleID != "to-de-id" {
+		t.Errorf("expected upload to locale %q, got %q", "to-de-id", uploads.lastLocaleID)
+	}
+	if string(uploads.lastContent) != `{"hello": "hallo"}` {
+		t.Errorf("expected downloaded content to be uploaded, got %q", uploads.lastContent)
+	}
+}
+
+func TestFindLocale(t *testing.T) {
+	locales := getBaseLocales()
+	for _, identifier := range []string{"de", "german", "de-locale-id"} {
+		if locale := findLocale(locales, identifier); locale == nil || locale.Code != "de" {
+			t.Errorf("expected %q to find the de locale, got %v", identifier, locale)
+		}
+	}
+	if locale := findLocale(locales, "fr"); locale != nil {
+		t.Errorf("expected no locale for fr, got %v", locale)
+	}
+}
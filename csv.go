@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/phrase/phraseapp-client/internal/csvcolumns"
+)
+
+// csvOptions are CSV specific options of pull, set with flags:
+//
+//	--csv-delimiter  column_separator  character separating the columns
+//	--csv-columns                      columns written, in this order
+//
+// The delimiter is passed to the server as format option, a column_separator
+// set explicitly in format_options takes precedence. The columns are
+// selected from the downloaded file by the names in its header row.
+type csvOptions struct {
+	Delimiter string
+	Columns   []string
+}
+
+// parseCSVDelimiter returns the delimiter given with --csv-delimiter, which
+// must be a single character or "tab".
+func parseCSVDelimiter(delimiter string) (string, error) {
+	switch delimiter {
+	case "":
+		return "", nil
+	case "tab", `\t`:
+		return "\t", nil
+	}
+	if utf8.RuneCountInString(delimiter) != 1 {
+		return "", fmt.Errorf("--csv-delimiter must be a single character or tab, got %q", delimiter)
+	}
+	return delimiter, nil
+}
+
+// apply returns the given format options extended by the CSV delimiter. The
+// given options are left untouched and returned as is for other formats.
+func (o csvOptions) apply(format string, options map[string]string) map[string]string {
+	if format != "csv" || o.Delimiter == "" {
+		return options
+	}
+
+	result := map[string]string{"column_separator": o.Delimiter}
+	for key, value := range options {
+		result[key] = value
+	}
+	return result
+}
+
+// selectColumns returns content with only the configured columns, in their
+// order. options are the format options content was downloaded with.
+func (o csvOptions) selectColumns(format string, options map[string]string, content []byte) ([]byte, error) {
+	if format != "csv" || len(o.Columns) == 0 {
+		return content, nil
+	}
+
+	delimiter := ','
+	if separator := options["column_separator"]; separator != "" {
+		delimiter, _ = utf8.DecodeRuneInString(separator)
+	}
+	return csvcolumns.Select(content, delimiter, o.Columns)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// The error policies of --error-policy: fail-fast stops a run with several
+// items, like the files of a push or pull, at the first failed item, collect
+// processes all items and reports their errors together at the end.
+const (
+	errorPolicyFailFast = "fail-fast"
+	errorPolicyCollect  = "collect"
+)
+
+// errorPolicy is the policy set with --error-policy. Without it, each command
+// uses its own default, see newErrorCollector.
+var errorPolicy string
+
+// setErrorPolicy sets the policy given with --error-policy, if any.
+func setErrorPolicy(policy string) error {
+	switch policy {
+	case "", errorPolicyFailFast, errorPolicyCollect:
+		errorPolicy = policy
+		return nil
+	}
+	return fmt.Errorf("--error-policy must be %s or %s, got %q", errorPolicyFailFast, errorPolicyCollect, policy)
+}
+
+// errorCollector accumulates the errors of the items of a run according to
+// the error policy. It's safe for concurrent use, all methods may be called
+// on a nil *errorCollector, which fails fast.
+type errorCollector struct {
+	collect bool
+
+	mu     sync.Mutex
+	failed []string
+	first  error
+}
+
+// newErrorCollector returns a collector for the policy of --error-policy, or
+// defaultPolicy if none was given.
+func newErrorCollector(defaultPolicy string) *errorCollector {
+	policy := errorPolicy
+	if policy == "" {
+		policy = defaultPolicy
+	}
+	return &errorCollector{collect: policy == errorPolicyCollect}
+}
+
+// add records the error of item. It returns true if the run must stop,
+// because the policy is fail-fast.
+func (c *errorCollector) add(item string, err error) bool {
+	if c == nil {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.first == nil {
+		c.first = err
+	}
+	c.failed = append(c.failed, fmt.Sprintf("%s: %s", item, err))
+	return !c.collect
+}
+
+// stopped returns true if the run must not start further items.
+func (c *errorCollector) stopped() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return !c.collect && c.first != nil
+}
+
+// count returns the number of failed items.
+func (c *errorCollector) count() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.failed)
+}
+
+// err returns nil if no item failed and the first error with fail-fast or if
+// a single item failed. Otherwise the error lists the errors of all items.
+func (c *errorCollector) err() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case c.first == nil:
+		return nil
+	case !c.collect || len(c.failed) == 1:
+		return c.first
+	}
+	return fmt.Errorf("%d items failed:\n  %s", len(c.failed), strings.Join(c.failed, "\n  "))
+}
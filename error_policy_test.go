@@ -0,0 +1,124 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func TestErrorPolicy(t *testing.T) {
+	defer func(original string) { errorPolicy = original }(errorPolicy)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/locales/de-locale-id/") {
+			io.WriteString(w, "de: {}\n")
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	pull := func(policy string) (error, bool) {
+		t.Helper()
+		dir, err := ioutil.TempDir("", "phraseapp-error-policy-test")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		if err := setErrorPolicy(policy); err != nil {
+			t.Fatalf("didn't expect an error, got: %s", err)
+		}
+		target := getBaseTarget()
+		target.File = filepath.Join(dir, "<locale_code>.yml")
+		target.Parallel = 1
+		target.RemoteLocales = append(target.RemoteLocales, &phraseapp.Locale{Code: "fr", ID: "fr-locale-id", Name: "french"})
+
+		err = target.Pull(client, "")
+		_, statErr := os.Stat(filepath.Join(dir, "de.yml"))
+		return err, statErr == nil
+	}
+
+	err, pulledDe := pull("fail-fast")
+	if err == nil || strings.Contains(err.Error(), "items failed") {
+		t.Errorf("expected the error of the first file with fail-fast, got %v", err)
+	}
+	if pulledDe {
+		t.Errorf("expected the run to stop at the first failed file with fail-fast")
+	}
+
+	err, pulledDe = pull("collect")
+	if err == nil || !strings.Contains(err.Error(), "2 items failed") || !strings.Contains(err.Error(), "en.yml") || !strings.Contains(err.Error(), "fr.yml") {
+		t.Errorf("expected the errors of both failed files with collect, got %v", err)
+	}
+	if !pulledDe {
+		t.Errorf("expected the remaining files to be pulled with collect")
+	}
+
+	if err := setErrorPolicy("ignore"); err == nil {
+		t.Errorf("expected an error for an unknown policy")
+	}
+}
+
+func TestErrorCollector(t *testing.T) {
+	defer func(original string) { errorPolicy = original }(errorPolicy)
+	errorPolicy = ""
+
+	var nilCollector *errorCollector
+	if !nilCollector.add("en.json", io.EOF) || nilCollector.err() != nil {
+		t.Errorf("expected a nil collector to fail fast without recording errors")
+	}
+
+	collector := newErrorCollector(errorPolicyCollect)
+	if collector.add("en.json", io.EOF) || collector.stopped() {
+		t.Errorf("expected the default policy to collect errors")
+	}
+	if collector.err() != io.EOF {
+		t.Errorf("expected a single error as is, got %v", collector.err())
+	}
+
+	errorPolicy = errorPolicyFailFast
+	collector = newErrorCollector(errorPolicyCollect)
+	if !collector.add("en.json", io.EOF) || !collector.stopped() {
+		t.Errorf("expected --error-policy to take precedence over the default")
+	}
+}
+
+func TestMoveFilesErrorPolicy(t *testing.T) {
+	defer func(original string) { errorPolicy = original }(errorPolicy)
+
+	d := setupFiles(t, "b.json")
+	defer os.RemoveAll(d)
+
+	renames := []*fileRename{
+		{From: filepath.Join(d, "a.json"), To: filepath.Join(d, "x", "a.json")},
+		{From: filepath.Join(d, "b.json"), To: filepath.Join(d, "x", "b.json")},
+	}
+
+	setErrorPolicy("fail-fast")
+	if err := moveFiles(renames); err == nil {
+		t.Fatalf("expected an error for the missing file")
+	}
+	if _, err := os.Stat(renames[1].To); err == nil {
+		t.Errorf("expected the run to stop at the first failed file with fail-fast")
+	}
+
+	setErrorPolicy("collect")
+	if err := moveFiles(renames); err == nil {
+		t.Fatalf("expected an error for the missing file")
+	}
+	if _, err := os.Stat(renames[1].To); err != nil {
+		t.Errorf("expected the other files to be moved with collect, got %s", err)
+	}
+}
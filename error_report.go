@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+// errorReport collects the errors of single files of a run, written as JSON
+// with --error-report.
+type errorReport struct {
+	mu      sync.Mutex
+	entries []errorReportEntry
+}
+
+type errorReportEntry struct {
+	Path   string `json:"path"`
+	Locale string `json:"locale"`
+	Error  string `json:"error"`
+	// Status is the HTTP status of the failed request, if known.
+	Status int `json:"status,omitempty"`
+}
+
+func (report *errorReport) add(localeFile *LocaleFile, err error) {
+	if report == nil || err == nil {
+		return
+	}
+	report.mu.Lock()
+	defer report.mu.Unlock()
+
+	report.entries = append(report.entries, errorReportEntry{
+		Path:   localeFile.RelPath(),
+		Locale: localeFile.localeLabel(),
+		Error:  err.Error(),
+		Status: httpStatus(err),
+	})
+}
+
+// write writes the report to path as JSON array sorted by path, an empty
+// array if there were no errors.
+func (report *errorReport) write(path string) error {
+	report.mu.Lock()
+	entries := append([]errorReportEntry{}, report.entries...)
+	report.mu.Unlock()
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+
+	content, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// statusPattern matches the status code in the messages of errors the API
+// client returns for unexpected responses.
+var statusPattern = regexp.MustCompile(`^(\d{3}) - |Unexpected HTTP Status Code \((\d{3})`)
+
+// httpStatus returns the HTTP status of the response err was created for, or
+// 0 if it's unknown.
+func httpStatus(err error) int {
+	switch e := err.(type) {
+	case phraseapp.ErrNotFound:
+		return 404
+	case *phraseapp.ValidationErrorResponse:
+		return 422
+	case *phraseapp.ErrorResponse:
+		return 400
+	case *phraseapp.RateLimitingError:
+		return 429
+	case nil:
+		return 0
+	default:
+		match := statusPattern.FindStringSubmatch(e.Error())
+		if match == nil {
+			return 0
+		}
+		code := match[1]
+		if code == "" {
+			code = match[2]
+		}
+		status, _ := strconv.Atoi(code)
+		return status
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func TestErrorReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-error-report-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer pushd(t, dir)()
+
+	report := &errorReport{}
+	if err := report.write("empty.json"); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := ioutil.ReadFile("empty.json"); string(content) != "[]\n" {
+		t.Errorf("expected an empty array without errors, got %q", content)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/de-locale-id/") {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message": "locale not found"}`)
+			return
+		}
+		io.WriteString(w, "{}")
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	target := getBaseTarget()
+	target.File = "./locales/<locale_code>.json"
+	target.FileFormat = "json"
+	target.SummaryOnly = true
+	target.results = &runResults{}
+	target.errorReport = report
+
+	if err := target.Pull(client, ""); err == nil {
+		t.Fatalf("expected the failed download to fail the pull")
+	}
+	if err := report.write("report.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile("report.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []errorReportEntry{}
+	if err := json.Unmarshal(content, &entries); err != nil {
+		t.Fatalf("expected the report to be valid JSON, got: %s", err)
+	}
+	exp := []errorReportEntry{{Path: filepath.Join("locales", "de.json"), Locale: "de", Error: `{"message": "locale not found"}`, Status: 404}}
+	if !reflect.DeepEqual(entries, exp) {
+		t.Errorf("expected report %+v, got %+v", exp, entries)
+	}
+}
+
+func TestHTTPStatus(t *testing.T) {
+	for err, exp := range map[error]int{
+		phraseapp.ErrNotFound{Message: "not found"}:                                            404,
+		&phraseapp.RateLimitingError{TooManyRequests: true}:                                    429,
+		errors.New("401 - Unauthorized\nThe credentials you provided are invalid."):            401,
+		errors.New("Unexpected HTTP Status Code (502 Bad Gateway) received; expected 200 OK."): 502,
+		errors.New("open locales/en.json: permission denied"):                                  0,
+	} {
+		if status := httpStatus(err); status != exp {
+			t.Errorf("expected status %d for %q, got %d", exp, err, status)
+		}
+	}
+}
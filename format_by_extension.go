@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// formatByExtension maps file extensions, like .arb, to the formats of the
+// format_by_extension config option. Sources and targets without a format
+// of their own use the format of the extension of their file, before the
+// file_format of the config.
+var formatByExtension = map[string]string{}
+
+// parseFormatByExtension converts the raw value of format_by_extension, a
+// map of file extensions to format names. Extensions are matched ignoring
+// case, the leading dot is optional.
+func parseFormatByExtension(value interface{}) (map[string]string, error) {
+	raw, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("format_by_extension must map file extensions to formats, got %T", value)
+	}
+
+	result := map[string]string{}
+	for extension, format := range raw {
+		name, ok := format.(string)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("format_by_extension: the format of %v must be a format name, got %v", extension, format)
+		}
+		result[normalizeExtension(fmt.Sprintf("%v", extension))] = name
+	}
+	return result, nil
+}
+
+// formatForFile returns the format configured in format_by_extension for the
+// extension of path, or "" if there is none.
+func formatForFile(path string) string {
+	return formatByExtension[normalizeExtension(filepath.Ext(path))]
+}
+
+func normalizeExtension(extension string) string {
+	if extension == "" {
+		return ""
+	}
+	return "." + strings.ToLower(strings.TrimPrefix(extension, "."))
+}
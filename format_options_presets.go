@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/phrase/phraseapp-client/internal/placeholders"
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+// formatOptionsPresets are the named format options of the
+// format_options_presets config option. Sources and targets reference them
+// with format_options_preset instead of repeating the options.
+var formatOptionsPresets = map[string]map[string]string{}
+
+// parseFormatOptionsPresets converts the raw value of format_options_presets,
+// a map of preset names to format options.
+func parseFormatOptionsPresets(value interface{}) (map[string]map[string]string, error) {
+	presets, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("format_options_presets must map preset names to format options, got %T", value)
+	}
+
+	result := map[string]map[string]string{}
+	for name, options := range presets {
+		rawOptions, ok := options.(map[interface{}]interface{})
+		if !ok {
+			return nil, fmt.Errorf("format_options_presets: preset %v must contain format options, got %T", name, options)
+		}
+
+		m := map[string]interface{}{}
+		for key, value := range rawOptions {
+			m[fmt.Sprintf("%v", key)] = value
+		}
+
+		converted, err := phraseapp.ConvertToStringMap(m)
+		if err != nil {
+			return nil, fmt.Errorf("format_options_presets: preset %v: %s", name, err)
+		}
+		result[fmt.Sprintf("%v", name)] = converted
+	}
+	return result, nil
+}
+
+// withFormatOptionsPreset returns the format options of the named preset
+// merged with options, the latter taking precedence.
+func withFormatOptionsPreset(name string, options map[string]string) (map[string]string, error) {
+	preset, ok := formatOptionsPresets[name]
+	if !ok {
+		return nil, fmt.Errorf("format_options_preset %q is not defined in format_options_presets", name)
+	}
+
+	result := map[string]string{}
+	for key, value := range preset {
+		result[key] = value
+	}
+	for key, value := range options {
+		result[key] = value
+	}
+	return result, nil
+}
+
+// parseFormatOptionsJSON parses the value of --format-options-json, a JSON
+// object of format options. Values must be strings, numbers or booleans.
+func parseFormatOptionsJSON(value string) (map[string]string, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	raw := map[string]interface{}{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("--format-options-json must be a JSON object: %s", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("--format-options-json must be a single JSON object")
+	}
+
+	options := map[string]string{}
+	for key, value := range raw {
+		switch value := value.(type) {
+		case string:
+			options[key] = value
+		case json.Number:
+			options[key] = value.String()
+		case bool:
+			options[key] = strconv.FormatBool(value)
+		default:
+			return nil, fmt.Errorf("--format-options-json: format option %q must be a string, number or boolean, got %s", key, jsonType(value))
+		}
+	}
+	return options, nil
+}
+
+func jsonType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// withFormatOptions returns options with overrides applied, which take
+// precedence. It returns options as is without overrides.
+func withFormatOptions(options, overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return options
+	}
+
+	result := map[string]string{}
+	for key, value := range options {
+		result[key] = value
+	}
+	for key, value := range overrides {
+		result[key] = value
+	}
+	return result
+}
+
+// withLocalePlaceholders returns options with the <locale_code> and
+// <locale_name> placeholders in their values replaced by those of
+// localeFile, e.g. to name a root node after the locale. The given options
+// are left untouched and returned as is without locale placeholders.
+func withLocalePlaceholders(options map[string]string, localeFile *LocaleFile) map[string]string {
+	found := false
+	for _, value := range options {
+		found = found || placeholders.ContainsLocalePlaceholder(value)
+	}
+	if !found {
+		return options
+	}
+
+	values := map[string]string{"locale_code": localeFile.Code, "locale_name": localeFile.Name}
+	result := map[string]string{}
+	for key, value := range options {
+		result[key] = placeholders.Replace(value, values)
+	}
+	return result
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+const formatsCacheTTL = 10 * time.Minute
+
+// formatsCacheFilename overrides the file of the formats cache, which is in
+// the temp dir by default.
+var formatsCacheFilename string
+
+func formatsCachePath() string {
+	if formatsCacheFilename != "" {
+		return formatsCacheFilename
+	}
+	return tempFilePath(".phraseapp.formats.json")
+}
+
+// formatsHost is the API host of the command. Formats are only validated
+// against a cached list of the same host.
+var formatsHost = "https://api.phraseapp.com"
+
+type formatsCache struct {
+	Host    string              `json:"host"`
+	Formats []*phraseapp.Format `json:"formats"`
+}
+
+// Formats returns all formats supported by PhraseApp. The list is cached on
+// disk for a short time, as it changes rarely.
+func Formats(client *phraseapp.Client) ([]*phraseapp.Format, error) {
+	if formats, err := readFormatsCache(client.Credentials.Host, formatsCacheTTL); err == nil && !refreshCaches {
+		return formats, nil
+	}
+
+	return refreshFormats(client)
+}
+
+// refreshFormats lists the formats of the API, regardless of the cache, and
+// updates the cache.
+func refreshFormats(client *phraseapp.Client) ([]*phraseapp.Format, error) {
+	formats, err := remoteFormats(client)
+	if err != nil {
+		return nil, err
+	}
+
+	writeFormatsCache(client.Credentials.Host, formats)
+	return formats, nil
+}
+
+func remoteFormats(client *phraseapp.Client) ([]*phraseapp.Format, error) {
+	page := 1
+	formats, err := client.FormatsList(page, 25)
+	if err != nil {
+		return nil, err
+	}
+	result := formats
+	for len(formats) == 25 {
+		page = page + 1
+		formats, err = client.FormatsList(page, 25)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, formats...)
+	}
+	return result, nil
+}
+
+func readFormatsCache(host string, ttl time.Duration) ([]*phraseapp.Format, error) {
+	stat, err := os.Stat(formatsCachePath())
+	if err != nil {
+		return nil, err
+	}
+	if time.Since(stat.ModTime()) > ttl {
+		return nil, fmt.Errorf("formats cache expired")
+	}
+
+	cache, err := readFormatsCacheFile()
+	if err != nil {
+		return nil, err
+	}
+	if cache.Host != host {
+		return nil, fmt.Errorf("formats cache does not match host %s", host)
+	}
+	return cache.Formats, nil
+}
+
+func readFormatsCacheFile() (*formatsCache, error) {
+	content, err := ioutil.ReadFile(formatsCachePath())
+	if err != nil {
+		return nil, err
+	}
+
+	cache := &formatsCache{}
+	if err := json.Unmarshal(content, cache); err != nil {
+		return nil, err
+	}
+	if len(cache.Formats) == 0 {
+		return nil, fmt.Errorf("formats cache is empty")
+	}
+	return cache, nil
+}
+
+// validateFormat checks that name is a format known from the formats cache,
+// regardless of the age of the cache, so formats are validated without
+// requests. Without cache of formatsHost nothing is validated.
+func validateFormat(name string) error {
+	if name == "" {
+		return nil
+	}
+
+	cache, err := readFormatsCacheFile()
+	if err != nil || cache.Host != formatsHost {
+		return nil
+	}
+
+	for _, format := range cache.Formats {
+		if format.ApiName == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("Format %q is not supported by PhraseApp. Run 'phraseapp formats' to list all supported formats.", name)
+}
+
+func writeFormatsCache(host string, formats []*phraseapp.Format) {
+	content, err := json.Marshal(formatsCache{Host: host, Formats: formats})
+	if err != nil {
+		return
+	}
+	ioutil.WriteFile(formatsCachePath(), content, 0600)
+}
+
+type FormatsCommand struct {
+	phraseapp.Config
+	Format string `cli:"opt --format default=table desc='Output format, table or json'"`
+}
+
+func (cmd *FormatsCommand) Run() error {
+	client, err := newClient(cmd.Config.Credentials, cmd.Config.Debug)
+	if err != nil {
+		return err
+	}
+
+	formats, err := Formats(client)
+	if err != nil {
+		return err
+	}
+
+	switch cmd.Format {
+	case "json":
+		return json.NewEncoder(os.Stdout).Encode(formats)
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "FORMAT\tEXTENSION\tIMPORTABLE\tEXPORTABLE\tNAME")
+		for _, format := range formats {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", format.ApiName, format.Extension, yesNo(format.Importable), yesNo(format.Exportable), format.Name)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q, use table or json", cmd.Format)
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
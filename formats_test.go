@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func withFormatsCache(t *testing.T, formats []*phraseapp.Format, age time.Duration) func() {
+	dir, err := ioutil.TempDir("", "phraseapp-formats")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	original, originalHost := formatsCacheFilename, formatsHost
+	formatsCacheFilename = filepath.Join(dir, "formats.json")
+	formatsHost = "https://api.phraseapp.com"
+
+	if formats != nil {
+		content, _ := json.Marshal(formatsCache{Host: "https://api.phraseapp.com", Formats: formats})
+		ioutil.WriteFile(formatsCacheFilename, content, 0600)
+		modified := time.Now().Add(-age)
+		os.Chtimes(formatsCacheFilename, modified, modified)
+	}
+
+	return func() {
+		formatsCacheFilename, formatsHost = original, originalHost
+		os.RemoveAll(dir)
+	}
+}
+
+func TestValidateFormatWithStaleCache(t *testing.T) {
+	defer withFormatsCache(t, []*phraseapp.Format{{ApiName: "yml"}, {ApiName: "simple_json"}}, 30*24*time.Hour)()
+
+	if _, err := readFormatsCache("https://api.phraseapp.com", formatsCacheTTL); err == nil {
+		t.Errorf("expected the stale cache not to be used for the formats list")
+	}
+
+	target := getBaseTarget()
+	if err := target.CheckPreconditions(); err != nil {
+		t.Errorf("didn't expect an error for a known format, got: %s", err)
+	}
+
+	target.FileFormat = "yaml"
+	err := target.CheckPreconditions()
+	if err == nil || !strings.Contains(err.Error(), `"yaml"`) {
+		t.Errorf("expected an error for the unknown format, got: %v", err)
+	}
+
+	source := getBaseSource()
+	source.Params.FileFormat = sPt("jsonn")
+	if err := source.CheckPreconditions(); err == nil {
+		t.Errorf("expected an error for the unknown source format")
+	}
+}
+
+func TestValidateFormatWithoutCache(t *testing.T) {
+	defer withFormatsCache(t, nil, 0)()
+
+	if err := validateFormat("anything"); err != nil {
+		t.Errorf("didn't expect formats to be validated without cache, got: %s", err)
+	}
+}
+
+func TestValidateFormatOtherHost(t *testing.T) {
+	defer withFormatsCache(t, []*phraseapp.Format{{ApiName: "yml"}}, 0)()
+	formatsHost = "https://api.example.com"
+
+	if err := validateFormat("simple_json"); err != nil {
+		t.Errorf("didn't expect formats to be validated against the cache of another host, got: %s", err)
+	}
+}
+
+func TestFormatsByApiNameRefreshesCache(t *testing.T) {
+	defer withFormatsCache(t, nil, 0)()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `[{"api_name": "yml"}, {"api_name": "new_format"}]`)
+	}))
+	defer srv.Close()
+
+	writeFormatsCache(srv.URL, []*phraseapp.Format{{ApiName: "yml"}})
+	formatsHost = srv.URL
+	if err := validateFormat("new_format"); err == nil {
+		t.Fatalf("expected an error for a format missing in the cache")
+	}
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	formatMap, err := formatsByApiName(client)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if formatMap["new_format"] == nil {
+		t.Errorf("expected the formats to be listed, got %v", formatMap)
+	}
+	if err := validateFormat("new_format"); err != nil {
+		t.Errorf("expected the cache to be refreshed with the new format, got: %s", err)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/phrase/phraseapp-client/internal/print"
+)
+
+// githubActions reports the results of a run as GitHub Actions step outputs
+// and annotations. All methods may be called on a nil *githubActions, which is
+// returned when not running in GitHub Actions.
+type githubActions struct {
+	out        io.Writer
+	outputFile string
+}
+
+// newGithubActions returns a reporter if enabled is set or the environment is
+// a GitHub Actions runner.
+func newGithubActions(enabled bool) *githubActions {
+	if !enabled && os.Getenv("GITHUB_ACTIONS") != "true" {
+		return nil
+	}
+	return &githubActions{out: print.SanitizingWriter(os.Stdout), outputFile: os.Getenv("GITHUB_OUTPUT")}
+}
+
+// SetOutput sets a step output. Outputs are appended to the file in
+// $GITHUB_OUTPUT, runners not providing it are sent a set-output command.
+func (gh *githubActions) SetOutput(name, value string) error {
+	if gh == nil {
+		return nil
+	}
+
+	if gh.outputFile == "" {
+		fmt.Fprintf(gh.out, "::set-output name=%s::%s\n", name, escapeWorkflowData(value))
+		return nil
+	}
+
+	f, err := os.OpenFile(gh.outputFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = fmt.Fprintf(f, "%s=%s\n", name, strings.Replace(value, "\n", " ", -1))
+	return err
+}
+
+// SetResults sets the outputs files_changed, files and locales_created.
+func (gh *githubActions) SetResults(results *runResults) error {
+	if gh == nil || results == nil {
+		return nil
+	}
+
+	outputs := []struct{ name, value string }{
+		{"files_changed", fmt.Sprintf("%d", len(results.Files))},
+		{"files", strings.Join(results.Files, ",")},
+		{"locales_created", strings.Join(results.createdLocaleNames(), ",")},
+	}
+	for _, output := range outputs {
+		if err := gh.SetOutput(output.name, output.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Error adds an error annotation for err, if it isn't nil.
+func (gh *githubActions) Error(err error) {
+	if gh == nil || err == nil {
+		return
+	}
+	fmt.Fprintf(gh.out, "::error::%s\n", escapeWorkflowData(err.Error()))
+}
+
+// Warning adds a warning annotation.
+func (gh *githubActions) Warning(msg string, args ...interface{}) {
+	if gh == nil {
+		return
+	}
+	fmt.Fprintf(gh.out, "::warning::%s\n", escapeWorkflowData(fmt.Sprintf(msg, args...)))
+}
+
+// escapeWorkflowData escapes the characters with a special meaning in
+// workflow commands.
+func escapeWorkflowData(s string) string {
+	s = strings.Replace(s, "%", "%25", -1)
+	s = strings.Replace(s, "\r", "%0D", -1)
+	return strings.Replace(s, "\n", "%0A", -1)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGithubActionsOutputFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-github-actions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	out := &bytes.Buffer{}
+	gh := &githubActions{out: out, outputFile: filepath.Join(dir, "output")}
+	results := &runResults{Files: []string{"en.json", "de.json"}, CreatedLocales: []createdLocale{{ID: "de-locale-id", Code: "de", Name: "de"}}}
+	if err := gh.SetResults(results); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	content, err := ioutil.ReadFile(gh.outputFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "files_changed=2\nfiles=en.json,de.json\nlocales_created=de\n"
+	if string(content) != expected {
+		t.Errorf("expected outputs %q, got %q", expected, content)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no commands on stdout, got %q", out.String())
+	}
+}
+
+func TestGithubActionsAnnotations(t *testing.T) {
+	out := &bytes.Buffer{}
+	gh := &githubActions{out: out}
+
+	gh.SetOutput("files_changed", "1")
+	gh.Warning("could not create locale %s", "de")
+	gh.Error(errors.New("upload failed:\n100% broken"))
+	gh.Error(nil)
+
+	expected := "::set-output name=files_changed::1\n" +
+		"::warning::could not create locale de\n" +
+		"::error::upload failed:%0A100%25 broken\n"
+	if out.String() != expected {
+		t.Errorf("expected %q, got %q", expected, out.String())
+	}
+}
+
+func TestGithubActionsDisabled(t *testing.T) {
+	defer os.Setenv("GITHUB_ACTIONS", os.Getenv("GITHUB_ACTIONS"))
+
+	os.Setenv("GITHUB_ACTIONS", "")
+	if gh := newGithubActions(false); gh != nil {
+		t.Errorf("expected reporter to be disabled")
+	}
+	if gh := newGithubActions(true); gh == nil {
+		t.Errorf("expected reporter to be enabled by flag")
+	}
+
+	os.Setenv("GITHUB_ACTIONS", "true")
+	if gh := newGithubActions(false); gh == nil {
+		t.Errorf("expected reporter to be enabled in GitHub Actions")
+	}
+}
@@ -1,29 +1,67 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"runtime"
 	"strings"
 )
 
+type InfoCommand struct {
+	JSON bool `cli:"opt --json desc='Print the version and build details as JSON'"`
+}
+
+// buildInfo are the version and build details of the client, most of them set
+// with ldflags by build/go_build.sh.
+type buildInfo struct {
+	Version         string `json:"version"`
+	Revision        string `json:"revision"`
+	LibraryRevision string `json:"library_revision"`
+	BuildDate       string `json:"build_date"`
+	LastChange      string `json:"last_change"`
+	GoVersion       string `json:"go_version"`
+}
+
+func currentBuildInfo() *buildInfo {
+	return &buildInfo{
+		Version:         PHRASEAPP_CLIENT_VERSION,
+		Revision:        REVISION,
+		LibraryRevision: LIBRARY_REVISION,
+		BuildDate:       BUILD_DATE,
+		LastChange:      LAST_CHANGE,
+		GoVersion:       runtime.Version(),
+	}
+}
+
 func GetInfo() string {
 	info := []string{
 		fmt.Sprintf("PhraseApp client version:            %s", PHRASEAPP_CLIENT_VERSION),
 		fmt.Sprintf("PhraseApp client revision:           %s", REVISION),
 		fmt.Sprintf("PhraseApp library revision:          %s", LIBRARY_REVISION),
+		fmt.Sprintf("Build date:                          %s", BUILD_DATE),
 		fmt.Sprintf("Last change at:                      %s", LAST_CHANGE),
 		fmt.Sprintf("Go version:                          %s", runtime.Version()),
 	}
 	return fmt.Sprintf("%s\n", strings.Join(info, "\n"))
 }
 
-func infoCommand() error {
-	fmt.Print(GetInfo())
-	return nil
+func (cmd *InfoCommand) Run() error {
+	return cmd.print(os.Stdout)
+}
+
+func (cmd *InfoCommand) print(w io.Writer) error {
+	if cmd.JSON {
+		return json.NewEncoder(w).Encode(currentBuildInfo())
+	}
+	_, err := io.WriteString(w, GetInfo())
+	return err
 }
 
 var (
 	LAST_CHANGE              = "LIVE"
+	BUILD_DATE               = "LIVE"
 	REVISION                 = "DEV"
 	LIBRARY_REVISION         = "DEV"
 	PHRASEAPP_CLIENT_VERSION = "DEV"
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestInfoJSON(t *testing.T) {
+	defer func(original string) { PHRASEAPP_CLIENT_VERSION = original }(PHRASEAPP_CLIENT_VERSION)
+	PHRASEAPP_CLIENT_VERSION = "1.2.3"
+
+	buf := &bytes.Buffer{}
+	if err := (&InfoCommand{JSON: true}).print(buf); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	info := map[string]string{}
+	if err := json.Unmarshal(buf.Bytes(), &info); err != nil {
+		t.Fatalf("expected JSON, got %q: %s", buf.String(), err)
+	}
+	if info["version"] != "1.2.3" || info["revision"] != REVISION || info["go_version"] == "" {
+		t.Errorf("expected the build details, got %v", info)
+	}
+}
+
+func TestAliasRoute(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		exp  []string
+	}{
+		{[]string{"version"}, []string{"info"}},
+		{[]string{"version", "--json"}, []string{"info", "--json"}},
+		{[]string{"version", "show", "--id", "1"}, []string{"version", "show", "--id", "1"}},
+		{[]string{"formats"}, []string{"formats", "supported"}},
+		{[]string{"formats", "--format", "json"}, []string{"formats", "supported", "--format", "json"}},
+		{[]string{"formats", "list"}, []string{"formats", "list"}},
+		{[]string{"branches", "--format", "json"}, []string{"branches", "all", "--format", "json"}},
+		{[]string{"pull"}, []string{"pull"}},
+		{nil, nil},
+	} {
+		if args := aliasRoute(tc.args); !reflect.DeepEqual(args, tc.exp) {
+			t.Errorf("expected %v for %v, got %v", tc.exp, tc.args, args)
+		}
+	}
+}
@@ -390,10 +390,10 @@ func (cmd *InitCommand) writeConfig() error {
 }
 
 func firstPush() error {
-	cfg, err := phraseapp.ReadConfig()
+	cfg, _, err := ReadConfig("", "", nil)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-		os.Exit(2)
+		exit(2)
 	}
 	cmd := &PushCommand{Config: *cfg}
 	return cmd.Run()
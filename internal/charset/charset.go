@@ -0,0 +1,118 @@
+package charset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type decoder func([]byte) ([]byte, error)
+
+var decoders = map[string]decoder{
+	"utf8":        decodeUTF8,
+	"latin1":      decodeLatin1,
+	"iso88591":    decodeLatin1,
+	"windows1252": decodeWindows1252,
+	"cp1252":      decodeWindows1252,
+	"utf16":       decodeUTF16(nil),
+	"utf16le":     decodeUTF16(binary.LittleEndian),
+	"utf16be":     decodeUTF16(binary.BigEndian),
+}
+
+func normalize(name string) string {
+	name = strings.ToLower(name)
+	name = strings.Replace(name, "-", "", -1)
+	return strings.Replace(name, "_", "", -1)
+}
+
+// Supported returns true if content in the named charset can be decoded.
+func Supported(name string) bool {
+	_, found := decoders[normalize(name)]
+	return found
+}
+
+// IsUTF8 returns true if the named charset is UTF-8, i.e. no decoding is
+// necessary.
+func IsUTF8(name string) bool {
+	return normalize(name) == "utf8"
+}
+
+// Decode converts content encoded in the named charset to UTF-8. Supported are
+// UTF-8, ISO-8859-1 (Latin-1), Windows-1252 and UTF-16.
+func Decode(name string, content []byte) ([]byte, error) {
+	decode, found := decoders[normalize(name)]
+	if !found {
+		return nil, fmt.Errorf("unsupported encoding %q", name)
+	}
+	return decode(content)
+}
+
+func decodeUTF8(content []byte) ([]byte, error) {
+	if !utf8.Valid(content) {
+		return nil, fmt.Errorf("content is not valid UTF-8")
+	}
+	return content, nil
+}
+
+func decodeLatin1(content []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, len(content)))
+	for _, b := range content {
+		buf.WriteRune(rune(b))
+	}
+	return buf.Bytes(), nil
+}
+
+// windows1252 contains the characters differing from ISO-8859-1. Undefined
+// positions are decoded like in ISO-8859-1.
+var windows1252 = map[byte]rune{
+	0x80: '€', 0x82: '‚', 0x83: 'ƒ', 0x84: '„',
+	0x85: '…', 0x86: '†', 0x87: '‡', 0x88: 'ˆ',
+	0x89: '‰', 0x8A: 'Š', 0x8B: '‹', 0x8C: 'Œ',
+	0x8E: 'Ž', 0x91: '‘', 0x92: '’', 0x93: '“',
+	0x94: '”', 0x95: '•', 0x96: '–', 0x97: '—',
+	0x98: '˜', 0x99: '™', 0x9A: 'š', 0x9B: '›',
+	0x9C: 'œ', 0x9E: 'ž', 0x9F: 'Ÿ',
+}
+
+func decodeWindows1252(content []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(make([]byte, 0, len(content)))
+	for _, b := range content {
+		if r, found := windows1252[b]; found {
+			buf.WriteRune(r)
+		} else {
+			buf.WriteRune(rune(b))
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeUTF16 decodes UTF-16 with the given byte order. Without a byte order,
+// it is taken from the byte order mark, defaulting to big endian.
+func decodeUTF16(order binary.ByteOrder) decoder {
+	return func(content []byte) ([]byte, error) {
+		if len(content)%2 != 0 {
+			return nil, fmt.Errorf("content is not valid UTF-16, odd number of bytes")
+		}
+
+		byteOrder := order
+		switch {
+		case bytes.HasPrefix(content, []byte{0xFF, 0xFE}):
+			byteOrder = binary.LittleEndian
+			content = content[2:]
+		case bytes.HasPrefix(content, []byte{0xFE, 0xFF}):
+			byteOrder = binary.BigEndian
+			content = content[2:]
+		case byteOrder == nil:
+			byteOrder = binary.BigEndian
+		}
+
+		units := make([]uint16, len(content)/2)
+		for i := range units {
+			units[i] = byteOrder.Uint16(content[2*i:])
+		}
+		return []byte(string(utf16.Decode(units))), nil
+	}
+}
@@ -0,0 +1,56 @@
+package charset
+
+import (
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		exp     string
+	}{
+		{name: "UTF-8", content: []byte("grüße"), exp: "grüße"},
+		{name: "ISO-8859-1", content: []byte{'g', 'r', 0xFC, 0xDF, 'e'}, exp: "grüße"},
+		{name: "latin1", content: []byte{0x80, 0xE9}, exp: "\u0080é"},
+		{name: "windows-1252", content: []byte{0x80, 0xE9, 0x81}, exp: "€é\u0081"},
+		{name: "UTF-16", content: []byte{0xFF, 0xFE, 'h', 0, 0xFC, 0}, exp: "hü"},
+		{name: "UTF-16", content: []byte{0, 'h', 0, 0xFC}, exp: "hü"},
+		{name: "utf_16le", content: []byte{'h', 0, 0xFC, 0}, exp: "hü"},
+		{name: "UTF-16BE", content: []byte{0xFE, 0xFF, 0, 'h'}, exp: "h"},
+	}
+
+	for _, test := range tests {
+		content, err := Decode(test.name, test.content)
+		if err != nil {
+			t.Errorf("didn't expect an error for %s %q, got: %s", test.name, test.content, err)
+			continue
+		}
+		if string(content) != test.exp {
+			t.Errorf("expected %s %q to be decoded to %q, got %q", test.name, test.content, test.exp, content)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	if _, err := Decode("utf-8", []byte{'g', 'r', 0xFC}); err == nil {
+		t.Errorf("expected an error for invalid UTF-8")
+	}
+	if _, err := Decode("utf-16", []byte{0, 'h', 0}); err == nil {
+		t.Errorf("expected an error for an odd number of UTF-16 bytes")
+	}
+	if _, err := Decode("ebcdic", []byte("hello")); err == nil {
+		t.Errorf("expected an error for an unsupported encoding")
+	}
+}
+
+func TestSupported(t *testing.T) {
+	for name, exp := range map[string]bool{"UTF-8": true, "iso_8859_1": true, "CP1252": true, "utf-16le": true, "ebcdic": false} {
+		if Supported(name) != exp {
+			t.Errorf("expected %s to be supported: %v", name, exp)
+		}
+	}
+	if !IsUTF8("utf-8") || IsUTF8("latin1") {
+		t.Errorf("expected only utf-8 to need no decoding")
+	}
+}
@@ -0,0 +1,59 @@
+package csvcolumns
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+)
+
+// Select returns the CSV content with only the given columns, in the given
+// order. Columns are identified by the names in the header row, the first
+// row, ignoring case. The content is written anew with the same delimiter,
+// quoting fields only where needed.
+func Select(content []byte, delimiter rune, columns []string) ([]byte, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %s", err)
+	}
+	if len(records) == 0 {
+		return content, nil
+	}
+
+	header := records[0]
+	indexes := make([]int, len(columns))
+	for i, column := range columns {
+		indexes[i] = -1
+		for j, name := range header {
+			if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(column)) {
+				indexes[i] = j
+				break
+			}
+		}
+		if indexes[i] < 0 {
+			return nil, fmt.Errorf("the CSV has no column %q, its columns are %s", column, strings.Join(header, ", "))
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	writer := csv.NewWriter(buf)
+	writer.Comma = delimiter
+	for _, record := range records {
+		selected := make([]string, len(indexes))
+		for i, index := range indexes {
+			if index < len(record) {
+				selected[i] = record[index]
+			}
+		}
+		if err := writer.Write(selected); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
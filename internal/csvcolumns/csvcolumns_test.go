@@ -0,0 +1,28 @@
+package csvcolumns
+
+import "testing"
+
+func TestSelect(t *testing.T) {
+	content := []byte("key_name;en;de;comment\n" +
+		"greeting;Hello;Hallo;\"shown on start; once\"\n" +
+		"farewell;Bye;\n")
+
+	result, err := Select(content, ';', []string{"de", "KEY_NAME", "comment"})
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	exp := "de;key_name;comment\n" +
+		"Hallo;greeting;\"shown on start; once\"\n" +
+		";farewell;\n"
+	if string(result) != exp {
+		t.Errorf("expected %q, got %q", exp, result)
+	}
+
+	if _, err := Select(content, ';', []string{"fr"}); err == nil {
+		t.Errorf("expected an error for a missing column")
+	}
+
+	if result, err := Select(nil, ',', []string{"key_name"}); err != nil || len(result) != 0 {
+		t.Errorf("expected empty content to be kept, got %q, %v", result, err)
+	}
+}
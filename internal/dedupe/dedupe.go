@@ -0,0 +1,167 @@
+package dedupe
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/phrase/phraseapp-client/internal/orderedjson"
+	"gopkg.in/yaml.v2"
+)
+
+// Supported returns true if duplicate keys of files with the given extension
+// can be removed. JSON and YAML are supported, which covers formats like
+// simple_json, nested_json, i18next or yml. Other formats are passed through.
+func Supported(extension string) bool {
+	switch strings.ToLower(strings.TrimPrefix(extension, ".")) {
+	case "json", "yml", "yaml":
+		return true
+	}
+	return false
+}
+
+// Keys removes duplicate keys of the objects in content, keeping the first or
+// the last value of each key at the position of its first occurrence. It
+// returns the paths of the removed keys, nested keys joined by dots. Content
+// without duplicates is returned as is, otherwise it's formatted anew.
+func Keys(extension string, content []byte, keepFirst bool) ([]byte, []string, error) {
+	if len(bytes.TrimSpace(content)) == 0 {
+		return content, nil, nil
+	}
+
+	if strings.ToLower(strings.TrimPrefix(extension, ".")) == "json" {
+		return dedupeJSON(content, keepFirst)
+	}
+	return dedupeYAML(content, keepFirst)
+}
+
+func dedupeJSON(content []byte, keepFirst bool) ([]byte, []string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	duplicates := []string{}
+	value, err := decodeJSON(decoder, "", keepFirst, &duplicates)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid JSON: %s", err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, nil, fmt.Errorf("invalid JSON: unexpected content after the top level value")
+	}
+	if len(duplicates) == 0 {
+		return content, nil, nil
+	}
+
+	buf := &bytes.Buffer{}
+	if err := orderedjson.Encode(buf, value); err != nil {
+		return nil, nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), duplicates, nil
+}
+
+func decodeJSON(decoder *json.Decoder, path string, keepFirst bool, duplicates *[]string) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		result := orderedjson.Object{}
+		index := map[string]int{}
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := keyToken.(string)
+			value, err := decodeJSON(decoder, path+key+".", keepFirst, duplicates)
+			if err != nil {
+				return nil, err
+			}
+
+			i, found := index[key]
+			switch {
+			case !found:
+				index[key] = len(result)
+				result = append(result, orderedjson.Member{Key: key, Value: value})
+			case !keepFirst:
+				*duplicates = append(*duplicates, path+key)
+				result[i].Value = value
+			default:
+				*duplicates = append(*duplicates, path+key)
+			}
+		}
+		_, err := decoder.Token()
+		return result, err
+	case json.Delim('['):
+		result := []interface{}{}
+		for i := 0; decoder.More(); i++ {
+			value, err := decodeJSON(decoder, fmt.Sprintf("%s%d.", path, i), keepFirst, duplicates)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		}
+		_, err := decoder.Token()
+		return result, err
+	}
+	return token, nil
+}
+
+func dedupeYAML(content []byte, keepFirst bool) ([]byte, []string, error) {
+	document := yaml.MapSlice{}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, nil, fmt.Errorf("invalid YAML: %s", err)
+	}
+
+	duplicates := []string{}
+	deduped := dedupeMapSlice(document, "", keepFirst, &duplicates)
+	if len(duplicates) == 0 {
+		return content, nil, nil
+	}
+
+	result, err := yaml.Marshal(deduped)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, duplicates, nil
+}
+
+func dedupeMapSlice(mapping yaml.MapSlice, path string, keepFirst bool, duplicates *[]string) yaml.MapSlice {
+	result := yaml.MapSlice{}
+	index := map[string]int{}
+	for _, item := range mapping {
+		key := fmt.Sprint(item.Key)
+		value := dedupeYAMLValue(item.Value, path+key+".", keepFirst, duplicates)
+
+		i, found := index[key]
+		switch {
+		case !found:
+			index[key] = len(result)
+			result = append(result, yaml.MapItem{Key: item.Key, Value: value})
+		case !keepFirst:
+			*duplicates = append(*duplicates, path+key)
+			result[i].Value = value
+		default:
+			*duplicates = append(*duplicates, path+key)
+		}
+	}
+	return result
+}
+
+func dedupeYAMLValue(value interface{}, path string, keepFirst bool, duplicates *[]string) interface{} {
+	switch value := value.(type) {
+	case yaml.MapSlice:
+		return dedupeMapSlice(value, path, keepFirst, duplicates)
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, element := range value {
+			result[i] = dedupeYAMLValue(element, fmt.Sprintf("%s%d.", path, i), keepFirst, duplicates)
+		}
+		return result
+	}
+	return value
+}
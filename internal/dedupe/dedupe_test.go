@@ -0,0 +1,84 @@
+package dedupe
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestKeysJSON(t *testing.T) {
+	content, err := ioutil.ReadFile("testdata/duplicates.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		keepFirst bool
+		expected  string
+	}{
+		{
+			keepFirst: false,
+			expected: `{
+  "app": {
+    "title": "Second title",
+    "cancel": "Cancel"
+  },
+  "greeting": "Hi",
+  "count": 3
+}
+`,
+		},
+		{
+			keepFirst: true,
+			expected: `{
+  "app": {
+    "title": "First title",
+    "cancel": "Cancel"
+  },
+  "greeting": "Hello <b>you</b>",
+  "count": 3
+}
+`,
+		},
+	} {
+		deduped, duplicates, err := Keys(".json", content, tc.keepFirst)
+		if err != nil {
+			t.Fatalf("didn't expect an error, got: %s", err)
+		}
+		if string(deduped) != tc.expected {
+			t.Errorf("keepFirst=%t: expected %s, got %s", tc.keepFirst, tc.expected, deduped)
+		}
+		if exp := []string{"app.title", "greeting"}; !reflect.DeepEqual(duplicates, exp) {
+			t.Errorf("expected duplicates %v, got %v", exp, duplicates)
+		}
+	}
+}
+
+func TestKeysWithoutDuplicates(t *testing.T) {
+	content := []byte(`{"b": 1,   "a": [{"x": 1}, {"x": 2}]}`)
+	deduped, duplicates, err := Keys("json", content, false)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if string(deduped) != string(content) || len(duplicates) != 0 {
+		t.Errorf("expected the content to be returned as is, got %q and %v", deduped, duplicates)
+	}
+
+	if _, _, err := Keys("json", []byte(`{"a": 1} {"b": 2}`), false); err == nil {
+		t.Errorf("expected an error for content after the object")
+	}
+}
+
+func TestKeysYAML(t *testing.T) {
+	content := []byte("en:\n  title: First\n  cancel: Cancel\n  title: Second\n")
+	deduped, duplicates, err := Keys("yml", content, false)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if exp := "en:\n  title: Second\n  cancel: Cancel\n"; string(deduped) != exp {
+		t.Errorf("expected %q, got %q", exp, deduped)
+	}
+	if exp := []string{"en.title"}; !reflect.DeepEqual(duplicates, exp) {
+		t.Errorf("expected duplicates %v, got %v", exp, duplicates)
+	}
+}
@@ -0,0 +1,199 @@
+package fallback
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/phrase/phraseapp-client/internal/orderedjson"
+	"gopkg.in/yaml.v2"
+)
+
+// Supported returns true if the fallback translations of files with the given
+// extension can be merged. JSON and YAML are supported, which covers formats
+// like simple_json, nested_json, i18next or yml.
+func Supported(extension string) bool {
+	switch normalize(extension) {
+	case "json", "yml", "yaml":
+		return true
+	}
+	return false
+}
+
+// Apply fills the keys of content which are missing or empty with the values
+// of the fallbacks, the nearest fallback first. Nested objects are filled
+// recursively. YAML documents with a single root key, like the locale code
+// of Rails files, are filled below their roots. Keys keep their order, filled
+// keys are appended. Content without missing keys is returned as is.
+func Apply(extension string, content []byte, fallbacks [][]byte) ([]byte, error) {
+	if normalize(extension) == "json" {
+		return applyJSON(content, fallbacks)
+	}
+	return applyYAML(content, fallbacks)
+}
+
+func applyJSON(content []byte, fallbacks [][]byte) ([]byte, error) {
+	object, err := decodeJSON(content)
+	if err != nil {
+		return nil, err
+	}
+
+	filled := 0
+	for i, raw := range fallbacks {
+		fallback, err := decodeJSON(raw)
+		if err != nil {
+			return nil, fmt.Errorf("fallback %d: %s", i+1, err)
+		}
+		filled += fillObject(&object, fallback)
+	}
+	if filled == 0 {
+		return content, nil
+	}
+
+	buf := &bytes.Buffer{}
+	if err := orderedjson.Encode(buf, object); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+func applyYAML(content []byte, fallbacks [][]byte) ([]byte, error) {
+	document, err := decodeYAML(content)
+	if err != nil {
+		return nil, err
+	}
+	target, targetRoot := &document, rootIndex(document)
+	if targetRoot >= 0 {
+		child := document[targetRoot].Value.(yaml.MapSlice)
+		target = &child
+	}
+
+	filled := 0
+	for i, raw := range fallbacks {
+		fallback, err := decodeYAML(raw)
+		if err != nil {
+			return nil, fmt.Errorf("fallback %d: %s", i+1, err)
+		}
+		if root := rootIndex(fallback); targetRoot >= 0 && root >= 0 {
+			fallback = fallback[root].Value.(yaml.MapSlice)
+		}
+		filled += fillMapSlice(target, fallback)
+	}
+	if filled == 0 {
+		return content, nil
+	}
+
+	if targetRoot >= 0 {
+		document[targetRoot].Value = *target
+	}
+	return yaml.Marshal(document)
+}
+
+// fillObject adds the values of src which are missing or empty in dst and
+// returns the number of values added.
+func fillObject(dst *orderedjson.Object, src orderedjson.Object) int {
+	filled := 0
+	for _, member := range src {
+		existing, found := dst.Get(member.Key)
+		if !found || isEmpty(existing) {
+			if !found || !isEmpty(member.Value) {
+				dst.Set(member.Key, member.Value)
+				filled++
+			}
+			continue
+		}
+
+		dstChild, dstIsObject := existing.(orderedjson.Object)
+		srcChild, srcIsObject := member.Value.(orderedjson.Object)
+		if dstIsObject && srcIsObject {
+			if n := fillObject(&dstChild, srcChild); n > 0 {
+				dst.Set(member.Key, dstChild)
+				filled += n
+			}
+		}
+	}
+	return filled
+}
+
+// fillMapSlice is fillObject for YAML mappings.
+func fillMapSlice(dst *yaml.MapSlice, src yaml.MapSlice) int {
+	filled := 0
+	for _, item := range src {
+		i := indexOf(*dst, item.Key)
+		if i < 0 {
+			*dst = append(*dst, item)
+			filled++
+			continue
+		}
+		if isEmpty((*dst)[i].Value) {
+			if !isEmpty(item.Value) {
+				(*dst)[i].Value = item.Value
+				filled++
+			}
+			continue
+		}
+
+		dstChild, dstIsMapping := (*dst)[i].Value.(yaml.MapSlice)
+		srcChild, srcIsMapping := item.Value.(yaml.MapSlice)
+		if dstIsMapping && srcIsMapping {
+			if n := fillMapSlice(&dstChild, srcChild); n > 0 {
+				(*dst)[i].Value = dstChild
+				filled += n
+			}
+		}
+	}
+	return filled
+}
+
+func indexOf(mapping yaml.MapSlice, key interface{}) int {
+	for i, item := range mapping {
+		if fmt.Sprint(item.Key) == fmt.Sprint(key) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isEmpty(value interface{}) bool {
+	return value == nil || value == ""
+}
+
+// rootIndex returns the index of the only key of mapping if its value is a
+// mapping, -1 otherwise.
+func rootIndex(mapping yaml.MapSlice) int {
+	if len(mapping) != 1 {
+		return -1
+	}
+	if _, ok := mapping[0].Value.(yaml.MapSlice); ok {
+		return 0
+	}
+	return -1
+}
+
+func decodeJSON(content []byte) (orderedjson.Object, error) {
+	if len(bytes.TrimSpace(content)) == 0 {
+		return orderedjson.Object{}, nil
+	}
+	value, err := orderedjson.Decode(content)
+	if err != nil {
+		return nil, fmt.Errorf("fallbacks can only be applied to JSON objects: %s", err)
+	}
+	object, ok := value.(orderedjson.Object)
+	if !ok {
+		return nil, fmt.Errorf("fallbacks can only be applied to JSON objects")
+	}
+	return object, nil
+}
+
+func decodeYAML(content []byte) (yaml.MapSlice, error) {
+	document := yaml.MapSlice{}
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("fallbacks can only be applied to YAML mappings: %s", err)
+	}
+	return document, nil
+}
+
+func normalize(extension string) string {
+	return strings.ToLower(strings.TrimPrefix(extension, "."))
+}
@@ -0,0 +1,50 @@
+package fallback
+
+import "testing"
+
+func TestApplyTwoLevelChain(t *testing.T) {
+	// de-AT falls back to de, which falls back to en
+	deAT := []byte(`{"greeting": "Servus <3", "app": {"title": ""}}`)
+	de := []byte(`{"greeting": "Hallo", "app": {"title": "Anwendung"}, "cancel": ""}`)
+	en := []byte(`{"greeting": "Hello", "app": {"title": "App", "ok": "OK"}, "cancel": "Cancel", "save": "Save"}`)
+
+	resolved, err := Apply(".json", deAT, [][]byte{de, en})
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	expected := `{
+  "greeting": "Servus <3",
+  "app": {
+    "title": "Anwendung",
+    "ok": "OK"
+  },
+  "cancel": "Cancel",
+  "save": "Save"
+}
+`
+	if string(resolved) != expected {
+		t.Errorf("expected %s, got %s", expected, resolved)
+	}
+}
+
+func TestApplyYAMLRoots(t *testing.T) {
+	resolved, err := Apply("yml", []byte("de-AT:\n  greeting: Servus\n"), [][]byte{[]byte("de:\n  greeting: Hallo\n  cancel: Abbrechen\n")})
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if exp := "de-AT:\n  greeting: Servus\n  cancel: Abbrechen\n"; string(resolved) != exp {
+		t.Errorf("expected %q, got %q", exp, resolved)
+	}
+}
+
+func TestApplyNothingFilled(t *testing.T) {
+	content := []byte(`{"b": "B", "a": "<a>"}`)
+	resolved, err := Apply("json", content, [][]byte{[]byte(`{"a": "A", "b": ""}`)})
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if string(resolved) != string(content) {
+		t.Errorf("expected content to be returned as is, got %s", resolved)
+	}
+}
@@ -0,0 +1,106 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// run executes git with args in dir and returns the trimmed output. The
+// error contains the message printed by git.
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Root returns the top level directory of the repository containing dir.
+func Root(dir string) (string, error) {
+	root, err := run(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("%s is not inside a git repository", dir)
+	}
+	return filepath.FromSlash(root), nil
+}
+
+// CurrentBranch returns the name of the branch checked out in the repository
+// containing dir. It is an error if HEAD is detached.
+func CurrentBranch(dir string) (string, error) {
+	if _, err := Root(dir); err != nil {
+		return "", err
+	}
+
+	branch, err := run(dir, "symbolic-ref", "--short", "-q", "HEAD")
+	if err != nil || branch == "" {
+		return "", fmt.Errorf("HEAD is detached, no branch is checked out")
+	}
+	return branch, nil
+}
+
+// ChangedFiles returns the absolute paths of all files in the repository
+// containing dir that differ from ref, including uncommitted changes.
+func ChangedFiles(dir, ref string) ([]string, error) {
+	root, err := Root(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := run(root, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("invalid git reference %q", ref)
+	}
+
+	out, err := run(root, "diff", "--name-only", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+	for _, name := range strings.Split(out, "\n") {
+		if name != "" {
+			files = append(files, filepath.Join(root, filepath.FromSlash(name)))
+		}
+	}
+	return files, nil
+}
+
+// Commit stages the given files of the repository containing dir and
+// commits only them with message, leaving other staged changes alone. It
+// returns false if the files contain no changes to commit.
+func Commit(dir, message string, files []string) (bool, error) {
+	root, err := Root(dir)
+	if err != nil {
+		return false, err
+	}
+	if len(files) == 0 {
+		return false, nil
+	}
+
+	if _, err := run(root, append([]string{"add", "--"}, files...)...); err != nil {
+		return false, err
+	}
+
+	staged, err := run(root, append([]string{"diff", "--cached", "--name-only", "--"}, files...)...)
+	if err != nil {
+		return false, err
+	}
+	if staged == "" {
+		return false, nil
+	}
+
+	if _, err := run(root, append([]string{"commit", "-m", message, "--"}, files...)...); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,127 @@
+package git
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func setupRepo(t *testing.T) string {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "phraseapp-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the temp dir might be a symlink, git reports the resolved path
+	if dir, err = filepath.EvalSymlinks(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	mustRun(t, dir, "init", "-q")
+	for _, name := range []string{"en.yml", "de.yml"} {
+		ioutil.WriteFile(filepath.Join(dir, name), []byte("hello: world\n"), 0600)
+	}
+	mustRun(t, dir, "add", ".")
+	mustRun(t, dir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "initial")
+	return dir
+}
+
+func mustRun(t *testing.T, dir string, args ...string) {
+	if _, err := run(dir, args...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	dir := setupRepo(t)
+	defer os.RemoveAll(dir)
+
+	ioutil.WriteFile(filepath.Join(dir, "de.yml"), []byte("hello: welt\n"), 0600)
+
+	files, err := ChangedFiles(dir, "HEAD")
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	exp := []string{filepath.Join(dir, "de.yml")}
+	if !reflect.DeepEqual(files, exp) {
+		t.Errorf("expected changed files %v, got %v", exp, files)
+	}
+}
+
+func TestCurrentBranch(t *testing.T) {
+	dir := setupRepo(t)
+	defer os.RemoveAll(dir)
+
+	mustRun(t, dir, "checkout", "-q", "-b", "feature/login")
+	if branch, err := CurrentBranch(dir); err != nil || branch != "feature/login" {
+		t.Errorf("expected branch %q, got %q (%v)", "feature/login", branch, err)
+	}
+
+	mustRun(t, dir, "checkout", "-q", "--detach")
+	if _, err := CurrentBranch(dir); err == nil {
+		t.Errorf("expected an error for a detached HEAD")
+	}
+}
+
+func TestChangedFilesInvalidRef(t *testing.T) {
+	dir := setupRepo(t)
+	defer os.RemoveAll(dir)
+
+	if _, err := ChangedFiles(dir, "does-not-exist"); err == nil {
+		t.Errorf("expected an error for an invalid ref")
+	}
+}
+
+func TestChangedFilesNoRepository(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir("", "phraseapp-git-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := ChangedFiles(dir, "HEAD"); err == nil {
+		t.Errorf("expected an error outside of a git repository")
+	}
+}
+
+func TestCommit(t *testing.T) {
+	dir := setupRepo(t)
+	defer os.RemoveAll(dir)
+	mustRun(t, dir, "config", "user.name", "test")
+	mustRun(t, dir, "config", "user.email", "test@example.com")
+
+	ioutil.WriteFile(filepath.Join(dir, "de.yml"), []byte("hello: welt\n"), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "fr.yml"), []byte("hello: monde\n"), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "en.yml"), []byte("hello: you\n"), 0600)
+
+	committed, err := Commit(dir, "Update translations", []string{filepath.Join(dir, "de.yml"), filepath.Join(dir, "fr.yml")})
+	if err != nil || !committed {
+		t.Fatalf("expected a commit, got %v (%v)", committed, err)
+	}
+
+	if message, err := run(dir, "log", "-1", "--format=%s"); err != nil || message != "Update translations" {
+		t.Errorf("expected the commit message %q, got %q (%v)", "Update translations", message, err)
+	}
+	files, err := ChangedFiles(dir, "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := []string{filepath.Join(dir, "en.yml")}; !reflect.DeepEqual(files, exp) {
+		t.Errorf("expected only %v to remain uncommitted, got %v", exp, files)
+	}
+
+	if committed, err := Commit(dir, "Nothing", []string{filepath.Join(dir, "de.yml")}); err != nil || committed {
+		t.Errorf("expected no commit without changes, got %v (%v)", committed, err)
+	}
+}
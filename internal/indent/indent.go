@@ -0,0 +1,311 @@
+package indent
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DefaultSize is the indentation size used if neither indent_size nor
+// tab_width is configured, the one of files downloaded from PhraseApp.
+const DefaultSize = 2
+
+// Style is an indentation, with tabs or with Size spaces.
+type Style struct {
+	Tabs bool
+	Size int
+}
+
+// Unit returns the string of a single level of indentation.
+func (style Style) Unit() string {
+	if style.Tabs {
+		return "\t"
+	}
+	return strings.Repeat(" ", style.size())
+}
+
+func (style Style) size() int {
+	if style.Size > 0 {
+		return style.Size
+	}
+	return DefaultSize
+}
+
+// Supported returns true if files with the given extension can be indented
+// anew. JSON and YAML are supported.
+func Supported(extension string) bool {
+	switch strings.ToLower(strings.TrimPrefix(extension, ".")) {
+	case "json", "yml", "yaml":
+		return true
+	}
+	return false
+}
+
+// Reindent indents content with style. YAML doesn't allow tabs, it's always
+// indented with spaces, the levels of the given content are detected by the
+// smallest indentation. Content of unsupported types is returned unchanged.
+func Reindent(extension string, content []byte, style Style) ([]byte, error) {
+	switch strings.ToLower(strings.TrimPrefix(extension, ".")) {
+	case "json":
+		return reindentJSON(content, style)
+	case "yml", "yaml":
+		return reindentYAML(content, style), nil
+	}
+	return content, nil
+}
+
+func reindentJSON(content []byte, style Style) ([]byte, error) {
+	if len(bytes.TrimSpace(content)) == 0 {
+		return content, nil
+	}
+
+	buf := &bytes.Buffer{}
+	if err := json.Indent(buf, bytes.TrimSpace(content), "", style.Unit()); err != nil {
+		return nil, err
+	}
+	if bytes.HasSuffix(content, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+func reindentYAML(content []byte, style Style) []byte {
+	lines := bytes.SplitAfter(content, []byte("\n"))
+
+	unit := 0
+	for _, line := range lines {
+		if n := leadingSpaces(line); n > 0 && n < len(bytes.TrimRight(line, "\r\n")) && (unit == 0 || n < unit) {
+			unit = n
+		}
+	}
+	if unit == 0 {
+		return content
+	}
+
+	buf := &bytes.Buffer{}
+	for _, line := range lines {
+		n := leadingSpaces(line)
+		if n == len(bytes.TrimRight(line, "\r\n")) {
+			// blank lines are kept as they are
+			buf.Write(line)
+			continue
+		}
+		buf.WriteString(strings.Repeat(" ", n/unit*style.size()+n%unit))
+		buf.Write(line[n:])
+	}
+	return buf.Bytes()
+}
+
+func leadingSpaces(line []byte) int {
+	return len(line) - len(bytes.TrimLeft(line, " "))
+}
+
+// FromEditorConfig returns the indentation of the file at path configured by
+// the indent_style, indent_size and tab_width properties of the .editorconfig
+// files in its directory and above, up to the one with root = true. Nearer
+// files take precedence. Without configuration spaces of the default size are
+// used.
+func FromEditorConfig(path string) (Style, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return Style{}, err
+	}
+
+	files := []*editorConfig{}
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		config, err := readEditorConfig(filepath.Join(dir, ".editorconfig"))
+		if err != nil {
+			return Style{}, err
+		}
+		if config != nil {
+			files = append(files, config)
+			if config.root {
+				break
+			}
+		}
+		if parent := filepath.Dir(dir); parent == dir {
+			break
+		}
+	}
+
+	properties := map[string]string{}
+	for i := len(files) - 1; i >= 0; i-- {
+		files[i].apply(path, properties)
+	}
+
+	style := Style{Tabs: properties["indent_style"] == "tab"}
+	size := properties["indent_size"]
+	if size == "tab" || size == "" {
+		size = properties["tab_width"]
+	}
+	if size != "" {
+		if style.Size, err = strconv.Atoi(size); err != nil || style.Size <= 0 {
+			return Style{}, fmt.Errorf("invalid indentation size %q in .editorconfig", size)
+		}
+	}
+	return style, nil
+}
+
+type editorConfig struct {
+	dir      string
+	root     bool
+	sections []*section
+}
+
+type section struct {
+	pattern    *regexp.Regexp
+	properties map[string]string
+}
+
+// readEditorConfig parses the .editorconfig file at path, nil if there's
+// none. Sections with patterns that can't be parsed are ignored.
+func readEditorConfig(path string) (*editorConfig, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	config := &editorConfig{dir: filepath.ToSlash(filepath.Dir(path))}
+	var current *section
+	ignored := false
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";"):
+			continue
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			pattern, err := compilePattern(line[1 : len(line)-1])
+			ignored = err != nil
+			if ignored {
+				continue
+			}
+			current = &section{pattern: pattern, properties: map[string]string{}}
+			config.sections = append(config.sections, current)
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(parts[0]))
+		value := strings.ToLower(strings.TrimSpace(parts[1]))
+		switch {
+		case ignored:
+			continue
+		case current == nil:
+			// the preamble
+			if key == "root" {
+				config.root = value == "true"
+			}
+		default:
+			current.properties[key] = value
+		}
+	}
+	return config, scanner.Err()
+}
+
+// apply sets the properties of all sections matching path, later sections
+// take precedence.
+func (config *editorConfig) apply(path string, properties map[string]string) {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, config.dir+"/") {
+		return
+	}
+	rel := strings.TrimPrefix(path, config.dir+"/")
+
+	for _, section := range config.sections {
+		if !section.pattern.MatchString(rel) {
+			continue
+		}
+		for key, value := range section.properties {
+			if value == "unset" {
+				delete(properties, key)
+				continue
+			}
+			properties[key] = value
+		}
+	}
+}
+
+// compilePattern returns a regular expression for the glob of a section,
+// matched against paths relative to the directory of the .editorconfig file.
+// Patterns without a slash match files in any subdirectory.
+func compilePattern(glob string) (*regexp.Regexp, error) {
+	prefix := ""
+	if !strings.Contains(glob, "/") {
+		prefix = "(?:.*/)?"
+	}
+	glob = strings.TrimPrefix(glob, "/")
+
+	expr := &strings.Builder{}
+	braces := 0
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			switch {
+			case strings.HasPrefix(glob[i:], "**/"):
+				expr.WriteString("(?:.*/)?")
+				i += 2
+			case strings.HasPrefix(glob[i:], "**"):
+				expr.WriteString(".*")
+				i++
+			default:
+				expr.WriteString("[^/]*")
+			}
+		case '?':
+			expr.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i:], ']')
+			if end < 0 {
+				expr.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + strings.Replace(class, `\`, `\\`, -1) + "]")
+			i += end
+		case '{':
+			braces++
+			expr.WriteString("(?:")
+		case '}':
+			if braces == 0 {
+				expr.WriteString(`\}`)
+				continue
+			}
+			braces--
+			expr.WriteString(")")
+		case ',':
+			if braces == 0 {
+				expr.WriteString(",")
+				continue
+			}
+			expr.WriteString("|")
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				expr.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			}
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if braces > 0 {
+		return nil, fmt.Errorf("unbalanced braces in pattern %q", glob)
+	}
+	return regexp.Compile("^" + prefix + expr.String() + "$")
+}
@@ -0,0 +1,76 @@
+package indent
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFromEditorConfig(t *testing.T) {
+	tests := []struct {
+		path     string
+		expected Style
+	}{
+		{"locales/en.json", Style{Size: 4}},
+		{"locales/en.yml", Style{Size: 2}},
+		{"locales/legacy/en.json", Style{Tabs: true, Size: 8}},
+		{"locales/legacy/en.yml", Style{Size: 2}},
+		{"config/en.json", Style{}},
+	}
+
+	for _, test := range tests {
+		style, err := FromEditorConfig(filepath.Join("testdata", "project", filepath.FromSlash(test.path)))
+		if err != nil {
+			t.Errorf("%s: didn't expect an error, got: %s", test.path, err)
+			continue
+		}
+		if style != test.expected {
+			t.Errorf("%s: expected %+v, got %+v", test.path, test.expected, style)
+		}
+	}
+}
+
+func TestReindent(t *testing.T) {
+	tests := []struct {
+		extension string
+		style     Style
+		content   string
+		expected  string
+	}{
+		{
+			".json", Style{Size: 4},
+			"{\n  \"a\": {\n    \"b\": [1, 2]\n  },\n  \"c\": {}\n}\n",
+			"{\n    \"a\": {\n        \"b\": [\n            1,\n            2\n        ]\n    },\n    \"c\": {}\n}\n",
+		},
+		{
+			".json", Style{Tabs: true},
+			`{"a":"x  y"}`,
+			"{\n\t\"a\": \"x  y\"\n}",
+		},
+		{
+			".yml", Style{Size: 4},
+			"en:\n  a:\n    b: x\n  list:\n  - one\n\n  text: |\n    first\n     second\n",
+			"en:\n    a:\n        b: x\n    list:\n    - one\n\n    text: |\n        first\n         second\n",
+		},
+		{
+			".yml", Style{Tabs: true},
+			"en:\n  a: x\n",
+			"en:\n  a: x\n",
+		},
+		{
+			".xml", Style{Size: 4},
+			"<a>\n  <b/>\n</a>\n",
+			"<a>\n  <b/>\n</a>\n",
+		},
+	}
+
+	for _, test := range tests {
+		result, err := Reindent(test.extension, []byte(test.content), test.style)
+		if err != nil {
+			t.Errorf("%s: didn't expect an error, got: %s", test.content, err)
+			continue
+		}
+		if string(result) != test.expected {
+			t.Errorf("expected %q, got %q", test.expected, result)
+		}
+	}
+}
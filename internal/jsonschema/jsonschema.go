@@ -0,0 +1,565 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Schema is a JSON Schema. The validation keywords type, enum, const,
+// properties, required, additionalProperties, patternProperties,
+// minProperties, maxProperties, items, minItems, maxItems, minLength,
+// maxLength, pattern, minimum, maximum, allOf, anyOf, oneOf and not are
+// supported, as well as $ref to definitions of the same schema. Schemas using
+// other validation keywords of the specification, like format or
+// uniqueItems, are rejected instead of silently passing invalid files.
+// Annotations like title or description and unknown keywords are ignored.
+type Schema struct {
+	root interface{}
+
+	mu       sync.Mutex
+	patterns map[string]*regexp.Regexp
+}
+
+// Load reads the schema at path.
+func Load(path string) (*Schema, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(content)
+}
+
+// Parse parses the schema in content.
+func Parse(content []byte) (*Schema, error) {
+	root, err := decode(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %s", err)
+	}
+	switch root.(type) {
+	case map[string]interface{}, bool:
+	default:
+		return nil, fmt.Errorf("invalid schema: expected an object")
+	}
+	if err := checkKeywords(root, ""); err != nil {
+		return nil, err
+	}
+	return &Schema{root: root, patterns: map[string]*regexp.Regexp{}}, nil
+}
+
+// unsupportedKeywords are the validation keywords of JSON Schema which are
+// not implemented.
+var unsupportedKeywords = map[string]bool{
+	"format":                true,
+	"multipleOf":            true,
+	"exclusiveMinimum":      true,
+	"exclusiveMaximum":      true,
+	"uniqueItems":           true,
+	"additionalItems":       true,
+	"contains":              true,
+	"minContains":           true,
+	"maxContains":           true,
+	"prefixItems":           true,
+	"unevaluatedItems":      true,
+	"unevaluatedProperties": true,
+	"propertyNames":         true,
+	"dependencies":          true,
+	"dependentRequired":     true,
+	"dependentSchemas":      true,
+	"if":                    true,
+	"then":                  true,
+	"else":                  true,
+}
+
+// checkKeywords returns an error if schema or one of its subschemas uses an
+// unsupported keyword.
+func checkKeywords(schema interface{}, pointer string) error {
+	object, ok := schema.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	keywords := []string{}
+	for keyword := range object {
+		keywords = append(keywords, keyword)
+	}
+	sort.Strings(keywords)
+
+	for _, keyword := range keywords {
+		if unsupportedKeywords[keyword] {
+			if pointer == "" {
+				pointer = "/"
+			}
+			return fmt.Errorf("unsupported keyword %q in schema at %s", keyword, pointer)
+		}
+
+		value := object[keyword]
+		path := pointer + "/" + keyword
+		switch keyword {
+		case "properties", "patternProperties", "definitions", "$defs":
+			if schemas, ok := value.(map[string]interface{}); ok {
+				for name, sub := range schemas {
+					if err := checkKeywords(sub, path+"/"+name); err != nil {
+						return err
+					}
+				}
+			}
+		case "allOf", "anyOf", "oneOf", "items":
+			if schemas, ok := value.([]interface{}); ok {
+				for i, sub := range schemas {
+					if err := checkKeywords(sub, fmt.Sprintf("%s/%d", path, i)); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := checkKeywords(value, path); err != nil {
+				return err
+			}
+		case "additionalProperties", "not":
+			if err := checkKeywords(value, path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Validate validates the JSON document in content against the schema. It
+// returns the violations, each prefixed with the JSON pointer of the invalid
+// value, and an error if content isn't valid JSON or the schema can't be
+// applied.
+func (schema *Schema) Validate(content []byte) ([]string, error) {
+	document, err := decode(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON: %s", err)
+	}
+
+	v := &validator{schema: schema}
+	if err := v.validate(schema.root, document, ""); err != nil {
+		return nil, err
+	}
+	return v.violations, nil
+}
+
+func decode(content []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected content after the top level value")
+	}
+	return value, nil
+}
+
+type validator struct {
+	schema     *Schema
+	violations []string
+	// depth limits the resolution of references, which may be cyclic.
+	depth int
+}
+
+func (v *validator) fail(pointer, format string, args ...interface{}) {
+	if pointer == "" {
+		pointer = "/"
+	}
+	v.violations = append(v.violations, pointer+": "+fmt.Sprintf(format, args...))
+}
+
+// valid returns true if value conforms to schema, without recording any
+// violations.
+func (v *validator) valid(schema, value interface{}, pointer string) (bool, error) {
+	sub := &validator{schema: v.schema, depth: v.depth}
+	if err := sub.validate(schema, value, pointer); err != nil {
+		return false, err
+	}
+	return len(sub.violations) == 0, nil
+}
+
+func (v *validator) validate(schema, value interface{}, pointer string) error {
+	switch schema := schema.(type) {
+	case bool:
+		if !schema {
+			v.fail(pointer, "no value is allowed")
+		}
+		return nil
+	case map[string]interface{}:
+		if ref, ok := schema["$ref"].(string); ok {
+			return v.validateRef(ref, value, pointer)
+		}
+		for _, validate := range []func(map[string]interface{}, interface{}, string) error{
+			v.validateType,
+			v.validateEnum,
+			v.validateObject,
+			v.validateArray,
+			v.validateString,
+			v.validateNumber,
+			v.validateCombinations,
+		} {
+			if err := validate(schema, value, pointer); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid schema at %s: expected an object", pointer)
+}
+
+func (v *validator) validateRef(ref string, value interface{}, pointer string) error {
+	if !strings.HasPrefix(ref, "#") {
+		return fmt.Errorf("unsupported reference %q, only references within the schema are supported", ref)
+	}
+	if v.depth > 100 {
+		return fmt.Errorf("reference %q nested too deep", ref)
+	}
+
+	target := v.schema.root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		token = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+		object, ok := target.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("unresolvable reference %q", ref)
+		}
+		if target, ok = object[token]; !ok {
+			return fmt.Errorf("unresolvable reference %q", ref)
+		}
+	}
+
+	v.depth++
+	defer func() { v.depth-- }()
+	return v.validate(target, value, pointer)
+}
+
+func (v *validator) validateType(schema map[string]interface{}, value interface{}, pointer string) error {
+	var types []string
+	switch t := schema["type"].(type) {
+	case nil:
+		return nil
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, name := range t {
+			types = append(types, fmt.Sprint(name))
+		}
+	default:
+		return fmt.Errorf("invalid type %v in schema", t)
+	}
+
+	actual := typeOf(value)
+	for _, name := range types {
+		if name == actual || name == "number" && actual == "integer" {
+			return nil
+		}
+	}
+	v.fail(pointer, "expected %s, got %s", strings.Join(types, " or "), actual)
+	return nil
+}
+
+func typeOf(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, ok := new(big.Int).SetString(value.String(), 10); ok {
+			return "integer"
+		}
+		if f, err := value.Float64(); err == nil && f == float64(int64(f)) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	}
+	return "object"
+}
+
+func (v *validator) validateEnum(schema map[string]interface{}, value interface{}, pointer string) error {
+	if constant, found := schema["const"]; found && !equal(constant, value) {
+		v.fail(pointer, "expected %s", encode(constant))
+	}
+
+	enum, found := schema["enum"]
+	if !found {
+		return nil
+	}
+	values, ok := enum.([]interface{})
+	if !ok {
+		return fmt.Errorf("invalid enum in schema, expected an array")
+	}
+	for _, allowed := range values {
+		if equal(allowed, value) {
+			return nil
+		}
+	}
+	allowed := make([]string, len(values))
+	for i, value := range values {
+		allowed[i] = encode(value)
+	}
+	v.fail(pointer, "expected one of %s", strings.Join(allowed, ", "))
+	return nil
+}
+
+func (v *validator) validateObject(schema map[string]interface{}, value interface{}, pointer string) error {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if _, found := object[fmt.Sprint(name)]; !found {
+				v.fail(pointer, "missing required property %q", name)
+			}
+		}
+	}
+	if min, ok := integer(schema["minProperties"]); ok && len(object) < min {
+		v.fail(pointer, "expected at least %d properties, got %d", min, len(object))
+	}
+	if max, ok := integer(schema["maxProperties"]); ok && len(object) > max {
+		v.fail(pointer, "expected at most %d properties, got %d", max, len(object))
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	patternProperties, _ := schema["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := schema["additionalProperties"]
+
+	for _, name := range sortedKeys(object) {
+		child := pointer + "/" + escapePointer(name)
+		matched := false
+		if propertySchema, found := properties[name]; found {
+			matched = true
+			if err := v.validate(propertySchema, object[name], child); err != nil {
+				return err
+			}
+		}
+		for _, pattern := range sortedKeys(patternProperties) {
+			re, err := v.schema.pattern(pattern)
+			if err != nil {
+				return err
+			}
+			if !re.MatchString(name) {
+				continue
+			}
+			matched = true
+			if err := v.validate(patternProperties[pattern], object[name], child); err != nil {
+				return err
+			}
+		}
+		if !matched && hasAdditional {
+			if allowed, ok := additional.(bool); ok && !allowed {
+				v.fail(child, "property %q is not allowed", name)
+				continue
+			}
+			if err := v.validate(additional, object[name], child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (v *validator) validateArray(schema map[string]interface{}, value interface{}, pointer string) error {
+	array, ok := value.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	if min, ok := integer(schema["minItems"]); ok && len(array) < min {
+		v.fail(pointer, "expected at least %d items, got %d", min, len(array))
+	}
+	if max, ok := integer(schema["maxItems"]); ok && len(array) > max {
+		v.fail(pointer, "expected at most %d items, got %d", max, len(array))
+	}
+
+	switch items := schema["items"].(type) {
+	case nil:
+	case []interface{}:
+		for i, item := range array {
+			if i >= len(items) {
+				break
+			}
+			if err := v.validate(items[i], item, pointer+"/"+strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+	default:
+		for i, item := range array {
+			if err := v.validate(items, item, pointer+"/"+strconv.Itoa(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (v *validator) validateString(schema map[string]interface{}, value interface{}, pointer string) error {
+	s, ok := value.(string)
+	if !ok {
+		return nil
+	}
+
+	length := utf8.RuneCountInString(s)
+	if min, ok := integer(schema["minLength"]); ok && length < min {
+		v.fail(pointer, "expected at least %d characters, got %d", min, length)
+	}
+	if max, ok := integer(schema["maxLength"]); ok && length > max {
+		v.fail(pointer, "expected at most %d characters, got %d", max, length)
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		re, err := v.schema.pattern(pattern)
+		if err != nil {
+			return err
+		}
+		if !re.MatchString(s) {
+			v.fail(pointer, "%q doesn't match the pattern %q", s, pattern)
+		}
+	}
+	return nil
+}
+
+func (v *validator) validateNumber(schema map[string]interface{}, value interface{}, pointer string) error {
+	n, ok := value.(json.Number)
+	if !ok {
+		return nil
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil
+	}
+
+	if min, ok := number(schema["minimum"]); ok && f < min {
+		v.fail(pointer, "expected at least %v, got %s", min, n)
+	}
+	if max, ok := number(schema["maximum"]); ok && f > max {
+		v.fail(pointer, "expected at most %v, got %s", max, n)
+	}
+	return nil
+}
+
+func (v *validator) validateCombinations(schema map[string]interface{}, value interface{}, pointer string) error {
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			if err := v.validate(sub, value, pointer); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, keyword := range []string{"anyOf", "oneOf"} {
+		schemas, ok := schema[keyword].([]interface{})
+		if !ok {
+			continue
+		}
+		matches := 0
+		for _, sub := range schemas {
+			valid, err := v.valid(sub, value, pointer)
+			if err != nil {
+				return err
+			}
+			if valid {
+				matches++
+			}
+		}
+		switch {
+		case matches == 0:
+			v.fail(pointer, "doesn't match any schema of %s", keyword)
+		case keyword == "oneOf" && matches > 1:
+			v.fail(pointer, "matches %d schemas of oneOf instead of one", matches)
+		}
+	}
+
+	if not, found := schema["not"]; found {
+		valid, err := v.valid(not, value, pointer)
+		if err != nil {
+			return err
+		}
+		if valid {
+			v.fail(pointer, "must not match the schema of not")
+		}
+	}
+	return nil
+}
+
+// pattern returns the compiled regular expression, cached for the schema.
+func (schema *Schema) pattern(pattern string) (*regexp.Regexp, error) {
+	schema.mu.Lock()
+	defer schema.mu.Unlock()
+
+	if re, found := schema.patterns[pattern]; found {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q in schema: %s", pattern, err)
+	}
+	schema.patterns[pattern] = re
+	return re, nil
+}
+
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func integer(value interface{}) (int, bool) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	i, err := n.Int64()
+	return int(i), err == nil
+}
+
+func number(value interface{}) (float64, bool) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := n.Float64()
+	return f, err == nil
+}
+
+// equal compares decoded JSON values, numbers by their value.
+func equal(a, b interface{}) bool {
+	if x, ok := a.(json.Number); ok {
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fx, errX := x.Float64()
+		fy, errY := y.Float64()
+		return errX == nil && errY == nil && fx == fy
+	}
+	return encode(a) == encode(b)
+}
+
+// encode returns value as JSON, objects with sorted keys.
+func encode(value interface{}) string {
+	content, _ := json.Marshal(value)
+	return string(content)
+}
+
+func escapePointer(token string) string {
+	return strings.Replace(strings.Replace(token, "~", "~0", -1), "/", "~1", -1)
+}
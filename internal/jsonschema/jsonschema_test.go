@@ -0,0 +1,109 @@
+package jsonschema
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	schema, err := Load(filepath.Join("testdata", "locale.schema.json"))
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	tests := []struct {
+		file       string
+		violations []string
+	}{
+		{"valid.json", nil},
+		{"invalid.json", []string{
+			"/app/Cart: property \"Cart\" is not allowed",
+			"/app/count: doesn't match any schema of anyOf",
+			"/app/title: doesn't match any schema of anyOf",
+			"/other: property \"other\" is not allowed",
+		}},
+	}
+
+	for _, test := range tests {
+		content, err := ioutil.ReadFile(filepath.Join("testdata", test.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		violations, err := schema.Validate(content)
+		if err != nil {
+			t.Errorf("%s: didn't expect an error, got: %s", test.file, err)
+			continue
+		}
+		if !reflect.DeepEqual(violations, test.violations) {
+			t.Errorf("%s: expected violations %q, got %q", test.file, test.violations, violations)
+		}
+	}
+}
+
+func TestValidateKeywords(t *testing.T) {
+	tests := []struct {
+		schema     string
+		document   string
+		violations []string
+	}{
+		{`{"type": "integer"}`, `1.5`, []string{"/: expected integer, got number"}},
+		{`{"type": ["string", "null"]}`, `null`, nil},
+		{`{"enum": ["a", 1]}`, `"b"`, []string{`/: expected one of "a", 1`}},
+		{`{"required": ["a", "b"]}`, `{"a": 1}`, []string{`/: missing required property "b"`}},
+		{`{"items": {"maxLength": 2}, "maxItems": 1}`, `["abc", "de"]`, []string{
+			"/: expected at most 1 items, got 2",
+			"/0: expected at most 2 characters, got 3",
+		}},
+		{`{"properties": {"a/b": {"pattern": "^x"}}}`, `{"a/b": "y"}`, []string{`/a~1b: "y" doesn't match the pattern "^x"`}},
+		{`{"oneOf": [{"type": "number"}, {"minimum": 0}]}`, `5`, []string{"/: matches 2 schemas of oneOf instead of one"}},
+		{`{"not": {"type": "string"}}`, `"a"`, []string{"/: must not match the schema of not"}},
+		{`false`, `{}`, []string{"/: no value is allowed"}},
+	}
+
+	for _, test := range tests {
+		schema, err := Parse([]byte(test.schema))
+		if err != nil {
+			t.Errorf("%s: didn't expect an error, got: %s", test.schema, err)
+			continue
+		}
+		violations, err := schema.Validate([]byte(test.document))
+		if err != nil {
+			t.Errorf("%s: didn't expect an error, got: %s", test.schema, err)
+			continue
+		}
+		if !reflect.DeepEqual(violations, test.violations) {
+			t.Errorf("%s with %s: expected violations %q, got %q", test.schema, test.document, test.violations, violations)
+		}
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	schema, err := Parse([]byte(`{"$ref": "http://example.com/schema.json"}`))
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if _, err := schema.Validate([]byte(`{}`)); err == nil {
+		t.Errorf("expected an error for a remote reference")
+	}
+	if _, err := schema.Validate([]byte(`{`)); err == nil {
+		t.Errorf("expected an error for invalid JSON")
+	}
+	if _, err := Parse([]byte(`[]`)); err == nil {
+		t.Errorf("expected an error for a schema that isn't an object")
+	}
+	for _, unsupported := range []string{
+		`{"type": "string", "format": "email"}`,
+		`{"properties": {"a": {"multipleOf": 2}}}`,
+		`{"items": [{"type": "string"}], "additionalItems": false}`,
+		`{"definitions": {"list": {"uniqueItems": true}}}`,
+		`{"anyOf": [{"exclusiveMinimum": 0}]}`,
+		`{"dependencies": {"a": ["b"]}}`,
+	} {
+		if _, err := Parse([]byte(unsupported)); err == nil || !strings.Contains(err.Error(), "unsupported keyword") {
+			t.Errorf("%s: expected an unsupported keyword error, got %v", unsupported, err)
+		}
+	}
+}
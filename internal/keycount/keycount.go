@@ -0,0 +1,51 @@
+package keycount
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Count returns the number of translation keys in content of a file with
+// the given extension. Keys are counted for JSON and YAML files, where every
+// value which isn't an object is a key. For other files only empty content
+// is known to have no keys, ok is false otherwise.
+func Count(extension string, content []byte) (count int, ok bool, err error) {
+	if len(bytes.TrimSpace(content)) == 0 {
+		return 0, true, nil
+	}
+
+	var value interface{}
+	switch strings.ToLower(strings.TrimPrefix(extension, ".")) {
+	case "json":
+		err = json.Unmarshal(content, &value)
+	case "yml", "yaml":
+		err = yaml.Unmarshal(content, &value)
+	default:
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	return leaves(value), true, nil
+}
+
+func leaves(value interface{}) int {
+	count := 0
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, child := range v {
+			count += leaves(child)
+		}
+	case map[interface{}]interface{}:
+		for _, child := range v {
+			count += leaves(child)
+		}
+	case nil:
+	default:
+		count = 1
+	}
+	return count
+}
@@ -0,0 +1,36 @@
+package keycount
+
+import (
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		extension string
+		content   string
+		count     int
+		ok        bool
+	}{
+		{extension: ".json", content: `{"app": {"title": "App", "ok": "OK"}, "cancel": "Cancel"}`, count: 3, ok: true},
+		{extension: ".json", content: `{}`, count: 0, ok: true},
+		{extension: ".yml", content: "en:\n  app:\n    title: App\n  cancel: Cancel\n", count: 2, ok: true},
+		{extension: ".yml", content: "en:\n", count: 0, ok: true},
+		{extension: ".strings", content: "  \n", count: 0, ok: true},
+		{extension: ".strings", content: `"cancel" = "Cancel";`, ok: false},
+	}
+
+	for _, test := range tests {
+		count, ok, err := Count(test.extension, []byte(test.content))
+		if err != nil {
+			t.Errorf("didn't expect an error for %q, got: %s", test.content, err)
+			continue
+		}
+		if count != test.count || ok != test.ok {
+			t.Errorf("expected %q to have %d keys (%v), got %d (%v)", test.content, test.count, test.ok, count, ok)
+		}
+	}
+
+	if _, _, err := Count(".json", []byte("{")); err == nil {
+		t.Errorf("expected an error for invalid JSON")
+	}
+}
@@ -0,0 +1,70 @@
+package keyfilter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Supported returns true if keys of files with the given extension can be
+// filtered. Only JSON is supported, which covers all JSON based formats like
+// simple_json, nested_json or i18next.
+func Supported(extension string) bool {
+	return strings.ToLower(strings.TrimPrefix(extension, ".")) == "json"
+}
+
+// JSON returns the JSON object content reduced to the given keys. A key is
+// either a member of the top level object or, for nested objects, a path of
+// members joined by dots, e.g. "app.title". Keys which are not found are
+// returned as missing.
+func JSON(content []byte, keys []string) ([]byte, []string, error) {
+	object := map[string]interface{}{}
+	if err := json.Unmarshal(content, &object); err != nil {
+		return nil, nil, fmt.Errorf("keys can only be filtered in JSON objects: %s", err)
+	}
+
+	filtered := map[string]interface{}{}
+	missing := []string{}
+	for _, key := range keys {
+		if value, ok := object[key]; ok {
+			filtered[key] = value
+			continue
+		}
+		if !copyPath(object, filtered, strings.Split(key, ".")) {
+			missing = append(missing, key)
+		}
+	}
+
+	result, err := json.MarshalIndent(filtered, "", "  ")
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(result, '\n'), missing, nil
+}
+
+// copyPath copies the value at path in src to the same path in dst, creating
+// intermediate objects. It returns false if src has no value at path.
+func copyPath(src, dst map[string]interface{}, path []string) bool {
+	value, ok := src[path[0]]
+	if !ok {
+		return false
+	}
+	if len(path) == 1 {
+		dst[path[0]] = value
+		return true
+	}
+
+	child, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	dstChild, ok := dst[path[0]].(map[string]interface{})
+	if !ok {
+		dstChild = map[string]interface{}{}
+	}
+	if !copyPath(child, dstChild, path[1:]) {
+		return false
+	}
+	dst[path[0]] = dstChild
+	return true
+}
@@ -0,0 +1,56 @@
+package keyfilter
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	content := []byte(`{
+  "greeting": "Hello",
+  "farewell": "Bye",
+  "app": {
+    "title": "App",
+    "menu": {"open": "Open", "close": "Close"}
+  },
+  "flat.dotted.key": "Dotted"
+}`)
+
+	filtered, missing, err := JSON(content, []string{"greeting", "app.menu.open", "app.title", "flat.dotted.key", "unknown", "greeting.nested"})
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	var actual interface{}
+	if err := json.Unmarshal(filtered, &actual); err != nil {
+		t.Fatal(err)
+	}
+	var expected interface{}
+	json.Unmarshal([]byte(`{
+  "greeting": "Hello",
+  "app": {"title": "App", "menu": {"open": "Open"}},
+  "flat.dotted.key": "Dotted"
+}`), &expected)
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+
+	if !reflect.DeepEqual(missing, []string{"unknown", "greeting.nested"}) {
+		t.Errorf("expected missing keys to be returned, got %v", missing)
+	}
+}
+
+func TestJSONInvalid(t *testing.T) {
+	if _, _, err := JSON([]byte(`["not", "an", "object"]`), []string{"a"}); err == nil {
+		t.Errorf("expected an error for a JSON array")
+	}
+}
+
+func TestSupported(t *testing.T) {
+	for ext, exp := range map[string]bool{".json": true, "JSON": true, ".yml": false, ".xlf": false} {
+		if Supported(ext) != exp {
+			t.Errorf("expected Supported(%q) to be %t", ext, exp)
+		}
+	}
+}
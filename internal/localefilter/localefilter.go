@@ -0,0 +1,108 @@
+// Package localefilter selects locales by their attributes.
+//
+// A filter is a comma separated list of conditions, all of which must match.
+// A condition compares an attribute with a value using = or !=, values may
+// contain the wildcards of path.Match:
+//
+//	rtl=true
+//	code=en-*,default!=true
+//
+// The attributes are code, name, id, rtl, default, main and source, the code
+// of the source locale.
+package localefilter
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+var attributes = map[string]func(*phraseapp.Locale) string{
+	"code":    func(l *phraseapp.Locale) string { return l.Code },
+	"name":    func(l *phraseapp.Locale) string { return l.Name },
+	"id":      func(l *phraseapp.Locale) string { return l.ID },
+	"rtl":     func(l *phraseapp.Locale) string { return strconv.FormatBool(l.Rtl) },
+	"default": func(l *phraseapp.Locale) string { return strconv.FormatBool(l.Default) },
+	"main":    func(l *phraseapp.Locale) string { return strconv.FormatBool(l.Main) },
+	"source": func(l *phraseapp.Locale) string {
+		if l.SourceLocale == nil {
+			return ""
+		}
+		return l.SourceLocale.Code
+	},
+}
+
+type condition struct {
+	attribute string
+	negate    bool
+	pattern   string
+}
+
+// Filter matches locales against a list of conditions.
+type Filter struct {
+	conditions []condition
+}
+
+// Parse parses a filter expression.
+func Parse(expr string) (*Filter, error) {
+	filter := &Filter{}
+	for _, part := range strings.Split(expr, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		c := condition{}
+		var parts []string
+		if strings.Contains(part, "!=") {
+			parts = strings.SplitN(part, "!=", 2)
+			c.negate = true
+		} else {
+			parts = strings.SplitN(part, "=", 2)
+		}
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid condition %q, expected attribute=value or attribute!=value", part)
+		}
+
+		c.attribute = strings.ToLower(strings.TrimSpace(parts[0]))
+		c.pattern = strings.TrimSpace(parts[1])
+		if _, ok := attributes[c.attribute]; !ok {
+			return nil, fmt.Errorf("unknown locale attribute %q in condition %q", c.attribute, part)
+		}
+		if _, err := path.Match(c.pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid value %q in condition %q: %s", c.pattern, part, err)
+		}
+
+		filter.conditions = append(filter.conditions, c)
+	}
+
+	if len(filter.conditions) == 0 {
+		return nil, fmt.Errorf("empty locale filter")
+	}
+	return filter, nil
+}
+
+// Match returns true if locale matches all conditions of the filter.
+func (filter *Filter) Match(locale *phraseapp.Locale) bool {
+	for _, c := range filter.conditions {
+		matched, _ := path.Match(c.pattern, attributes[c.attribute](locale))
+		if matched == c.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// Select returns the locales matching the filter.
+func (filter *Filter) Select(locales []*phraseapp.Locale) []*phraseapp.Locale {
+	selected := []*phraseapp.Locale{}
+	for _, locale := range locales {
+		if filter.Match(locale) {
+			selected = append(selected, locale)
+		}
+	}
+	return selected
+}
@@ -0,0 +1,60 @@
+package localefilter
+
+import (
+	"testing"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func TestSelect(t *testing.T) {
+	locales := []*phraseapp.Locale{
+		{Code: "en", Name: "english", Default: true},
+		{Code: "en-GB", Name: "british", SourceLocale: &phraseapp.LocalePreview{Code: "en"}},
+		{Code: "ar", Name: "arabic", Rtl: true},
+		{Code: "he", Name: "hebrew", Rtl: true},
+	}
+
+	tests := []struct {
+		expr     string
+		expected []string
+	}{
+		{"rtl=true", []string{"ar", "he"}},
+		{"rtl=false", []string{"en", "en-GB"}},
+		{"code=en*", []string{"en", "en-GB"}},
+		{"code=en*, default!=true", []string{"en-GB"}},
+		{"source=en", []string{"en-GB"}},
+		{"name!=hebrew,rtl=true", []string{"ar"}},
+	}
+
+	for _, test := range tests {
+		filter, err := Parse(test.expr)
+		if err != nil {
+			t.Errorf("%s: didn't expect an error, got: %s", test.expr, err)
+			continue
+		}
+
+		selected := filter.Select(locales)
+		codes := []string{}
+		for _, locale := range selected {
+			codes = append(codes, locale.Code)
+		}
+		if len(codes) != len(test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.expr, test.expected, codes)
+			continue
+		}
+		for i := range codes {
+			if codes[i] != test.expected[i] {
+				t.Errorf("%s: expected %v, got %v", test.expr, test.expected, codes)
+				break
+			}
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{"", "rtl", "color=red", "code=[en"} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("expected an error for %q", expr)
+		}
+	}
+}
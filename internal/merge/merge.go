@@ -0,0 +1,114 @@
+package merge
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// File is the content of a file to merge, its name is used in errors.
+type File struct {
+	Name    string
+	Content []byte
+}
+
+// Supported returns true if files with the given extension can be merged.
+// JSON and YAML are supported, which covers formats like simple_json,
+// nested_json, i18next or yml.
+func Supported(extension string) bool {
+	switch strings.ToLower(strings.TrimPrefix(extension, ".")) {
+	case "json", "yml", "yaml":
+		return true
+	}
+	return false
+}
+
+// Files merges the objects of files with the given extension into one.
+// Nested objects are merged recursively. A key defined with different values
+// in two files is an error, as it's not clear which translation is wanted.
+func Files(extension string, files []File) ([]byte, error) {
+	isJSON := strings.ToLower(strings.TrimPrefix(extension, ".")) == "json"
+
+	merged := map[string]interface{}{}
+	definedIn := map[string]string{}
+	for _, file := range files {
+		object := map[string]interface{}{}
+		if isJSON {
+			if err := json.Unmarshal(file.Content, &object); err != nil {
+				return nil, fmt.Errorf("%s: only JSON objects can be merged: %s", file.Name, err)
+			}
+		} else {
+			raw := map[interface{}]interface{}{}
+			if err := yaml.Unmarshal(file.Content, &raw); err != nil {
+				return nil, fmt.Errorf("%s: only YAML mappings can be merged: %s", file.Name, err)
+			}
+			object = stringKeys(raw)
+		}
+
+		if err := mergeInto(merged, object, "", file.Name, definedIn); err != nil {
+			return nil, err
+		}
+	}
+
+	if !isJSON {
+		return yaml.Marshal(merged)
+	}
+	result, err := json.MarshalIndent(merged, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(result, '\n'), nil
+}
+
+// mergeInto adds the keys of src to dst. definedIn records the file of each
+// key path, to name both files of a conflict.
+func mergeInto(dst, src map[string]interface{}, prefix, name string, definedIn map[string]string) error {
+	for key, value := range src {
+		path := prefix + key
+		existing, found := dst[key]
+		if !found {
+			dst[key] = value
+			markDefined(value, path, name, definedIn)
+			continue
+		}
+
+		dstChild, dstIsObject := existing.(map[string]interface{})
+		srcChild, srcIsObject := value.(map[string]interface{})
+		if dstIsObject && srcIsObject {
+			if err := mergeInto(dstChild, srcChild, path+".", name, definedIn); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(existing, value) {
+			return fmt.Errorf("key %q is defined differently in %s and %s", path, definedIn[path], name)
+		}
+	}
+	return nil
+}
+
+func markDefined(value interface{}, path, name string, definedIn map[string]string) {
+	definedIn[path] = name
+	if object, ok := value.(map[string]interface{}); ok {
+		for key, child := range object {
+			markDefined(child, path+"."+key, name, definedIn)
+		}
+	}
+}
+
+// stringKeys converts the mappings decoded from YAML to objects with string
+// keys, like decoded from JSON.
+func stringKeys(raw map[interface{}]interface{}) map[string]interface{} {
+	object := map[string]interface{}{}
+	for key, value := range raw {
+		if child, ok := value.(map[interface{}]interface{}); ok {
+			value = stringKeys(child)
+		}
+		object[fmt.Sprint(key)] = value
+	}
+	return object
+}
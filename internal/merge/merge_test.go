@@ -0,0 +1,57 @@
+package merge
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFilesJSON(t *testing.T) {
+	merged, err := Files(".json", []File{
+		{Name: "common.en.json", Content: []byte(`{"app": {"title": "App", "ok": "OK"}, "cancel": "Cancel"}`)},
+		{Name: "feature.en.json", Content: []byte(`{"app": {"feature": "Feature", "ok": "OK"}}`)},
+	})
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	expected := `{
+  "app": {
+    "feature": "Feature",
+    "ok": "OK",
+    "title": "App"
+  },
+  "cancel": "Cancel"
+}
+`
+	if string(merged) != expected {
+		t.Errorf("expected %q, got %q", expected, merged)
+	}
+}
+
+func TestFilesJSONConflict(t *testing.T) {
+	_, err := Files("json", []File{
+		{Name: "common.en.json", Content: []byte(`{"app": {"title": "App"}}`)},
+		{Name: "feature.en.json", Content: []byte(`{"app": {"title": "Feature"}}`)},
+	})
+	if err == nil {
+		t.Fatalf("expected an error for a key defined differently")
+	}
+	if !strings.Contains(err.Error(), `"app.title"`) || !strings.Contains(err.Error(), "common.en.json and feature.en.json") {
+		t.Errorf("expected the error to name the key and both files, got: %s", err)
+	}
+}
+
+func TestFilesYAML(t *testing.T) {
+	merged, err := Files("yml", []File{
+		{Name: "common.en.yml", Content: []byte("en:\n  cancel: Cancel\n")},
+		{Name: "feature.en.yml", Content: []byte("en:\n  feature: Feature\n")},
+	})
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	expected := "en:\n  cancel: Cancel\n  feature: Feature\n"
+	if string(merged) != expected {
+		t.Errorf("expected %q, got %q", expected, merged)
+	}
+}
@@ -0,0 +1,83 @@
+package minify
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+var xmlExtensions = []string{"xml", "xlf", "xliff", "resx", "tmx"}
+
+// Supported returns true if content with the given file extension can be
+// minified.
+func Supported(extension string) bool {
+	extension = strings.ToLower(strings.TrimPrefix(extension, "."))
+	if extension == "json" {
+		return true
+	}
+	for _, ext := range xmlExtensions {
+		if ext == extension {
+			return true
+		}
+	}
+	return false
+}
+
+// Minify removes insignificant whitespace from JSON and XML content, chosen
+// by the file extension. Content of unsupported types is returned unchanged.
+func Minify(extension string, content []byte) ([]byte, error) {
+	if !Supported(extension) {
+		return content, nil
+	}
+
+	if strings.ToLower(strings.TrimPrefix(extension, ".")) == "json" {
+		return JSON(content)
+	}
+	return XML(content)
+}
+
+// JSON removes all whitespace between JSON tokens.
+func JSON(content []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := json.Compact(buf, content); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// XML removes whitespace only text between elements if it spans multiple
+// lines, i.e. the indentation. Everything else, including whitespace inside of
+// elements on a single line, is copied verbatim from the input.
+func XML(content []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+
+	offset := int64(0)
+	for {
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		end := decoder.InputOffset()
+		raw := content[offset:end]
+		offset = end
+
+		if data, ok := token.(xml.CharData); ok && isIndentation(data) && !bytes.HasPrefix(raw, []byte("<![CDATA[")) {
+			continue
+		}
+		buf.Write(raw)
+	}
+	buf.Write(content[offset:])
+
+	return buf.Bytes(), nil
+}
+
+func isIndentation(data []byte) bool {
+	return len(bytes.TrimSpace(data)) == 0 && bytes.Contains(data, []byte("\n"))
+}
@@ -0,0 +1,74 @@
+package minify
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMinifyJSON(t *testing.T) {
+	content := []byte(`{
+  "greeting": "Hello  World",
+  "nested": {
+    "list": [ 1, 2, 3 ]
+  }
+}
+`)
+
+	minified, err := Minify(".json", content)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	expected := `{"greeting":"Hello  World","nested":{"list":[1,2,3]}}`
+	if string(minified) != expected {
+		t.Errorf("expected %q, got %q", expected, minified)
+	}
+
+	var before, after interface{}
+	if err := json.Unmarshal(content, &before); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(minified, &after); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(before, after) {
+		t.Errorf("expected minified content to be equivalent, got %v and %v", before, after)
+	}
+}
+
+func TestMinifyXML(t *testing.T) {
+	content := []byte(`<?xml version="1.0" encoding="utf-8"?>
+<resources>
+  <string name="greeting">Hello  World</string>
+  <string name="space"> </string>
+  <string name="cdata"><![CDATA[
+]]></string>
+  <!-- comment -->
+</resources>
+`)
+
+	minified, err := Minify("xml", content)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	expected := `<?xml version="1.0" encoding="utf-8"?><resources><string name="greeting">Hello  World</string><string name="space"> </string><string name="cdata"><![CDATA[
+]]></string><!-- comment --></resources>`
+	if string(minified) != expected {
+		t.Errorf("expected %q, got %q", expected, minified)
+	}
+}
+
+func TestMinifyUnsupported(t *testing.T) {
+	content := []byte("en:\n  greeting: Hello\n")
+
+	minified, err := Minify(".yml", content)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	if string(minified) != string(content) {
+		t.Errorf("expected content to be unchanged, got %q", minified)
+	}
+}
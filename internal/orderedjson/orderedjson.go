@@ -0,0 +1,151 @@
+package orderedjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Member is a key of a JSON object with its value.
+type Member struct {
+	Key   string
+	Value interface{}
+}
+
+// Object is a JSON object keeping its keys in order as a list of members.
+type Object []Member
+
+// Get returns the value of key.
+func (object Object) Get(key string) (interface{}, bool) {
+	for _, member := range object {
+		if member.Key == key {
+			return member.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Set replaces the value of key or appends it, if the object doesn't have
+// the key yet.
+func (object *Object) Set(key string, value interface{}) {
+	for i, member := range *object {
+		if member.Key == key {
+			(*object)[i].Value = value
+			return
+		}
+	}
+	*object = append(*object, Member{Key: key, Value: value})
+}
+
+// Decode returns the JSON value of content with objects decoded as Object
+// and numbers as json.Number, so encoding it again keeps keys and numbers as
+// they are. Of duplicate keys, the last value is kept.
+func Decode(content []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.UseNumber()
+
+	value, err := decode(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected content after the top level value")
+	}
+	return value, nil
+}
+
+func decode(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		result := Object{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			result.Set(key.(string), value)
+		}
+		_, err := decoder.Token()
+		return result, err
+	case json.Delim('['):
+		result := []interface{}{}
+		for decoder.More() {
+			value, err := decode(decoder)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+		}
+		_, err := decoder.Token()
+		return result, err
+	}
+	return token, nil
+}
+
+// Encode writes value indented by two spaces, like the downloads of
+// PhraseApp. HTML characters are not escaped.
+func Encode(buf *bytes.Buffer, value interface{}) error {
+	return encode(buf, value, "")
+}
+
+func encode(buf *bytes.Buffer, value interface{}, indent string) error {
+	switch value := value.(type) {
+	case Object:
+		if len(value) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{\n")
+		for i, member := range value {
+			buf.WriteString(indent + "  ")
+			if err := encode(buf, member.Key, ""); err != nil {
+				return err
+			}
+			buf.WriteString(": ")
+			if err := encode(buf, member.Value, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(value)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "}")
+	case []interface{}:
+		if len(value) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[\n")
+		for i, element := range value {
+			buf.WriteString(indent + "  ")
+			if err := encode(buf, element, indent+"  "); err != nil {
+				return err
+			}
+			if i < len(value)-1 {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "]")
+	default:
+		scalar := &bytes.Buffer{}
+		encoder := json.NewEncoder(scalar)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(value); err != nil {
+			return err
+		}
+		buf.Write(bytes.TrimRight(scalar.Bytes(), "\n"))
+	}
+	return nil
+}
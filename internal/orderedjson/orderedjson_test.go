@@ -0,0 +1,37 @@
+package orderedjson
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeEncode(t *testing.T) {
+	content := `{"b": "<b>", "a": {"z": 1.50, "y": [true, null]}, "b": "B"}`
+
+	value, err := Decode([]byte(content))
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := Encode(buf, value); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	exp := `{
+  "b": "B",
+  "a": {
+    "z": 1.50,
+    "y": [
+      true,
+      null
+    ]
+  }
+}`
+	if buf.String() != exp {
+		t.Errorf("expected %s, got %s", exp, buf.String())
+	}
+
+	if _, err := Decode([]byte(`{} {}`)); err == nil {
+		t.Errorf("expected an error for content after the top level value")
+	}
+}
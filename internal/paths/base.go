@@ -0,0 +1,75 @@
+package paths
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// envVarRegexp matches environment variables written as $VAR, ${VAR} or %VAR%.
+var envVarRegexp = regexp.MustCompile(`\$\{(\w+)\}|\$(\w+)|%(\w+)%`)
+
+// appDataVars are the well-known application data directories. If they are
+// not set, they default to the application data directory of the operating
+// system, so the same base works on all platforms.
+var appDataVars = []string{"XDG_DATA_HOME", "APPDATA", "LOCALAPPDATA"}
+
+// ExpandBase expands the environment variables in base, written as $VAR,
+// ${VAR} or %VAR%, for the operating system goos. Unset variables are an
+// error, except for well-known application data directories.
+func ExpandBase(base, goos string, getenv func(string) string) (string, error) {
+	var err error
+	expanded := envVarRegexp.ReplaceAllStringFunc(base, func(match string) string {
+		groups := envVarRegexp.FindStringSubmatch(match)
+		name := groups[1] + groups[2] + groups[3]
+
+		if value := getenv(name); value != "" {
+			return value
+		}
+		for _, appDataVar := range appDataVars {
+			if name == appDataVar {
+				dir, dirErr := appDataDir(goos, getenv)
+				if dirErr != nil && err == nil {
+					err = dirErr
+				}
+				return dir
+			}
+		}
+
+		if err == nil {
+			err = fmt.Errorf("environment variable %s of %q is not set", name, base)
+		}
+		return ""
+	})
+	if err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
+
+// appDataDir returns the directory for application data of the operating
+// system goos.
+func appDataDir(goos string, getenv func(string) string) (string, error) {
+	switch goos {
+	case "windows":
+		if dir := getenv("APPDATA"); dir != "" {
+			return dir, nil
+		}
+		return homeDir(getenv, "USERPROFILE", `\AppData\Roaming`)
+	case "darwin":
+		return homeDir(getenv, "HOME", "/Library/Application Support")
+	default:
+		if dir := getenv("XDG_DATA_HOME"); dir != "" {
+			return dir, nil
+		}
+		return homeDir(getenv, "HOME", "/.local/share")
+	}
+}
+
+func homeDir(getenv func(string) string, name, suffix string) (string, error) {
+	home := getenv(name)
+	if home == "" {
+		return "", fmt.Errorf("can't determine the application data directory, %s is not set", name)
+	}
+	return strings.TrimRight(home, `/\`) + suffix, nil
+}
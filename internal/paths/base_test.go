@@ -0,0 +1,78 @@
+package paths
+
+import (
+	"testing"
+)
+
+func TestExpandBase(t *testing.T) {
+	tests := []struct {
+		base   string
+		goos   string
+		env    map[string]string
+		expect string
+	}{
+		{
+			base:   "$XDG_DATA_HOME/myapp",
+			goos:   "linux",
+			env:    map[string]string{"XDG_DATA_HOME": "/data", "HOME": "/home/me"},
+			expect: "/data/myapp",
+		}, {
+			base:   "${XDG_DATA_HOME}/myapp",
+			goos:   "linux",
+			env:    map[string]string{"HOME": "/home/me"},
+			expect: "/home/me/.local/share/myapp",
+		}, {
+			base:   "%APPDATA%/myapp",
+			goos:   "linux",
+			env:    map[string]string{"HOME": "/home/me/"},
+			expect: "/home/me/.local/share/myapp",
+		}, {
+			base:   "$XDG_DATA_HOME/myapp",
+			goos:   "darwin",
+			env:    map[string]string{"HOME": "/Users/me"},
+			expect: "/Users/me/Library/Application Support/myapp",
+		}, {
+			base:   `%APPDATA%\myapp`,
+			goos:   "windows",
+			env:    map[string]string{"APPDATA": `C:\Users\me\AppData\Roaming`},
+			expect: `C:\Users\me\AppData\Roaming\myapp`,
+		}, {
+			base:   `$XDG_DATA_HOME\myapp`,
+			goos:   "windows",
+			env:    map[string]string{"USERPROFILE": `C:\Users\me`},
+			expect: `C:\Users\me\AppData\Roaming\myapp`,
+		}, {
+			base:   "$BUILD_DIR/%LANG_DIR%",
+			goos:   "linux",
+			env:    map[string]string{"BUILD_DIR": "/build", "LANG_DIR": "lang"},
+			expect: "/build/lang",
+		}, {
+			base:   "./locales",
+			goos:   "linux",
+			expect: "./locales",
+		},
+	}
+
+	for _, test := range tests {
+		getenv := func(name string) string { return test.env[name] }
+		was, err := ExpandBase(test.base, test.goos, getenv)
+		if err != nil {
+			t.Errorf("didn't expect an error for %q on %s, got: %s", test.base, test.goos, err)
+			continue
+		}
+		if was != test.expect {
+			t.Errorf("expected %q on %s to expand to %q, but was: %q", test.base, test.goos, test.expect, was)
+		}
+	}
+}
+
+func TestExpandBaseErrors(t *testing.T) {
+	getenv := func(string) string { return "" }
+
+	if _, err := ExpandBase("$UNKNOWN/myapp", "linux", getenv); err == nil {
+		t.Errorf("expected an error for an unset variable")
+	}
+	if _, err := ExpandBase("%APPDATA%/myapp", "windows", getenv); err == nil {
+		t.Errorf("expected an error without USERPROFILE")
+	}
+}
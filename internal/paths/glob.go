@@ -3,6 +3,7 @@ package paths
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
 	"strings"
@@ -134,3 +135,54 @@ func directoriesOnly(candidates []string) []string {
 		return fi.IsDir()
 	})
 }
+
+// Match reports whether the slash separated path name, e.g. of an archive
+// entry, matches pattern with the same * and ** globbing as Glob.
+func Match(pattern, name string) (bool, error) {
+	pattern = path.Clean(filepath.ToSlash(pattern))
+	name = path.Clean(name)
+
+	if strings.Count(pattern, dirGlobOperator) > 1 {
+		return false, fmt.Errorf("invalid pattern '%s': the ** globbing operator may only be used once in a pattern", pattern)
+	}
+
+	patternSegments := strings.Split(pattern, "/")
+	nameSegments := strings.Split(name, "/")
+
+	for i, segment := range patternSegments {
+		if segment != dirGlobOperator {
+			continue
+		}
+		start, end := patternSegments[:i], patternSegments[i+1:]
+		if len(nameSegments) < len(start)+len(end) {
+			return false, nil
+		}
+		ok, err := matchSegments(start, nameSegments[:len(start)])
+		if !ok || err != nil {
+			return false, err
+		}
+		return matchSegments(end, nameSegments[len(nameSegments)-len(end):])
+	}
+
+	if strings.Contains(pattern, dirGlobOperator) {
+		return false, fmt.Errorf("invalid pattern '%s': the ** globbing operator may only be used as path segment on its own, i.e. …/**/… or **/…", pattern)
+	}
+	if len(patternSegments) != len(nameSegments) {
+		return false, nil
+	}
+	return matchSegments(patternSegments, nameSegments)
+}
+
+func matchSegments(patterns, names []string) (bool, error) {
+	escaper := strings.NewReplacer("?", "\\?", "[", "\\[")
+	for i, pattern := range patterns {
+		ok, err := path.Match(escaper.Replace(pattern), names[i])
+		if err != nil {
+			return false, fmt.Errorf("invalid pattern '%s': %s", pattern, err)
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -197,3 +197,34 @@ func TestSplitAtDirGlobOperator(t *testing.T) {
 		}
 	}
 }
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		match         bool
+	}{
+		{"locales/*.json", "locales/en.json", true},
+		{"./locales/*.json", "locales/en.json", true},
+		{"locales/*.json", "locales/de/en.json", false},
+		{"locales/*.json", "en.json", false},
+		{"locales/**/*.json", "locales/en.json", true},
+		{"locales/**/*.json", "locales/web/de/en.json", true},
+		{"**/*.json", "a/b/en.json", true},
+		{"**/*.json", "a/b/en.yml", false},
+		{"foo?bar/*.yml", "foo?bar/en.yml", true},
+		{"foo?bar/*.yml", "fooxbar/en.yml", false},
+	}
+	for _, test := range tests {
+		match, err := Match(test.pattern, test.name)
+		if err != nil {
+			t.Errorf("%s: didn't expect an error, got: %s", test.pattern, err)
+		}
+		if match != test.match {
+			t.Errorf("expected %s matching %s to be %v", test.name, test.pattern, test.match)
+		}
+	}
+
+	if _, err := Match("locales/**/**/*.json", "locales/en.json"); err == nil {
+		t.Errorf("expected an error for two ** operators")
+	}
+}
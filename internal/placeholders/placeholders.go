@@ -9,12 +9,63 @@ import (
 	"github.com/phrase/phraseapp-client/internal/stringz"
 )
 
+// Placeholders may carry a modifier transforming the substituted value, e.g.
+// <locale_code:lower> or <locale_code:upper>.
 var (
-	anyPlaceholderRegexp = regexp.MustCompile("<(locale_name|tag|locale_code)>")
-	localePlaceholder    = regexp.MustCompile("<(locale_name|locale_code)>")
-	tagPlaceholder       = regexp.MustCompile("<(tag)>")
+	anyPlaceholderRegexp = regexp.MustCompile("<(locale_name|tag|locale_code|project)(?::(lower|upper))?>")
+	localePlaceholder    = regexp.MustCompile("<(locale_name|locale_code)(?::(lower|upper))?>")
+	tagPlaceholder       = regexp.MustCompile("<(tag)(?::(lower|upper))?>")
 )
 
+// legacyPlaceholderRegexp matches placeholders of the :locale: syntax of old
+// configs, with the names they translate to.
+var (
+	legacyPlaceholderRegexp = regexp.MustCompile(":(locale|locale_code|locale_name|tag):")
+	legacyPlaceholderNames  = map[string]string{
+		"locale":      "locale_code",
+		"locale_code": "locale_code",
+		"locale_name": "locale_name",
+		"tag":         "tag",
+	}
+)
+
+// anyTokenRegexp matches everything looking like a placeholder, to detect
+// unsupported ones.
+var anyTokenRegexp = regexp.MustCompile("<[^<>/]*>")
+
+// Unsupported returns all tokens in s looking like placeholders which are not
+// one of the supported ones, or use a supported placeholder with an unknown
+// modifier.
+func Unsupported(s string, supported []string) []string {
+	unsupported := []string{}
+	for _, token := range anyTokenRegexp.FindAllString(s, -1) {
+		match := anyPlaceholderRegexp.FindStringSubmatch(token)
+		if match == nil || match[0] != token || !stringz.Contains(supported, match[1]) {
+			unsupported = append(unsupported, token)
+		}
+	}
+	return unsupported
+}
+
+var modifiers = map[string]func(string) string{
+	"":      func(s string) string { return s },
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// TranslateLegacy converts placeholders of the legacy syntax in s, e.g.
+// :locale: or :tag:, to the current syntax. It returns false if s contains no
+// legacy placeholders.
+func TranslateLegacy(s string) (string, bool) {
+	if !legacyPlaceholderRegexp.MatchString(s) {
+		return s, false
+	}
+	return legacyPlaceholderRegexp.ReplaceAllStringFunc(s, func(placeholder string) string {
+		name := legacyPlaceholderRegexp.FindStringSubmatch(placeholder)[1]
+		return "<" + legacyPlaceholderNames[name] + ">"
+	}), true
+}
+
 func ContainsAnyPlaceholders(s string) bool {
 	return anyPlaceholderRegexp.MatchString(s)
 }
@@ -27,6 +78,19 @@ func ContainsTagPlaceholder(s string) bool {
 	return tagPlaceholder.MatchString(s)
 }
 
+// Replace substitutes the placeholders in s with the values for their names,
+// applying the modifiers of the placeholders.
+func Replace(s string, values map[string]string) string {
+	return anyPlaceholderRegexp.ReplaceAllStringFunc(s, func(placeholder string) string {
+		match := anyPlaceholderRegexp.FindStringSubmatch(placeholder)
+		value, ok := values[match[1]]
+		if !ok {
+			return placeholder
+		}
+		return modifiers[match[2]](value)
+	})
+}
+
 func ToGlobbingPattern(s string) string {
 	path := anyPlaceholderRegexp.ReplaceAllString(s, "*")
 	baseName := filepath.Base(s)
@@ -58,9 +122,9 @@ func Resolve(s, pattern string) (map[string]string, error) {
 	patternRE = strings.Replace(patternRE, "\\*", ".*", -1)
 
 	for _, placeholder := range stringz.RemoveDuplicates(placeholders) {
-		placeholder = regexp.QuoteMeta(placeholder)
-		placeholderRE := fmt.Sprintf("(?P%s[^/]+)", placeholder) // build named subexpression (capturing group) from placeholder
-		patternRE = strings.Replace(patternRE, placeholder, placeholderRE, -1)
+		name := anyPlaceholderRegexp.FindStringSubmatch(placeholder)[1]
+		placeholderRE := fmt.Sprintf("(?P<%s>[^/]+)", name) // build named subexpression (capturing group) from placeholder
+		patternRE = strings.Replace(patternRE, regexp.QuoteMeta(placeholder), placeholderRE, -1)
 	}
 
 	patternRegex, err := regexp.Compile(patternRE)
@@ -138,3 +138,83 @@ func areEqual(got, want map[string]string) bool {
 
 	return true
 }
+
+func TestReplace(t *testing.T) {
+	values := map[string]string{"locale_code": "pt-BR", "locale_name": "Portuguese", "tag": "app"}
+	tests := []struct {
+		pattern  string
+		expected string
+	}{
+		{"values-<locale_code>/strings.xml", "values-pt-BR/strings.xml"},
+		{"values-<locale_code:lower>/strings.xml", "values-pt-br/strings.xml"},
+		{"<locale_code:upper>/<tag>.json", "PT-BR/app.json"},
+		{"<locale_name:lower>-<locale_code>.yml", "portuguese-pt-BR.yml"},
+		{"<locale_code:title>.yml", "<locale_code:title>.yml"},
+	}
+
+	for _, test := range tests {
+		if result := Replace(test.pattern, values); result != test.expected {
+			t.Errorf("expected %q to be replaced with %q, got %q", test.pattern, test.expected, result)
+		}
+	}
+}
+
+func TestModifierPlaceholders(t *testing.T) {
+	if !ContainsLocalePlaceholder("values-<locale_code:lower>/strings.xml") {
+		t.Errorf("expected modified locale placeholder to be recognized")
+	}
+
+	result, err := Resolve("values-pt-br/strings.xml", "values-<locale_code:lower>/strings.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result["locale_code"] != "pt-br" {
+		t.Errorf("expected locale_code to be resolved, got %v", result)
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	supported := []string{"locale_name", "locale_code", "tag"}
+	tests := []struct {
+		pattern  string
+		expected []string
+	}{
+		{"./locales/<locale_code>/<tag>.json", []string{}},
+		{"./locales/<locale_code:lower>.json", []string{}},
+		{"./locales/<locale_cod>.json", []string{"<locale_cod>"}},
+		{"./<project>/<locale_code:title>.json", []string{"<project>", "<locale_code:title>"}},
+	}
+
+	for _, test := range tests {
+		result := Unsupported(test.pattern, supported)
+		if len(result) != len(test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.pattern, test.expected, result)
+			continue
+		}
+		for i := range result {
+			if result[i] != test.expected[i] {
+				t.Errorf("%s: expected %v, got %v", test.pattern, test.expected, result)
+			}
+		}
+	}
+}
+
+func TestTranslateLegacy(t *testing.T) {
+	tests := []struct {
+		pattern    string
+		expected   string
+		translated bool
+	}{
+		{"./locales/:locale:.yml", "./locales/<locale_code>.yml", true},
+		{"./:tag:/:locale_name:-:locale_code:.json", "./<tag>/<locale_name>-<locale_code>.json", true},
+		{"./locales/<locale_code>.yml", "./locales/<locale_code>.yml", false},
+		{"./locales/:other:.yml", "./locales/:other:.yml", false},
+	}
+
+	for _, test := range tests {
+		result, translated := TranslateLegacy(test.pattern)
+		if result != test.expected || translated != test.translated {
+			t.Errorf("%s: expected %q (%v), got %q (%v)", test.pattern, test.expected, test.translated, result, translated)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package print
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	ct "github.com/daviddengcn/go-colortext"
+)
+
+// Buffer collects output to print it later, e.g. to keep the output of an
+// operation running concurrently with others together. A nil Buffer prints
+// right away.
+type Buffer struct {
+	mu    sync.Mutex
+	lines []bufferedLine
+}
+
+type bufferedLine struct {
+	color ct.Color
+	text  string
+}
+
+// Line prints a line without color.
+func (b *Buffer) Line(msg string, args ...interface{}) {
+	b.add(ct.None, msg, args...)
+}
+
+func (b *Buffer) Success(msg string, args ...interface{}) {
+	b.add(ct.Green, msg, args...)
+}
+
+func (b *Buffer) Warning(msg string, args ...interface{}) {
+	b.add(ct.Yellow, msg, args...)
+}
+
+func (b *Buffer) add(color ct.Color, msg string, args ...interface{}) {
+	if b == nil {
+		printLine(color, fmt.Sprintf(msg, args...))
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lines = append(b.lines, bufferedLine{color: color, text: fmt.Sprintf(msg, args...)})
+}
+
+// Flush prints the collected output and empties the buffer.
+func (b *Buffer) Flush() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	lines := b.lines
+	b.lines = nil
+	b.mu.Unlock()
+
+	for _, line := range lines {
+		printLine(line.color, line.text)
+	}
+}
+
+func printLine(color ct.Color, text string) {
+	if color == ct.None {
+		fmt.Fprintln(os.Stdout, Sanitize(text))
+		return
+	}
+	fprintWithColor(os.Stdout, color, "%s", text)
+}
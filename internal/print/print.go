@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"runtime"
+	"strings"
+	"sync"
 
 	ct "github.com/daviddengcn/go-colortext"
 )
@@ -60,6 +63,10 @@ func Failure(msg string, args ...interface{}) {
 	WithColor(ct.Red, msg, args...)
 }
 
+func Warning(msg string, args ...interface{}) {
+	WithColor(ct.Yellow, msg, args...)
+}
+
 func WithColor(color ct.Color, msg string, args ...interface{}) {
 	fprintWithColor(os.Stdout, color, msg, args...)
 }
@@ -68,9 +75,189 @@ func Error(err error) {
 	fprintWithColor(os.Stderr, ct.Red, "ERROR: %s", err)
 }
 
+// colorEnabled is whether output is colored. Color is disabled if stdout
+// isn't a terminal, NO_COLOR is set or with DisableColor.
+var colorEnabled = colorSupported()
+
+func colorSupported() bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	stat, err := os.Stdout.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
+
+// DisableColor makes all output of this package plain text, e.g. for
+// --no-color.
+func DisableColor() {
+	colorEnabled = false
+}
+
 func fprintWithColor(w io.Writer, color ct.Color, msg string, args ...interface{}) {
+	if !colorEnabled || color == ct.None {
+		fmt.Fprintln(w, Sanitize(fmt.Sprintf(msg, args...)))
+		return
+	}
 	ct.Foreground(color, true)
-	fmt.Fprintf(w, msg, args...)
-	fmt.Fprintln(w)
+	fmt.Fprintln(w, Sanitize(fmt.Sprintf(msg, args...)))
 	ct.ResetColor()
 }
+
+const masked = "[MASKED]"
+
+// minSecretLength prevents masking short values, which would mostly hit
+// unrelated output.
+const minSecretLength = 8
+
+var secrets struct {
+	sync.RWMutex
+	values []string
+}
+
+// Mask registers secrets, like the access token, which are replaced in all
+// output of this package.
+func Mask(values ...string) {
+	secrets.Lock()
+	defer secrets.Unlock()
+
+	for _, value := range values {
+		if len(value) >= minSecretLength {
+			secrets.values = append(secrets.values, value)
+		}
+	}
+}
+
+// Sanitize replaces all secrets registered with Mask in s.
+func Sanitize(s string) string {
+	secrets.RLock()
+	defer secrets.RUnlock()
+
+	for _, secret := range secrets.values {
+		s = strings.Replace(s, secret, masked, -1)
+	}
+	return s
+}
+
+// SanitizingWriter returns a writer masking registered secrets in everything
+// written to w. Each write is sanitized on its own, so secrets must not be
+// split across writes.
+func SanitizingWriter(w io.Writer) io.Writer {
+	return &sanitizingWriter{w: w}
+}
+
+type sanitizingWriter struct {
+	w io.Writer
+}
+
+func (sw *sanitizingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(sw.w, Sanitize(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// streamSanitizer masks registered secrets in a stream copied in chunks of
+// arbitrary size to w. The end of a chunk which might be the start of a
+// secret continued in the next chunk is held back until it's known, nothing
+// else is delayed.
+type streamSanitizer struct {
+	w       io.Writer
+	pending string
+}
+
+func (ss *streamSanitizer) Write(p []byte) (int, error) {
+	s := Sanitize(ss.pending + string(p))
+	keep := partialSecretLength(s)
+	ss.pending = s[len(s)-keep:]
+	if _, err := io.WriteString(ss.w, s[:len(s)-keep]); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes the held back end of the stream.
+func (ss *streamSanitizer) Flush() error {
+	_, err := io.WriteString(ss.w, ss.pending)
+	ss.pending = ""
+	return err
+}
+
+// partialSecretLength returns the length of the longest end of s which is
+// the start of a registered secret.
+func partialSecretLength(s string) int {
+	secrets.RLock()
+	defer secrets.RUnlock()
+
+	longest := 0
+	for _, secret := range secrets.values {
+		for n := len(secret) - 1; n > longest; n-- {
+			if strings.HasSuffix(s, secret[:n]) {
+				longest = n
+				break
+			}
+		}
+	}
+	return longest
+}
+
+// SanitizeFile replaces *f, like os.Stderr, with a pipe copied to the
+// original file with registered secrets masked, so output written to the
+// file directly, e.g. the request dumps of the API client in debug mode,
+// can't leak them. The returned function restores *f once all output written
+// so far is copied, it must be called before the program exits.
+func SanitizeFile(f **os.File) (func(), error) {
+	original := *f
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ss := &streamSanitizer{w: original}
+		io.Copy(ss, r)
+		ss.Flush()
+		r.Close()
+	}()
+
+	*f = w
+	return func() {
+		*f = original
+		w.Close()
+		<-done
+	}, nil
+}
+
+// SanitizeStdio sanitizes stdout and stderr with SanitizeFile, including the
+// color codes of this package. The returned function restores both.
+//
+// On Windows colors are set with the console API right away, while the text
+// is copied through the pipe later. So with colors enabled there, output is
+// only sanitized by the functions of this package and stdio is kept.
+func SanitizeStdio() (func(), error) {
+	if colorEnabled && runtime.GOOS == "windows" {
+		return func() {}, nil
+	}
+
+	restoreStderr, err := SanitizeFile(&os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	restoreStdout, err := SanitizeFile(&os.Stdout)
+	if err != nil {
+		restoreStderr()
+		return nil, err
+	}
+	// color codes must go through the same pipe as the text they color
+	ct.Writer = os.Stdout
+
+	return func() {
+		restoreStdout()
+		ct.Writer = os.Stdout
+		restoreStderr()
+	}, nil
+}
@@ -0,0 +1,81 @@
+package print
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	ct "github.com/daviddengcn/go-colortext"
+)
+
+func TestSanitize(t *testing.T) {
+	token := "0123456789abcdef0123456789abcdef"
+	Mask(token, "short")
+
+	got := Sanitize("GET https://api.phraseapp.com/api/v2/projects?access_token=" + token + " (short)")
+	expected := "GET https://api.phraseapp.com/api/v2/projects?access_token=[MASKED] (short)"
+	if got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+
+	buf := &bytes.Buffer{}
+	fprintWithColor(buf, 0, "Authorization: token %s", token)
+	if buf.String() != "Authorization: token [MASKED]\n" {
+		t.Errorf("expected token to be masked in output, got %q", buf.String())
+	}
+
+	buf.Reset()
+	SanitizingWriter(buf).Write([]byte("token " + token))
+	if buf.String() != "token [MASKED]" {
+		t.Errorf("expected token to be masked by writer, got %q", buf.String())
+	}
+}
+
+func TestStreamSanitizer(t *testing.T) {
+	token := "fedcba9876543210fedcba9876543210"
+	Mask(token)
+
+	buf := &bytes.Buffer{}
+	ss := &streamSanitizer{w: buf}
+	ss.Write([]byte("Authorization: token " + token[:10]))
+	if buf.String() != "Authorization: token " {
+		t.Errorf("expected the possible start of the token to be held back, got %q", buf.String())
+	}
+	ss.Write([]byte(token[10:] + "\nUploading en.json... "))
+	if buf.String() != "Authorization: token [MASKED]\nUploading en.json... " {
+		t.Errorf("expected the token split across writes to be masked, got %q", buf.String())
+	}
+
+	ss.Write([]byte("done " + token[:4]))
+	ss.Flush()
+	if !strings.HasSuffix(buf.String(), "done "+token[:4]) {
+		t.Errorf("expected the held back end to be written on flush, got %q", buf.String())
+	}
+}
+
+func TestDisableColor(t *testing.T) {
+	defer func(enabled bool, writer io.Writer) {
+		colorEnabled = enabled
+		ct.Writer = writer
+	}(colorEnabled, ct.Writer)
+
+	buf := &bytes.Buffer{}
+	ct.Writer = buf
+
+	if runtime.GOOS != "windows" {
+		colorEnabled = true
+		fprintWithColor(buf, ct.Green, "Downloaded %s", "en.json")
+		if !strings.Contains(buf.String(), "\x1b[") {
+			t.Errorf("expected color codes in colored output, got %q", buf.String())
+		}
+	}
+
+	buf.Reset()
+	DisableColor()
+	fprintWithColor(buf, ct.Green, "Downloaded %s", "en.json")
+	if buf.String() != "Downloaded en.json\n" {
+		t.Errorf("expected plain output without color codes, got %q", buf.String())
+	}
+}
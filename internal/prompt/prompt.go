@@ -37,3 +37,12 @@ func WithDefault(msg string, arg *string, defaultValue string) error {
 
 	return err
 }
+
+// IsTerminal returns true if stdin is a terminal, so the user can be prompted.
+func IsTerminal() bool {
+	stat, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
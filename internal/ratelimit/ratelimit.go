@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket allowing a number of requests per second. It is
+// safe for concurrent use.
+type Bucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// New returns a bucket allowing rps requests per second, with bursts of up to
+// rps requests.
+func New(rps int) *Bucket {
+	return &Bucket{
+		rate:   float64(rps),
+		burst:  float64(rps),
+		tokens: float64(rps),
+		now:    time.Now,
+		sleep:  time.Sleep,
+	}
+}
+
+// Wait blocks until a request may be made.
+func (b *Bucket) Wait() {
+	b.mu.Lock()
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+	}
+	b.last = now
+
+	// the token is taken right away, so concurrent callers queue up behind
+	// each other instead of waking up at the same time
+	b.tokens--
+	wait := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mu.Unlock()
+
+	if wait > 0 {
+		b.sleep(wait)
+	}
+}
+
+// Transport waits for the bucket before every request sent through base.
+type Transport struct {
+	Bucket *Bucket
+	Base   http.RoundTripper
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.Bucket.Wait()
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
@@ -0,0 +1,42 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketWait(t *testing.T) {
+	now := time.Unix(0, 0)
+	slept := time.Duration(0)
+
+	b := New(2)
+	b.now = func() time.Time { return now }
+	b.sleep = func(d time.Duration) {
+		slept += d
+		now = now.Add(d)
+	}
+
+	// the burst is available right away
+	b.Wait()
+	b.Wait()
+	if slept != 0 {
+		t.Errorf("expected burst not to wait, waited %s", slept)
+	}
+
+	b.Wait()
+	if slept != 500*time.Millisecond {
+		t.Errorf("expected to wait 500ms, waited %s", slept)
+	}
+
+	now = now.Add(10 * time.Second)
+	slept = 0
+	b.Wait()
+	b.Wait()
+	if slept != 0 {
+		t.Errorf("expected the bucket to refill up to the burst, waited %s", slept)
+	}
+	b.Wait()
+	if slept != 500*time.Millisecond {
+		t.Errorf("expected to wait 500ms after the burst, waited %s", slept)
+	}
+}
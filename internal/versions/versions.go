@@ -0,0 +1,68 @@
+package versions
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/coreos/go-semver/semver"
+)
+
+// operators are ordered so that no operator is a prefix of one checked later.
+var operators = []string{">=", "<=", "!=", "==", ">", "<", "="}
+
+// Satisfies returns true if version matches all space separated comparisons
+// of constraint, e.g. ">=2.3.0 <3.0.0". A version without operator must match
+// exactly.
+func Satisfies(version, constraint string) (bool, error) {
+	v, err := parse(version)
+	if err != nil {
+		return false, err
+	}
+
+	comparisons := strings.Fields(constraint)
+	if len(comparisons) == 0 {
+		return false, fmt.Errorf("empty version constraint")
+	}
+
+	for _, comparison := range comparisons {
+		op := "="
+		for _, candidate := range operators {
+			if strings.HasPrefix(comparison, candidate) {
+				op = candidate
+				comparison = strings.TrimPrefix(comparison, candidate)
+				break
+			}
+		}
+
+		other, err := parse(comparison)
+		if err != nil {
+			return false, fmt.Errorf("invalid version constraint %q: %s", constraint, err)
+		}
+
+		if !compare(v.Compare(*other), op) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func parse(version string) (*semver.Version, error) {
+	return semver.NewVersion(strings.TrimPrefix(strings.TrimSpace(version), "v"))
+}
+
+func compare(result int, op string) bool {
+	switch op {
+	case ">=":
+		return result >= 0
+	case "<=":
+		return result <= 0
+	case ">":
+		return result > 0
+	case "<":
+		return result < 0
+	case "!=":
+		return result != 0
+	default:
+		return result == 0
+	}
+}
@@ -0,0 +1,40 @@
+package versions
+
+import "testing"
+
+func TestSatisfies(t *testing.T) {
+	tests := []struct {
+		version    string
+		constraint string
+		expected   bool
+	}{
+		{"2.3.0", ">=2.3.0 <3.0.0", true},
+		{"2.9.1", ">=2.3.0 <3.0.0", true},
+		{"2.2.9", ">=2.3.0 <3.0.0", false},
+		{"3.0.0", ">=2.3.0 <3.0.0", false},
+		{"1.17.1", "1.17.1", true},
+		{"1.17.1", "=1.17.0", false},
+		{"1.17.1", "!=1.17.0", true},
+		{"v1.17.1", ">1.17.0", true},
+		{"1.17.1", "<=1.17.1", true},
+	}
+
+	for _, test := range tests {
+		ok, err := Satisfies(test.version, test.constraint)
+		if err != nil {
+			t.Errorf("didn't expect an error for %q, got: %s", test.constraint, err)
+			continue
+		}
+		if ok != test.expected {
+			t.Errorf("expected %q satisfying %q to be %t", test.version, test.constraint, test.expected)
+		}
+	}
+}
+
+func TestSatisfiesInvalid(t *testing.T) {
+	for _, constraint := range []string{"", ">=two", "~>1.0"} {
+		if _, err := Satisfies("1.0.0", constraint); err == nil {
+			t.Errorf("expected an error for constraint %q", constraint)
+		}
+	}
+}
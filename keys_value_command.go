@@ -0,0 +1,225 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/phrase/phraseapp-client/internal/print"
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+type KeysGetCommand struct {
+	phraseapp.Config
+	ProjectID string `cli:"opt --project-id desc='Project of the key, defaults to the project of the config'"`
+	Branch    string `cli:"opt --branch"`
+	Key       string `cli:"opt --key required desc='Name of the key, e.g. foo.bar'"`
+	Locale    string `cli:"opt --locale required desc='Code, name or ID of the locale'"`
+}
+
+type KeysSetCommand struct {
+	phraseapp.Config
+	ProjectID string `cli:"opt --project-id desc='Project of the key, defaults to the project of the config'"`
+	Branch    string `cli:"opt --branch"`
+	Key       string `cli:"opt --key required desc='Name of the key, e.g. foo.bar'"`
+	Locale    string `cli:"opt --locale required desc='Code, name or ID of the locale'"`
+	Value     string `cli:"opt --value required desc='New translation of the key'"`
+}
+
+// keyValue is the translation of a key in a locale, Translation is nil if the
+// key isn't translated in the locale yet.
+type keyValue struct {
+	Key         *phraseapp.TranslationKey
+	Locale      *phraseapp.Locale
+	Translation *phraseapp.Translation
+}
+
+func (cmd *KeysGetCommand) Run() error {
+	if cmd.Config.Debug {
+		// suppresses content output
+		cmd.Config.Debug = false
+		Debug = true
+	}
+
+	projectID, err := projectIDOrDefault(cmd.ProjectID, cmd.Config)
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(cmd.Config.Credentials, cmd.Config.Debug)
+	if err != nil {
+		return err
+	}
+
+	value, err := findKeyValue(client, projectID, branchOrDefault(cmd.Branch), cmd.Key, cmd.Locale)
+	if err != nil {
+		return err
+	}
+	if value.Translation == nil {
+		return fmt.Errorf("Key %q has no translation for locale %s", cmd.Key, value.Locale.Code)
+	}
+	fmt.Println(value.Translation.Content)
+	return nil
+}
+
+func (cmd *KeysSetCommand) Run() error {
+	if cmd.Config.Debug {
+		// suppresses content output
+		cmd.Config.Debug = false
+		Debug = true
+	}
+
+	projectID, err := projectIDOrDefault(cmd.ProjectID, cmd.Config)
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(cmd.Config.Credentials, cmd.Config.Debug)
+	if err != nil {
+		return err
+	}
+
+	branch := branchOrDefault(cmd.Branch)
+	value, err := findKeyValue(client, projectID, branch, cmd.Key, cmd.Locale)
+	if err != nil {
+		return err
+	}
+
+	before := "(untranslated)"
+	if value.Translation != nil {
+		before = strconv.Quote(value.Translation.Content)
+	}
+
+	translation, err := setKeyValue(client, projectID, branch, value, cmd.Value)
+	if err != nil {
+		return err
+	}
+
+	print.Success("Set %s for locale %s", cmd.Key, value.Locale.Code)
+	fmt.Printf("  before: %s\n", before)
+	fmt.Printf("  after:  %s\n", strconv.Quote(translation.Content))
+	return nil
+}
+
+// projectIDOrDefault returns projectID, or the project of the config if it's
+// empty.
+func projectIDOrDefault(projectID string, cfg phraseapp.Config) (string, error) {
+	if projectID == "" {
+		projectID = cfg.DefaultProjectID
+	}
+	if projectID == "" {
+		return "", fmt.Errorf("No project given. Please specify one using --project-id.")
+	}
+	return projectID, nil
+}
+
+// findKeyValue looks up the key with the given name, the locale by code, name
+// or ID and the translation of the key in the locale.
+func findKeyValue(client *phraseapp.Client, projectID, branch, keyName, localeName string) (*keyValue, error) {
+	locale, err := projectLocale(client, projectID, branch, localeName)
+	if err != nil {
+		return nil, err
+	}
+	value := &keyValue{Locale: locale}
+
+	if value.Key, err = findKey(client, projectID, branch, keyName); err != nil {
+		return nil, err
+	}
+
+	params := &phraseapp.TranslationsByKeyParams{}
+	if branch != "" {
+		params.Branch = &branch
+	}
+	for page := 1; ; page++ {
+		var translations []*phraseapp.Translation
+		err := retryOnRateLimit(func() (err error) {
+			translations, err = client.TranslationsByKey(projectID, value.Key.ID, page, 100, params)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, translation := range translations {
+			// plural keys have a translation per plural form, the one
+			// without suffix is the value of the key
+			if translation.Locale != nil && translation.Locale.ID == value.Locale.ID && translation.PluralSuffix == "" {
+				value.Translation = translation
+				return value, nil
+			}
+		}
+		if len(translations) < 100 {
+			return value, nil
+		}
+	}
+}
+
+// projectLocale returns the locale of the project with the given code, name
+// or ID.
+func projectLocale(client *phraseapp.Client, projectID, branch, name string) (*phraseapp.Locale, error) {
+	locales, err := RemoteLocales(client, LocaleCacheKey{ProjectID: projectID, Branch: branch})
+	if err != nil {
+		return nil, err
+	}
+	locale := findLocale(locales, name)
+	if locale == nil {
+		return nil, fmt.Errorf("Project %q has no locale %q", projectID, name)
+	}
+	return locale, nil
+}
+
+// findKey returns the key with exactly the given name.
+func findKey(client *phraseapp.Client, projectID, branch, name string) (*phraseapp.TranslationKey, error) {
+	query := "name:" + name
+	params := &phraseapp.KeysSearchParams{Q: &query}
+	if branch != "" {
+		params.Branch = &branch
+	}
+	for page := 1; ; page++ {
+		var keys []*phraseapp.TranslationKey
+		err := retryOnRateLimit(func() (err error) {
+			keys, err = client.KeysSearch(projectID, page, 100, params)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			if key.Name == name {
+				return key, nil
+			}
+		}
+		if len(keys) < 100 {
+			return nil, fmt.Errorf("Project %q has no key %q", projectID, name)
+		}
+	}
+}
+
+// setKeyValue sets the translation of the key in the locale of value to
+// content, updating the existing translation or creating a new one.
+func setKeyValue(client *phraseapp.Client, projectID, branch string, value *keyValue, content string) (*phraseapp.TranslationDetails, error) {
+	var branchParam *string
+	if branch != "" {
+		branchParam = &branch
+	}
+
+	var translation *phraseapp.TranslationDetails
+	err := retryOnRateLimit(func() (err error) {
+		if value.Translation != nil {
+			translation, err = client.TranslationUpdate(projectID, value.Translation.ID, &phraseapp.TranslationUpdateParams{
+				Branch:  branchParam,
+				Content: &content,
+			})
+			return err
+		}
+		translation, err = client.TranslationCreate(projectID, &phraseapp.TranslationParams{
+			Branch:   branchParam,
+			Content:  &content,
+			KeyID:    &value.Key.ID,
+			LocaleID: &value.Locale.ID,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("setting %s for locale %s failed: %s", value.Key.Name, value.Locale.Code, err)
+	}
+	return translation, nil
+}
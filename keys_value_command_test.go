@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func TestKeyValue(t *testing.T) {
+	defer withLocalesCache(t, time.Minute)()
+
+	var mu sync.Mutex
+	translations := map[string]string{"en-locale-id": "Hello"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		params := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&params)
+		if branch, ok := params["branch"]; r.Method != "GET" && (!ok || branch != "feature") {
+			t.Errorf("%s %s: expected the branch feature, got %v", r.Method, r.URL.Path, params["branch"])
+		}
+
+		switch r.Method + " " + r.URL.Path {
+		case "GET /v2/projects/project-id/locales":
+			io.WriteString(w, `[{"id": "en-locale-id", "code": "en", "name": "english"}, {"id": "de-locale-id", "code": "de", "name": "german"}]`)
+		case "POST /v2/projects/project-id/keys/search":
+			if q, _ := params["q"].(string); !strings.HasPrefix(q, "name:app.") {
+				t.Errorf("expected a search by name, got %v", params["q"])
+			}
+			io.WriteString(w, `[{"id": "other-key-id", "name": "app.greeting.title"}, {"id": "key-id", "name": "app.greeting"}]`)
+		case "GET /v2/projects/project-id/keys/key-id/translations":
+			list := []string{}
+			for localeID, content := range translations {
+				list = append(list, `{"id": "`+localeID+`-translation", "content": "`+content+`", "locale": {"id": "`+localeID+`"}}`)
+			}
+			io.WriteString(w, "["+strings.Join(list, ",")+"]")
+		case "PATCH /v2/projects/project-id/translations/en-locale-id-translation":
+			translations["en-locale-id"] = params["content"].(string)
+			io.WriteString(w, `{"id": "en-locale-id-translation", "content": "`+translations["en-locale-id"]+`"}`)
+		case "POST /v2/projects/project-id/translations":
+			if params["key_id"] != "key-id" {
+				t.Errorf("expected a translation of key-id, got %v", params["key_id"])
+			}
+			translations[params["locale_id"].(string)] = params["content"].(string)
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"id": "new-translation", "content": "`+params["content"].(string)+`"}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	value, err := findKeyValue(client, "project-id", "feature", "app.greeting", "english")
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if value.Key.ID != "key-id" || value.Locale.Code != "en" || value.Translation == nil || value.Translation.Content != "Hello" {
+		t.Errorf("expected the english translation of app.greeting, got %+v", value)
+	}
+
+	updated, err := setKeyValue(client, "project-id", "feature", value, "Hi")
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if updated.Content != "Hi" || translations["en-locale-id"] != "Hi" {
+		t.Errorf("expected the translation to be updated, got %q", updated.Content)
+	}
+
+	value, err = findKeyValue(client, "project-id", "feature", "app.greeting", "de")
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if value.Translation != nil {
+		t.Errorf("expected no german translation, got %+v", value.Translation)
+	}
+	if _, err := setKeyValue(client, "project-id", "feature", value, "Hallo"); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if translations["de-locale-id"] != "Hallo" {
+		t.Errorf("expected the german translation to be created, got %q", translations["de-locale-id"])
+	}
+
+	if _, err := findKeyValue(client, "project-id", "feature", "app.missing", "en"); err == nil || !strings.Contains(err.Error(), "has no key") {
+		t.Errorf("expected an error for a missing key, got: %v", err)
+	}
+	if _, err := findKeyValue(client, "project-id", "feature", "app.greeting", "fr"); err == nil || !strings.Contains(err.Error(), "has no locale") {
+		t.Errorf("expected an error for a missing locale, got: %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+// localeDetailsSuffix is appended to the path of a downloaded file for its
+// locale details. It has no .json extension, so the sidecar doesn't match
+// the file patterns of sources and targets.
+const localeDetailsSuffix = ".details"
+
+// localeDetails is the metadata of a locale written next to its files.
+type localeDetails struct {
+	ID          string   `json:"id"`
+	Code        string   `json:"code"`
+	Name        string   `json:"name"`
+	Default     bool     `json:"default"`
+	Main        bool     `json:"main"`
+	Rtl         bool     `json:"rtl"`
+	PluralForms []string `json:"plural_forms"`
+	// Completion is the percentage of translated keys.
+	Completion float64                     `json:"completion"`
+	Statistics *phraseapp.LocaleStatistics `json:"statistics,omitempty"`
+}
+
+func newLocaleDetails(details *phraseapp.LocaleDetails) *localeDetails {
+	result := &localeDetails{
+		ID:          details.ID,
+		Code:        details.Code,
+		Name:        details.Name,
+		Default:     details.Default,
+		Main:        details.Main,
+		Rtl:         details.Rtl,
+		PluralForms: details.PluralForms,
+		Statistics:  details.Statistics,
+	}
+	if completion, known := localeCompletion(details.Statistics); known {
+		result.Completion = completion
+	}
+	return result
+}
+
+// localeCompletion returns the percentage of translated keys of the locale
+// with the given statistics, rounded to one decimal. It returns false if the
+// completion isn't known, e.g. for projects without keys.
+func localeCompletion(stats *phraseapp.LocaleStatistics) (float64, bool) {
+	if stats == nil || stats.KeysTotalCount <= 0 {
+		return 0, false
+	}
+	completion := float64(stats.KeysTotalCount-stats.KeysUntranslatedCount) / float64(stats.KeysTotalCount) * 100
+	return math.Round(completion*10) / 10, true
+}
+
+// writeLocaleDetails fetches the details of the locale of localeFile and
+// writes them as JSON next to the file.
+func (target *Target) writeLocaleDetails(client *phraseapp.Client, localeFile *LocaleFile, branch string) error {
+	params := &phraseapp.LocaleShowParams{}
+	if branch != "" {
+		params.Branch = &branch
+	}
+
+	var details *phraseapp.LocaleDetails
+	err := retryOnRateLimit(func() (err error) {
+		details, err = client.LocaleShow(target.ProjectID, localeFile.ID, params)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	content, err := json.MarshalIndent(newLocaleDetails(details), "", "  ")
+	if err != nil {
+		return err
+	}
+	return target.writeFile(localeFile.Path+localeDetailsSuffix, append(content, '\n'))
+}
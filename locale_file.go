@@ -12,14 +12,32 @@ type LocaleFiles []*LocaleFile
 type LocaleFile struct {
 	Path, Name, ID, Code, Tag, FileFormat string
 	ExistsRemote                          bool
+
+	// merged are further files uploaded together with Path, for sources
+	// merging all files of a locale.
+	merged []string
+	// archiveEntry is the name of the archive entry Path was extracted from.
+	archiveEntry string
 }
 
 func (localeFile *LocaleFile) RelPath() string {
+	if localeFile.archiveEntry != "" {
+		return localeFile.archiveEntry
+	}
 	callerPath, _ := os.Getwd()
 	relativePath, _ := filepath.Rel(callerPath, localeFile.Path)
 	return relativePath
 }
 
+// localeLabel returns the code of the locale of the file, or its name if the
+// code is unknown.
+func (localeFile *LocaleFile) localeLabel() string {
+	if localeFile.Code != "" {
+		return localeFile.Code
+	}
+	return localeFile.Name
+}
+
 // Locale to Path mapping
 func (localeFile *LocaleFile) Message() string {
 	str := ""
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/phrase/phraseapp-client/internal/placeholders"
+	"github.com/phrase/phraseapp-client/internal/print"
+	"github.com/phrase/phraseapp-client/internal/prompt"
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+// PickLocale lets the user select one of the remote locales of the targets
+// with locale placeholders, which are then restricted to that locale. Without
+// terminal all locales are kept.
+func (targets Targets) PickLocale() error {
+	locales := targets.placeholderLocales()
+	if len(locales) == 0 {
+		return nil
+	}
+
+	if !prompt.IsTerminal() {
+		warn("Not selecting a locale interactively, as the input is no terminal")
+		return nil
+	}
+
+	locale, err := pickLocale(locales)
+	if err != nil {
+		return err
+	}
+	targets.restrictToLocale(locale.Code)
+	return nil
+}
+
+// placeholderLocales returns the remote locales of all targets with locale
+// placeholders, once per code.
+func (targets Targets) placeholderLocales() []*phraseapp.Locale {
+	seen := map[string]bool{}
+	locales := []*phraseapp.Locale{}
+	for _, target := range targets {
+		if !placeholders.ContainsLocalePlaceholder(target.File) && target.OutputTemplate == "" {
+			continue
+		}
+		for _, locale := range target.RemoteLocales {
+			if !seen[locale.Code] {
+				seen[locale.Code] = true
+				locales = append(locales, locale)
+			}
+		}
+	}
+	return locales
+}
+
+// restrictToLocale removes all other remote locales from the targets with
+// locale placeholders.
+func (targets Targets) restrictToLocale(code string) {
+	for _, target := range targets {
+		if !placeholders.ContainsLocalePlaceholder(target.File) && target.OutputTemplate == "" {
+			continue
+		}
+
+		locales := []*phraseapp.Locale{}
+		for _, locale := range target.RemoteLocales {
+			if locale.Code == code {
+				locales = append(locales, locale)
+			}
+		}
+		target.RemoteLocales = locales
+	}
+}
+
+func pickLocale(locales []*phraseapp.Locale) (*phraseapp.Locale, error) {
+	for i, locale := range locales {
+		fmt.Printf("%2d: %s (Code: %s)\n", i+1, locale.Name, locale.Code)
+	}
+
+	selection := 0
+	for {
+		err := prompt.P(fmt.Sprintf("Select locale: (%v-%v)", 1, len(locales)), &selection)
+		if err == io.EOF {
+			return nil, fmt.Errorf("no locale selected")
+		} else if err != nil {
+			continue
+		}
+
+		if selection < 1 || selection > len(locales) {
+			print.Failure("Please select a locale from the list by specifying its position in the list, e.g. 2 for the second locale.")
+			continue
+		}
+
+		print.Success("Using locale %s", locales[selection-1].Name)
+		return locales[selection-1], nil
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+// localesCacheTTL is the time the locales of a project and branch are cached
+// on disk, so quick successive commands don't list them again. Zero disables
+// the cache.
+var localesCacheTTL = 10 * time.Second
+
+// localesCacheFilename is the file of the locales_cache_file config option,
+// the cache is in the temp dir by default.
+var localesCacheFilename string
+
+func localesCachePath() string {
+	if localesCacheFilename != "" {
+		return localesCacheFilename
+	}
+	return tempFilePath(".phraseapp.locales.json")
+}
+
+// refreshCaches ignores the cached formats and locales, set by --refresh.
+var refreshCaches bool
+
+// localesCacheMu serializes the access to the cache file within a run.
+var localesCacheMu sync.Mutex
+
+type localesCacheEntry struct {
+	Host string `json:"host"`
+	// TokenHash identifies the credentials the locales were listed with, so
+	// users without access to a project don't get its locales from the cache.
+	TokenHash string              `json:"token_hash"`
+	ProjectID string              `json:"project_id"`
+	Branch    string              `json:"branch"`
+	Fetched   time.Time           `json:"fetched"`
+	Locales   []*phraseapp.Locale `json:"locales"`
+}
+
+func (entry *localesCacheEntry) matches(client *phraseapp.Client, key LocaleCacheKey) bool {
+	return entry.Host == client.Credentials.Host && entry.TokenHash == credentialsHash(client) &&
+		entry.ProjectID == key.ProjectID && entry.Branch == key.Branch
+}
+
+// credentialsHash returns a hash of the credentials of client, which are not
+// written to the cache in plain text.
+func credentialsHash(client *phraseapp.Client) string {
+	sum := sha256.Sum256([]byte(client.Credentials.Username + ":" + client.Credentials.Token))
+	return hex.EncodeToString(sum[:])
+}
+
+// setLocalesCache applies the locales_cache_file and locales_cache_ttl config
+// options. The TTL is a duration like 30s, 0 disables the cache.
+func setLocalesCache(file, ttl string) error {
+	if file != "" {
+		localesCacheFilename = file
+	}
+	if ttl != "" {
+		parsed, err := time.ParseDuration(ttl)
+		if err != nil || parsed < 0 {
+			return fmt.Errorf("locales_cache_ttl must be a duration like 30s, got %q", ttl)
+		}
+		localesCacheTTL = parsed
+	}
+	return nil
+}
+
+// cachedLocales returns the cached locales of the project and branch of key,
+// if they were fetched with the credentials of client within the TTL.
+func cachedLocales(client *phraseapp.Client, key LocaleCacheKey) ([]*phraseapp.Locale, bool) {
+	if localesCacheTTL <= 0 || refreshCaches {
+		return nil, false
+	}
+	localesCacheMu.Lock()
+	defer localesCacheMu.Unlock()
+
+	for _, entry := range readLocalesCacheFile() {
+		if entry.matches(client, key) && time.Since(entry.Fetched) < localesCacheTTL {
+			return entry.Locales, true
+		}
+	}
+	return nil, false
+}
+
+// cacheLocales stores the locales of the project and branch of key. Expired
+// entries are dropped from the cache.
+func cacheLocales(client *phraseapp.Client, key LocaleCacheKey, locales []*phraseapp.Locale) {
+	if localesCacheTTL <= 0 {
+		return
+	}
+	updateLocalesCache(func(entry *localesCacheEntry) bool {
+		return !entry.matches(client, key)
+	}, &localesCacheEntry{
+		Host:      client.Credentials.Host,
+		TokenHash: credentialsHash(client),
+		ProjectID: key.ProjectID,
+		Branch:    key.Branch,
+		Fetched:   time.Now(),
+		Locales:   locales,
+	})
+}
+
+// invalidateLocalesCache removes the cached locales of all branches of the
+// project for all credentials, after its locales were changed.
+func invalidateLocalesCache(host, projectID string) {
+	updateLocalesCache(func(entry *localesCacheEntry) bool {
+		return entry.Host != host || entry.ProjectID != projectID
+	}, nil)
+}
+
+// updateLocalesCache rewrites the cache with the unexpired entries passing
+// keep, and added if not nil.
+func updateLocalesCache(keep func(*localesCacheEntry) bool, added *localesCacheEntry) {
+	localesCacheMu.Lock()
+	defer localesCacheMu.Unlock()
+
+	entries := []*localesCacheEntry{}
+	for _, entry := range readLocalesCacheFile() {
+		if keep(entry) && time.Since(entry.Fetched) < localesCacheTTL {
+			entries = append(entries, entry)
+		}
+	}
+	if added != nil {
+		entries = append(entries, added)
+	}
+
+	if len(entries) == 0 {
+		os.Remove(localesCachePath())
+		return
+	}
+	content, err := json.Marshal(entries)
+	if err != nil {
+		return
+	}
+	if err := writeFileAtomic(localesCachePath(), content, 0600); err != nil && Debug {
+		fmt.Fprintf(os.Stderr, "Writing the locales cache failed: %s\n", err)
+	}
+}
+
+// readLocalesCacheFile returns the entries of the cache, none if it's missing
+// or unreadable.
+func readLocalesCacheFile() []*localesCacheEntry {
+	content, err := ioutil.ReadFile(localesCachePath())
+	if err != nil {
+		return nil
+	}
+	entries := []*localesCacheEntry{}
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil
+	}
+	return entries
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func withLocalesCache(t *testing.T, ttl time.Duration) func() {
+	dir, err := ioutil.TempDir("", "phraseapp-locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	originalFilename, originalTTL := localesCacheFilename, localesCacheTTL
+	localesCacheFilename = filepath.Join(dir, "locales.json")
+	localesCacheTTL = ttl
+
+	return func() {
+		localesCacheFilename, localesCacheTTL = originalFilename, originalTTL
+		refreshCaches = false
+		os.RemoveAll(dir)
+	}
+}
+
+func TestRemoteLocalesCache(t *testing.T) {
+	defer withLocalesCache(t, time.Minute)()
+
+	var mu sync.Mutex
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		io.WriteString(w, `[{"id": "en-locale-id", "code": "en", "name": "english"}]`)
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	expectRequests := func(step string, key LocaleCacheKey, exp int) {
+		t.Helper()
+		locales, err := RemoteLocales(client, key)
+		if err != nil {
+			t.Fatalf("%s: didn't expect an error, got: %s", step, err)
+		}
+		if len(locales) != 1 || locales[0].Code != "en" {
+			t.Errorf("%s: expected the locale en, got %v", step, locales)
+		}
+		if requests != exp {
+			t.Errorf("%s: expected %d requests, got %d", step, exp, requests)
+		}
+	}
+
+	key := LocaleCacheKey{ProjectID: "project-id"}
+	expectRequests("miss", key, 1)
+	expectRequests("hit", key, 1)
+	expectRequests("other branch", LocaleCacheKey{ProjectID: "project-id", Branch: "feature"}, 2)
+	expectRequests("hit of the branch", LocaleCacheKey{ProjectID: "project-id", Branch: "feature"}, 2)
+
+	owner := client
+	client = new(phraseapp.Client)
+	*client = *owner
+	client.Credentials.Token = "other_token"
+	expectRequests("other token", key, 3)
+	client = owner
+	expectRequests("hit of the first token", key, 3)
+
+	content, err := ioutil.ReadFile(localesCacheFilename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "some_token") {
+		t.Errorf("expected the token not to be written to the cache, got %s", content)
+	}
+
+	refreshCaches = true
+	expectRequests("refresh", key, 4)
+	refreshCaches = false
+
+	invalidateLocalesCache(srv.URL, "project-id")
+	expectRequests("invalidated", key, 5)
+
+	localesCacheTTL = 10 * time.Millisecond
+	time.Sleep(20 * time.Millisecond)
+	expectRequests("expired", key, 6)
+
+	localesCacheTTL = 0
+	expectRequests("disabled", key, 7)
+	expectRequests("disabled again", key, 8)
+}
+
+func TestSetLocalesCache(t *testing.T) {
+	defer withLocalesCache(t, time.Minute)()
+
+	if err := setLocalesCache("locales.json", "30s"); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if localesCacheFilename != "locales.json" || localesCacheTTL != 30*time.Second {
+		t.Errorf("expected the cache options to be applied, got %q and %s", localesCacheFilename, localesCacheTTL)
+	}
+	if err := setLocalesCache("", "soon"); err == nil {
+		t.Errorf("expected an error for an invalid TTL")
+	}
+}
+
+func TestRefreshFlag(t *testing.T) {
+	refresh, args := refreshFlag([]string{"pull", "--refresh", "--branch", "feature"})
+	if !refresh || len(args) != 3 {
+		t.Errorf("expected --refresh to be extracted, got %v and %v", refresh, args)
+	}
+	if refresh, _ := refreshFlag([]string{"pull"}); refresh {
+		t.Errorf("expected no refresh without the option")
+	}
+}
+
+func TestLocalesForProjectsRetriesEmpty(t *testing.T) {
+	defer withLocalesCache(t, time.Minute)()
+	originalDelay := emptyLocalesRetryDelay
+	emptyLocalesRetryDelay = time.Millisecond
+	defer func() { emptyLocalesRetryDelay = originalDelay }()
+
+	var mu sync.Mutex
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		empty := requests < 3
+		mu.Unlock()
+		if empty {
+			io.WriteString(w, `[]`)
+			return
+		}
+		io.WriteString(w, `[{"id": "en-locale-id", "code": "en", "name": "english"}]`)
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	locales, err := LocalesForProjects(client, Targets{&Target{ProjectID: "project-id"}}, "")
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	key := LocaleCacheKey{ProjectID: "project-id"}
+	if len(locales[key]) != 1 || locales[key][0].Code != "en" {
+		t.Errorf("expected the locale en, got %v", locales[key])
+	}
+	if requests != 3 {
+		t.Errorf("expected 3 requests, got %d", requests)
+	}
+
+	// the retries are bounded
+	mu.Lock()
+	requests = -100
+	mu.Unlock()
+	locales, err = LocalesForProjects(client, Targets{&Target{ProjectID: "other-project-id"}}, "")
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if exp := 1 + emptyLocalesRetries; requests+100 != exp {
+		t.Errorf("expected %d requests, got %d", exp, requests+100)
+	}
+	if len(locales[LocaleCacheKey{ProjectID: "other-project-id"}]) != 0 {
+		t.Errorf("expected no locales, got %v", locales)
+	}
+	// sources create the locales of empty projects
+	mu.Lock()
+	requests = -100
+	mu.Unlock()
+	if _, err := LocalesForProjects(client, Sources{&Source{ProjectID: "source-project-id"}}, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if requests+100 != 1 {
+		t.Errorf("expected no retries for sources, got %d requests", requests+100)
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/phrase/phraseapp-client/internal/print"
+	"github.com/phrase/phraseapp-client/internal/prompt"
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+type LocalesRenameCommand struct {
+	phraseapp.Config
+	ProjectID string `cli:"opt --project-id desc='Project of the locale, defaults to the project of the config'"`
+	From      string `cli:"opt --from required desc='Current code of the locale'"`
+	To        string `cli:"opt --to required desc='New code of the locale'"`
+	Name      string `cli:"opt --name desc='New name of the locale, by default a name equal to the code is renamed as well'"`
+	Branch    string `cli:"opt --branch"`
+	DryRun    bool   `cli:"opt --dry-run desc='Only print what would be renamed'"`
+	Yes       bool   `cli:"opt --yes desc='Don’t ask for confirmation'"`
+}
+
+// fileRename is a local file moved by a locale rename.
+type fileRename struct {
+	From string
+	To   string
+}
+
+func (cmd *LocalesRenameCommand) Run() error {
+	if cmd.Config.Debug {
+		// suppresses content output
+		cmd.Config.Debug = false
+		Debug = true
+	}
+
+	projectID := cmd.ProjectID
+	if projectID == "" {
+		projectID = cmd.Config.DefaultProjectID
+	}
+	if projectID == "" {
+		return fmt.Errorf("No project given. Please specify one using --project-id.")
+	}
+	if cmd.From == cmd.To {
+		return fmt.Errorf("--from and --to must be different locale codes")
+	}
+
+	client, err := newClient(cmd.Config.Credentials, cmd.Config.Debug)
+	if err != nil {
+		return err
+	}
+
+	remoteLocales, err := RemoteLocales(client, LocaleCacheKey{ProjectID: projectID, Branch: cmd.Branch})
+	if err != nil {
+		return err
+	}
+
+	var locale *phraseapp.Locale
+	for _, remoteLocale := range remoteLocales {
+		switch remoteLocale.Code {
+		case cmd.From:
+			locale = remoteLocale
+		case cmd.To:
+			return fmt.Errorf("Project %q already has a locale with code %q", projectID, cmd.To)
+		}
+	}
+	if locale == nil {
+		return fmt.Errorf("Project %q has no locale with code %q", projectID, cmd.From)
+	}
+
+	renamed := *locale
+	renamed.Code = cmd.To
+	switch {
+	case cmd.Name != "":
+		renamed.Name = cmd.Name
+	case locale.Name == cmd.From:
+		renamed.Name = cmd.To
+	}
+
+	renames, err := cmd.fileRenames(client, projectID, locale, &renamed)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Rename locale %s (%s) to %s (%s) in project %s\n", locale.Code, locale.Name, renamed.Code, renamed.Name, projectID)
+	for _, rename := range renames {
+		fmt.Printf("Move %s to %s\n", rename.From, rename.To)
+	}
+	if cmd.DryRun {
+		return nil
+	}
+
+	if !cmd.Yes {
+		confirmation := ""
+		if err := prompt.WithDefault("Are you sure you want to continue? (y/n)", &confirmation, "n"); err != nil {
+			return err
+		}
+		if strings.ToLower(confirmation) != "y" {
+			return fmt.Errorf("Rename aborted")
+		}
+	}
+
+	params := &phraseapp.LocaleParams{Code: &renamed.Code, Name: &renamed.Name}
+	if cmd.Branch != "" {
+		params.Branch = &cmd.Branch
+	}
+	if _, err := client.LocaleUpdate(projectID, locale.ID, params); err != nil {
+		return err
+	}
+	invalidateLocalesCache(client.Credentials.Host, projectID)
+	print.Success("Renamed locale %s to %s", locale.Code, renamed.Code)
+
+	if err := moveFiles(renames); err != nil {
+		return fmt.Errorf("%s. The locale was renamed already, please move the remaining files manually", err)
+	}
+	return nil
+}
+
+// fileRenames returns the local files of the pull targets of the project
+// which must be moved for the renamed locale. Without targets there is
+// nothing to move.
+func (cmd *LocalesRenameCommand) fileRenames(client *phraseapp.Client, projectID string, locale, renamed *phraseapp.Locale) ([]*fileRename, error) {
+	if len(cmd.Config.Targets) == 0 {
+		return nil, nil
+	}
+
+	targets, err := TargetsFromConfig(cmd.Config)
+	if err != nil {
+		return nil, err
+	}
+	if err := targets.ResolveProjectNames(client); err != nil {
+		return nil, err
+	}
+
+	projectTargets := Targets{}
+	for _, target := range targets {
+		if target.ProjectID == projectID {
+			projectTargets = append(projectTargets, target)
+		}
+	}
+
+	if err := projectTargets.FetchProjectNames(client); err != nil {
+		return nil, err
+	}
+	return renamesForTargets(projectTargets, locale, renamed)
+}
+
+// renamesForTargets resolves the files of locale and renamed for each target
+// and returns the existing files whose path changes.
+func renamesForTargets(targets Targets, locale, renamed *phraseapp.Locale) ([]*fileRename, error) {
+	renames := []*fileRename{}
+	for _, target := range targets {
+		if err := target.CheckPreconditions(); err != nil {
+			return nil, err
+		}
+
+		oldFiles, err := target.createLocaleFiles(locale)
+		if err != nil {
+			return nil, err
+		}
+		newFiles, err := target.createLocaleFiles(renamed)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, oldFile := range oldFiles {
+			from, to := oldFile.Path, newFiles[i].Path
+			if from == to {
+				continue
+			}
+			if _, err := os.Stat(from); os.IsNotExist(err) {
+				continue
+			}
+			if _, err := os.Stat(to); err == nil {
+				return nil, fmt.Errorf("Can't move %s, %s exists already", from, to)
+			}
+			renames = append(renames, &fileRename{From: from, To: to})
+		}
+	}
+	return renames, nil
+}
+
+func moveFiles(renames []*fileRename) error {
+	errs := newErrorCollector(errorPolicyFailFast)
+	for _, rename := range renames {
+		if err := moveFile(rename); err != nil {
+			if errs.add(rename.From, err) {
+				break
+			}
+			continue
+		}
+		print.Success("Moved %s to %s", rename.From, rename.To)
+	}
+	return errs.err()
+}
+
+func moveFile(rename *fileRename) error {
+	if err := os.MkdirAll(filepath.Dir(rename.To), 0700); err != nil {
+		return err
+	}
+	return os.Rename(rename.From, rename.To)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func TestRenamesForTargets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-rename-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer pushd(t, dir)()
+
+	os.MkdirAll("locales/cn", 0700)
+	ioutil.WriteFile("locales/cn/app.json", []byte("{}"), 0600)
+	ioutil.WriteFile("locales/cn.yml", []byte("cn:\n"), 0600)
+
+	json := getBaseTarget()
+	json.File = "./locales/<locale_code>/app.json"
+	yml := getBaseTarget()
+	yml.File = "./locales/<locale_code>.yml"
+	missing := getBaseTarget()
+	missing.File = "./other/<locale_code>.json"
+
+	locale := &phraseapp.Locale{ID: "cn-id", Code: "cn", Name: "cn"}
+	renamed := &phraseapp.Locale{ID: "cn-id", Code: "zh-Hans", Name: "zh-Hans"}
+
+	renames, err := renamesForTargets(Targets{json, yml, missing}, locale, renamed)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if len(renames) != 2 {
+		t.Fatalf("expected 2 files to move, got %d", len(renames))
+	}
+
+	if err := moveFiles(renames); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	for _, path := range []string{"locales/zh-Hans/app.json", "locales/zh-Hans.yml"} {
+		if _, err := os.Stat(filepath.Join(dir, path)); err != nil {
+			t.Errorf("expected %s to exist: %s", path, err)
+		}
+	}
+
+	ioutil.WriteFile("locales/cn.yml", []byte("cn:\n"), 0600)
+	if _, err := renamesForTargets(Targets{yml}, locale, renamed); err == nil {
+		t.Errorf("expected an error if the new file exists already")
+	}
+}
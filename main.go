@@ -25,6 +25,16 @@ func main() {
 	Run()
 }
 
+// restoreOutput restores stdout and stderr sanitized by Run, after copying
+// all output written to them.
+var restoreOutput = func() {}
+
+// exit ends the program with code, after flushing the sanitized output.
+func exit(code int) {
+	restoreOutput()
+	os.Exit(code)
+}
+
 func Run() {
 	var cfg *phraseapp.Config
 	defer func() {
@@ -33,32 +43,102 @@ func Run() {
 				fmt.Fprintf(os.Stderr, "%v\n%s", recovered, debug.Stack())
 			}
 			print.Error(fmt.Errorf("This should not have happened: %s - Contact support: %s", recovered, phraseAppSupport))
-			os.Exit(1)
+			exit(1)
 		}
 	}()
 
 	phraseapp.ClientVersion = PHRASEAPP_CLIENT_VERSION
 	updateChecker.Check()
 
-	cfg, err := phraseapp.ReadConfig()
+	configLocation, args, err := configFlag(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		exit(2)
+	}
+
+	environment, args, err := envFlag(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		exit(2)
+	}
+
+	refreshCaches, args = refreshFlag(args)
+
+	noColor, args := noColorFlag(args)
+	if noColor {
+		print.DisableColor()
+	}
+
+	// the token must not reach stdout or stderr, not even with debug output
+	// of the API client; on Windows this depends on the color output
+	if restore, err := print.SanitizeStdio(); err == nil {
+		restoreOutput = restore
+	}
+
+	policy, args, err := errorPolicyFlag(args)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-		os.Exit(2)
+		exit(2)
+	}
+	if err := setErrorPolicy(policy); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		exit(2)
+	}
+
+	requestHeaders, args, err = headerFlags(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		exit(2)
+	}
+
+	overrides, args, err := setFlags(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		exit(2)
+	}
+
+	cfg, clientCfg, err := ReadConfig(configLocation, environment, overrides)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		exit(2)
+	}
+	print.Mask(cfg.Credentials.Token, os.Getenv("PHRASEAPP_ACCESS_TOKEN"))
+
+	if err := ValidateVersion(clientCfg); err != nil {
+		print.Error(err)
+		exit(2)
+	}
+
+	if err := setTmpDir(clientCfg.TmpDir); err != nil {
+		print.Error(err)
+		exit(2)
+	}
+	if err := setLocalesCache(clientCfg.LocalesCacheFile, clientCfg.LocalesCacheTTL); err != nil {
+		print.Error(err)
+		exit(2)
+	}
+	if clientCfg.EmptyLocalesRetries != nil {
+		emptyLocalesRetries = *clientCfg.EmptyLocalesRetries
 	}
+	formatOptionsPresets = clientCfg.FormatOptionsPresets
+	formatByExtension = clientCfg.FormatByExtension
+	defaultProjectName = clientCfg.ProjectName
+	defaultBranch = clientCfg.Branch
+	defaultBranchBase = clientCfg.BranchBase
 
 	r, err := router(cfg)
 	if err != nil {
 		print.Error(err)
-		os.Exit(3)
+		exit(3)
 	}
 
-	switch err := r.RunWithArgs(); err {
+	switch err := r.Run(aliasRoute(args)...); err {
 	case cli.ErrorHelpRequested, cli.ErrorNoRoute:
-		os.Exit(1)
+		exit(1)
 	case nil:
-		os.Exit(0)
+		exit(0)
 	default:
 		print.Error(err)
-		os.Exit(1)
+		exit(1)
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"sort"
 	"strings"
 	"testing"
@@ -14,6 +15,23 @@ import (
 	"github.com/phrase/phraseapp-go/phraseapp"
 )
 
+// TestMain keeps the tests from sharing the locales and formats caches of the
+// system and of previous runs, tests of the caches use withLocalesCache and
+// withFormatsCache.
+func TestMain(m *testing.M) {
+	dir, err := ioutil.TempDir("", "phraseapp-test-cache")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	localesCacheFilename = filepath.Join(dir, "locales.json")
+	formatsCacheFilename = filepath.Join(dir, "formats.json")
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
 func getBaseLocales() []*phraseapp.Locale {
 	return []*phraseapp.Locale{
 		{
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+var manifestAlgorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// writeManifest writes the checksums of files to path, one "<checksum>  <file>"
+// line per file. The format is understood by sha256sum -c and friends.
+func writeManifest(path, algorithm string, files []string) error {
+	newHash, ok := manifestAlgorithms[strings.ToLower(algorithm)]
+	if !ok {
+		return fmt.Errorf("unsupported manifest algorithm %q, use one of md5, sha1, sha256 or sha512", algorithm)
+	}
+
+	checksums := map[string]string{}
+	for _, file := range files {
+		sum, err := fileChecksum(file, newHash())
+		if err != nil {
+			return err
+		}
+		checksums[file] = sum
+	}
+	return writeChecksums(path, checksums)
+}
+
+// writeChecksums writes a manifest of the given checksums by file name.
+func writeChecksums(path string, checksums map[string]string) error {
+	files := []string{}
+	for file := range checksums {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	lines := []string{}
+	for _, file := range files {
+		lines = append(lines, fmt.Sprintf("%s  %s\n", checksums[file], file))
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.WriteString(f, strings.Join(lines, ""))
+	return err
+}
+
+// readManifest returns the checksums of a manifest written by writeManifest
+// by file name.
+func readManifest(path string) (map[string]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	checksums := map[string]string{}
+	for i, line := range strings.Split(string(content), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		parts := strings.SplitN(line, "  ", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("%s:%d: invalid manifest line %q", path, i+1, line)
+		}
+		checksums[parts[1]] = parts[0]
+	}
+	return checksums, nil
+}
+
+func fileChecksum(path string, h hash.Hash) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer pushd(t, dir)()
+
+	ioutil.WriteFile("en.json", []byte("{}"), 0600)
+	ioutil.WriteFile("de.json", []byte("{}\n"), 0600)
+
+	if err := writeManifest(filepath.Join(dir, "manifest"), "sha256", []string{"en.json", "de.json"}); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "manifest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "ca3d163bab055381827226140568f3bef7eaac187cebd76878e0b63e9e442356  de.json\n" +
+		"44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a  en.json\n"
+	if string(content) != expected {
+		t.Errorf("expected manifest %q, got %q", expected, content)
+	}
+
+	if err := writeManifest(filepath.Join(dir, "manifest"), "crc32", nil); err == nil {
+		t.Errorf("expected an error for an unsupported algorithm")
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-manifest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "manifest")
+	checksums := map[string]string{"locales/en.json": "abc", "locales/de.json": "def"}
+	if err := writeChecksums(path, checksums); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	read, err := readManifest(path)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if !reflect.DeepEqual(read, checksums) {
+		t.Errorf("expected checksums %v, got %v", checksums, read)
+	}
+
+	ioutil.WriteFile(path, []byte("abc locales/en.json\n"), 0600)
+	if _, err := readManifest(path); err == nil {
+		t.Errorf("expected an error for an invalid line")
+	}
+}
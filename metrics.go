@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+// metrics collects the stats of a run exported with --otlp-endpoint, nil if
+// the export is disabled.
+var metrics *runMetrics
+
+// runMetrics are the stats of a run, all methods are no-ops on nil.
+type runMetrics struct {
+	mu            sync.Mutex
+	start         time.Time
+	requests      int64
+	errors        int64
+	retries       int64
+	bytesSent     int64
+	bytesReceived int64
+}
+
+func newRunMetrics() *runMetrics {
+	return &runMetrics{start: time.Now()}
+}
+
+func (m *runMetrics) add(counter *int64, n int64) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	*counter += n
+	m.mu.Unlock()
+}
+
+func (m *runMetrics) addRetry() {
+	if m != nil {
+		m.add(&m.retries, 1)
+	}
+}
+
+// recordMetrics makes the client count its requests, failed requests and the
+// bytes sent and received in metrics. Without metrics the client is left
+// untouched.
+func recordMetrics(client *phraseapp.Client) {
+	if metrics == nil {
+		return
+	}
+	client.Transport = &metricsTransport{metrics: metrics, base: client.Transport}
+}
+
+type metricsTransport struct {
+	metrics *runMetrics
+	base    http.RoundTripper
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	t.metrics.add(&t.metrics.requests, 1)
+	if req.ContentLength > 0 {
+		t.metrics.add(&t.metrics.bytesSent, req.ContentLength)
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		t.metrics.add(&t.metrics.errors, 1)
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		t.metrics.add(&t.metrics.errors, 1)
+	}
+	resp.Body = &countingBody{ReadCloser: resp.Body, metrics: t.metrics}
+	return resp, nil
+}
+
+type countingBody struct {
+	io.ReadCloser
+	metrics *runMetrics
+}
+
+func (body *countingBody) Read(p []byte) (int, error) {
+	n, err := body.ReadCloser.Read(p)
+	body.metrics.add(&body.metrics.bytesReceived, int64(n))
+	return n, err
+}
+
+// otlpTimeout limits the export of the metrics.
+var otlpTimeout = 10 * time.Second
+
+// exportMetrics sends the metrics of the run of command to the OTLP/HTTP
+// collector at endpoint, JSON encoded. The headers of the environment
+// variable OTEL_EXPORTER_OTLP_HEADERS, e.g. "Authorization=Bearer x", are
+// added to the request.
+func exportMetrics(endpoint, command string, runErr error) error {
+	if metrics == nil {
+		return nil
+	}
+
+	content, err := json.Marshal(metrics.otlpRequest(command, runErr, time.Now()))
+	if err != nil {
+		return err
+	}
+
+	url := strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/metrics") {
+		url += "/v1/metrics"
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for _, header := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		parts := strings.SplitN(header, "=", 2)
+		if len(parts) == 2 {
+			req.Header.Set(strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]))
+		}
+	}
+
+	resp, err := (&http.Client{Timeout: otlpTimeout}).Do(req)
+	if err != nil {
+		return fmt.Errorf("exporting metrics failed: %s", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("exporting metrics failed: %s", resp.Status)
+	}
+	return nil
+}
+
+// The types below are the parts of the JSON encoding of an OTLP
+// ExportMetricsServiceRequest used for the metrics of a run.
+
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type otlpMetric struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Unit        string     `json:"unit"`
+	Sum         *otlpSum   `json:"sum,omitempty"`
+	Gauge       *otlpGauge `json:"gauge,omitempty"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	IsMonotonic            bool            `json:"isMonotonic"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpDataPoint struct {
+	Attributes        []otlpAttribute `json:"attributes"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	// AsInt is a string, as 64 bit integers are encoded in OTLP JSON.
+	AsInt    string   `json:"asInt,omitempty"`
+	AsDouble *float64 `json:"asDouble,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// aggregationTemporalityCumulative is the temporality of all sums, the
+// counters start at the start of the run.
+const aggregationTemporalityCumulative = 2
+
+func (m *runMetrics) otlpRequest(command string, runErr error, now time.Time) *otlpRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := "ok"
+	if runErr != nil {
+		status = "error"
+	}
+	attributes := []otlpAttribute{
+		{Key: "command", Value: otlpValue{StringValue: command}},
+		{Key: "status", Value: otlpValue{StringValue: status}},
+	}
+	start, end := strconv.FormatInt(m.start.UnixNano(), 10), strconv.FormatInt(now.UnixNano(), 10)
+
+	counter := func(name, description, unit string, value int64) otlpMetric {
+		return otlpMetric{Name: name, Description: description, Unit: unit, Sum: &otlpSum{
+			DataPoints: []otlpDataPoint{{
+				Attributes:        attributes,
+				StartTimeUnixNano: start,
+				TimeUnixNano:      end,
+				AsInt:             strconv.FormatInt(value, 10),
+			}},
+			AggregationTemporality: aggregationTemporalityCumulative,
+			IsMonotonic:            true,
+		}}
+	}
+	duration := now.Sub(m.start).Seconds()
+
+	return &otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource: otlpResource{Attributes: []otlpAttribute{
+			{Key: "service.name", Value: otlpValue{StringValue: "phraseapp-client"}},
+			{Key: "service.version", Value: otlpValue{StringValue: PHRASEAPP_CLIENT_VERSION}},
+		}},
+		ScopeMetrics: []otlpScopeMetrics{{
+			Scope: otlpScope{Name: "phraseapp-client", Version: PHRASEAPP_CLIENT_VERSION},
+			Metrics: []otlpMetric{
+				counter("phraseapp.client.requests", "API requests made", "{request}", m.requests),
+				counter("phraseapp.client.request.errors", "API requests that failed or got an error response", "{request}", m.errors),
+				counter("phraseapp.client.retries", "Requests retried after the rate limit was exceeded", "{retry}", m.retries),
+				counter("phraseapp.client.bytes.sent", "Bytes of request bodies sent", "By", m.bytesSent),
+				counter("phraseapp.client.bytes.received", "Bytes of response bodies received", "By", m.bytesReceived),
+				{Name: "phraseapp.client.run.duration", Description: "Duration of the run", Unit: "s", Gauge: &otlpGauge{
+					DataPoints: []otlpDataPoint{{
+						Attributes:        attributes,
+						StartTimeUnixNano: start,
+						TimeUnixNano:      end,
+						AsDouble:          &duration,
+					}},
+				}},
+			},
+		}},
+	}}}
+}
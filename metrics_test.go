@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func TestExportMetrics(t *testing.T) {
+	metrics = newRunMetrics()
+	defer func() { metrics = nil }()
+
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/user" {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message": "Not Found"}`)
+			return
+		}
+		io.WriteString(w, `{"id": "user-id", "name": "Jane"}`)
+	}))
+	defer api.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = api.URL
+	client.Credentials.Token = "some_token"
+	recordMetrics(client)
+
+	if _, err := client.ShowUser(); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if _, err := client.ProjectShow("missing"); err == nil {
+		t.Fatalf("expected an error for a missing project")
+	}
+	metrics.addRetry()
+
+	var path, contentType string
+	var request otlpRequest
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("expected an OTLP JSON request, got: %s", err)
+		}
+	}))
+	defer collector.Close()
+
+	if err := exportMetrics(collector.URL, "pull", nil); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if path != "/v1/metrics" || contentType != "application/json" {
+		t.Errorf("expected a JSON request to /v1/metrics, got %q to %s", contentType, path)
+	}
+
+	values := map[string]string{}
+	for _, metric := range request.ResourceMetrics[0].ScopeMetrics[0].Metrics {
+		if metric.Sum == nil {
+			continue
+		}
+		point := metric.Sum.DataPoints[0]
+		values[metric.Name] = point.AsInt
+		if len(point.Attributes) != 2 || point.Attributes[0].Value.StringValue != "pull" || point.Attributes[1].Value.StringValue != "ok" {
+			t.Errorf("%s: expected the attributes command=pull and status=ok, got %+v", metric.Name, point.Attributes)
+		}
+	}
+	for name, exp := range map[string]string{
+		"phraseapp.client.requests":       "2",
+		"phraseapp.client.request.errors": "1",
+		"phraseapp.client.retries":        "1",
+		"phraseapp.client.bytes.sent":     "0",
+	} {
+		if values[name] != exp {
+			t.Errorf("expected %s to be %q, got %q", name, exp, values[name])
+		}
+	}
+	if values["phraseapp.client.bytes.received"] == "" {
+		t.Errorf("expected the received bytes to be counted")
+	}
+}
+
+func TestMetricsDisabled(t *testing.T) {
+	client := new(phraseapp.Client)
+	recordMetrics(client)
+	if client.Transport != nil {
+		t.Errorf("expected the client to be left untouched without metrics")
+	}
+	metrics.addRetry()
+	if err := exportMetrics("http://localhost:1", "push", nil); err != nil {
+		t.Errorf("expected nothing to be exported without metrics, got: %s", err)
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"strings"
+
 	"github.com/dynport/dgtk/cli"
 	"github.com/phrase/phraseapp-go/phraseapp"
 )
@@ -10,9 +12,52 @@ func ApplyNonRestRoutes(r *cli.Router, cfg *phraseapp.Config) {
 
 	r.Register("push", &PushCommand{Config: *cfg}, "Upload locales to your PhraseApp project.\n  You can provide parameters supported by the uploads#create endpoint https://developers.phraseapp.com/api/#uploads_create\n  in your configuration (.phraseapp.yml) for each source.\n  See our configuration guide for more information https://help.phraseapp.com/phraseapp-for-developers/phraseapp-client/configuration#push")
 
+	r.Register("sync", &SyncCommand{Config: *cfg}, "Download locales like pull, but detect files modified locally since the last sync.\n  Files modified locally and remotely are conflicts, resolved according to --conflicts.\n  Local modifications are kept and can be uploaded with --push.")
+
+	r.Register("paths", &PathsCommand{Config: *cfg}, "Print the files a pull would write, one per line, without downloading anything.\n  Use --push to print the local files a push would upload instead.")
+
+	r.Register("formats/supported", &FormatsCommand{Config: *cfg}, "List the file formats supported by PhraseApp with their extensions and whether they can be uploaded and downloaded, also available as formats.\n  Use --format json to print the formats as JSON.")
+
+	r.Register("copy", &CopyCommand{Config: *cfg}, "Copy translations of locales from one project to another.\n  The locales are downloaded from --from-project and uploaded to --to-project, where missing locales are created.")
+
+	r.Register("tags/unused", &TagsUnusedCommand{Config: *cfg}, "List tags of a project not used by the push sources or pull targets of your configuration,\n  nor created for one of the recent uploads.")
+
+	r.Register("locales/rename", &LocalesRenameCommand{Config: *cfg}, "Change the code of a locale and move the local files of your pull targets accordingly.\n  Use --dry-run to only print what would be renamed.")
+
+	r.Register("keys/get", &KeysGetCommand{Config: *cfg}, "Print the translation of a single key in a locale, e.g. keys get --key foo.bar --locale en.")
+
+	r.Register("keys/set", &KeysSetCommand{Config: *cfg}, "Set the translation of a single key in a locale, e.g. keys set --key foo.bar --locale en --value Hello.\n  Prints the value before and after the change.")
+
+	r.Register("untranslated", &UntranslatedCommand{Config: *cfg}, "List the keys without a translation or with an unverified one in a locale, e.g. untranslated --locale de.\n  Use --tag to only list keys with these tags and --count to only print their number.")
+
+	r.Register("branches/all", &BranchesCommand{Config: *cfg}, "List the names, creation dates and states of all branches of a project, also available as branches,\n  to find valid values for --branch. Use --format json to print the branches as JSON.")
+
+	r.Register("ratelimit", &RateLimitCommand{Config: *cfg}, "Show the limit, remaining requests and reset time of the API rate limit of your account,\n  to check the budget before large pulls or pushes. Makes a single request.")
+
 	r.Register("init", &InitCommand{Config: *cfg}, "Configure your PhraseApp client.")
 
 	r.Register("upload/cleanup", &UploadCleanupCommand{Config: *cfg}, "Delete unmentioned keys for given upload")
 
-	r.RegisterFunc("info", infoCommand, "Info about version and revision of this client")
+	r.Register("info", &InfoCommand{}, "Info about version and revision of this client, also available as version.\n  Use --json to check the installed version from scripts.")
+}
+
+// routeAliases are commands run as another route. They can't be registered
+// themselves, as their paths are prefixes of API commands.
+var routeAliases = map[string][]string{
+	"branches": {"branches", "all"},
+	"formats":  {"formats", "supported"},
+	"version":  {"info"},
+}
+
+// aliasRoute rewrites args of an alias, given without a subcommand, to the
+// route of the alias.
+func aliasRoute(args []string) []string {
+	if len(args) == 0 || len(args) > 1 && !strings.HasPrefix(args[1], "-") {
+		return args
+	}
+	route, ok := routeAliases[args[0]]
+	if !ok {
+		return args
+	}
+	return append(append([]string{}, route...), args[1:]...)
 }
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// outputTemplateData are the fields available in the output_template of a
+// target. Language and Region are derived from the locale code, e.g. "pt" and
+// "BR" for "pt-BR". Region is empty for codes without region.
+type outputTemplateData struct {
+	Code      string
+	Name      string
+	ID        string
+	Language  string
+	Region    string
+	Tag       string
+	Project   string
+	ProjectID string
+	Format    string
+}
+
+var outputTemplateFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// template returns the compiled output_template of the target, or nil if the
+// target has none.
+func (target *Target) template() (*template.Template, error) {
+	if target.OutputTemplate == "" || target.outputTemplate != nil {
+		return target.outputTemplate, nil
+	}
+
+	tmpl, err := template.New("output_template").Funcs(outputTemplateFuncs).Option("missingkey=error").Parse(target.OutputTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output_template %q: %s", target.OutputTemplate, err)
+	}
+
+	// unknown fields are only detected on execution
+	if _, err := executeOutputTemplate(tmpl, outputTemplateData{Code: "en-US", Name: "english", ID: "id"}); err != nil {
+		return nil, fmt.Errorf("invalid output_template %q: %s", target.OutputTemplate, err)
+	}
+
+	target.outputTemplate = tmpl
+	return tmpl, nil
+}
+
+// templatePath returns the absolute path of localeFile from the output_template.
+func (target *Target) templatePath(tmpl *template.Template, localeFile *LocaleFile) (string, error) {
+	language, region := localeFile.Code, ""
+	if i := strings.IndexAny(localeFile.Code, "-_"); i >= 0 {
+		language, region = localeFile.Code[:i], localeFile.Code[i+1:]
+	}
+
+	path, err := executeOutputTemplate(tmpl, outputTemplateData{
+		Code:      localeFile.Code,
+		Name:      localeFile.Name,
+		ID:        localeFile.ID,
+		Language:  language,
+		Region:    region,
+		Tag:       target.tagName(localeFile.Tag),
+		Project:   target.projectName(),
+		ProjectID: target.ProjectID,
+		Format:    localeFile.FileFormat,
+	})
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return "", fmt.Errorf("output_template %q resolved to an empty path for locale %q", target.OutputTemplate, localeFile.Code)
+	}
+	return filepath.Abs(target.withOutputBase(path))
+}
+
+func executeOutputTemplate(tmpl *template.Template, data outputTemplateData) (string, error) {
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+type PathsCommand struct {
+	phraseapp.Config
+	Push    bool   `cli:"opt --push desc='Print the local files matched by the push sources instead of the pull destinations'"`
+	Branch  string `cli:"opt --branch"`
+	Flatten bool   `cli:"opt --flatten desc='Print pull destinations as written with pull --flatten'"`
+}
+
+func (cmd *PathsCommand) Run() error {
+	if cmd.Config.Debug {
+		// suppresses content output
+		cmd.Config.Debug = false
+		Debug = true
+	}
+
+	var paths []string
+	var err error
+	if cmd.Push {
+		paths, err = cmd.sourcePaths()
+	} else {
+		paths, err = cmd.targetPaths()
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, path := range paths {
+		fmt.Println(path)
+	}
+	return nil
+}
+
+// sourcePaths returns the files on disk a push would upload. No API requests
+// are necessary for this.
+func (cmd *PathsCommand) sourcePaths() ([]string, error) {
+	sources, err := SourcesFromConfig(cmd.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := sources.Validate(); err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	for _, source := range sources {
+		localeFiles, err := source.LocaleFiles()
+		if err != nil {
+			return nil, err
+		}
+		for _, localeFile := range localeFiles {
+			paths = append(paths, localeFile.RelPath())
+		}
+	}
+	return paths, nil
+}
+
+// targetPaths returns the files a pull would write. The remote locales must be
+// fetched to expand the locale placeholders, but nothing is downloaded.
+func (cmd *PathsCommand) targetPaths() ([]string, error) {
+	client, err := newClient(cmd.Config.Credentials, cmd.Config.Debug)
+	if err != nil {
+		return nil, err
+	}
+
+	targets, err := TargetsFromConfig(cmd.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := targets.ResolveProjectNames(client); err != nil {
+		return nil, err
+	}
+
+	if err := targets.FetchRemoteLocales(client, cmd.Branch); err != nil {
+		return nil, err
+	}
+
+	if err := targets.LoadFormatExtensions(client); err != nil {
+		return nil, err
+	}
+
+	if err := targets.FetchProjectNames(client); err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	for _, target := range targets {
+		target.Flatten = cmd.Flatten
+		if err := target.CheckPreconditions(); err != nil {
+			return nil, err
+		}
+
+		localeFiles, err := target.LocaleFiles()
+		if err != nil {
+			return nil, err
+		}
+		for _, localeFile := range localeFiles {
+			paths = append(paths, localeFile.RelPath())
+		}
+	}
+	return paths, nil
+}
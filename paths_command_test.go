@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func TestPathsCommandSourcePaths(t *testing.T) {
+	d := setupFiles(t, "locales/en.yml", "locales/de.yml", "locales/README.md")
+	defer os.RemoveAll(d)
+	defer pushd(t, d)()
+
+	cfg := phraseapp.Config{
+		DefaultProjectID:  "project-id",
+		DefaultFileFormat: "yml",
+		Sources:           []byte("sources:\n- file: ./locales/<locale_code>.yml\n"),
+	}
+
+	paths, err := (&PathsCommand{Config: cfg, Push: true}).sourcePaths()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if exp := []string{"locales/de.yml", "locales/en.yml"}; !reflect.DeepEqual(paths, exp) {
+		t.Errorf("expected the matched files %v, got %v", exp, paths)
+	}
+}
+
+func TestPathsCommandTargetPaths(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-paths-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer pushd(t, dir)()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/projects/project-id/locales" {
+			t.Errorf("unexpected request to %s, nothing should be downloaded", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		io.WriteString(w, `[{"id": "en-locale-id", "code": "en", "name": "english"}, {"id": "de-locale-id", "code": "de", "name": "german"}]`)
+	}))
+	defer srv.Close()
+
+	cfg := phraseapp.Config{
+		DefaultProjectID:  "project-id",
+		DefaultFileFormat: "json",
+		Targets:           []byte("targets:\n- file: ./locales/<locale_code>.json\n"),
+	}
+	cfg.Credentials.Host = srv.URL
+	cfg.Credentials.Token = "some_token"
+
+	paths, err := (&PathsCommand{Config: cfg}).targetPaths()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if exp := []string{"locales/en.json", "locales/de.json"}; !reflect.DeepEqual(paths, exp) {
+		t.Errorf("expected the destinations %v, got %v", exp, paths)
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected no files to be written, got %d", len(entries))
+	}
+}
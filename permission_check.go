@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+// writeScope is the access token scope needed to create locales, upload
+// files and delete keys.
+const writeScope = "write"
+
+// checkWriteAccess verifies the access token of the client and the tokens
+// configured per project have the write scope before a command changes a
+// project, so it fails right away instead of after some of its changes.
+func checkWriteAccess(client *phraseapp.Client, tokens projectTokens) error {
+	checked := map[string]bool{}
+	for _, token := range append([]string{client.Credentials.Token}, tokens.values()...) {
+		if token == "" || checked[token] {
+			continue
+		}
+		checked[token] = true
+		if err := checkTokenScopes(client, token, writeScope); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tokens projectTokens) values() []string {
+	values := []string{}
+	for _, token := range tokens {
+		values = append(values, token)
+	}
+	return values
+}
+
+// checkTokenScopes looks up the scopes of token in the authorizations of its
+// user and returns an error if it lacks any of the required ones. The check
+// is skipped if the authorizations can't be listed or don't include the
+// token, as with tokens of other users or basic authentication.
+func checkTokenScopes(client *phraseapp.Client, token string, required ...string) error {
+	c := *client
+	c.Credentials.Token = token
+
+	authorization, err := findAuthorization(&c, token)
+	if err != nil {
+		if httpStatus(err) == 401 {
+			return fmt.Errorf("The access token %s is invalid or expired", maskedToken(token))
+		}
+		if Debug {
+			fmt.Fprintf(os.Stderr, "Skipping permission check, can't list authorizations: %s\n", err)
+		}
+		return nil
+	}
+	if authorization == nil {
+		if Debug {
+			fmt.Fprintf(os.Stderr, "Skipping permission check, no authorization found for the access token %s\n", maskedToken(token))
+		}
+		return nil
+	}
+
+	granted := map[string]bool{}
+	for _, scope := range authorization.Scopes {
+		granted[scope] = true
+	}
+	missing := []string{}
+	for _, scope := range required {
+		if !granted[scope] {
+			missing = append(missing, scope)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("The access token %s lacks the %s scope required for this command, its scopes are: %s",
+			maskedToken(token), strings.Join(missing, ", "), strings.Join(authorization.Scopes, ", "))
+	}
+	return nil
+}
+
+// findAuthorization returns the authorization of token, or nil if there is
+// none.
+func findAuthorization(client *phraseapp.Client, token string) (*phraseapp.Authorization, error) {
+	for page := 1; ; page++ {
+		var authorizations []*phraseapp.Authorization
+		err := retryOnRateLimit(func() (err error) {
+			authorizations, err = client.AuthorizationsList(page, 100)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, authorization := range authorizations {
+			if authorization.TokenLastEight != "" && strings.HasSuffix(token, authorization.TokenLastEight) {
+				return authorization, nil
+			}
+		}
+		if len(authorizations) < 100 {
+			return nil, nil
+		}
+	}
+}
+
+// maskedToken returns token with all but its last four characters replaced,
+// to name it in messages.
+func maskedToken(token string) string {
+	if len(token) <= 4 {
+		return strings.Repeat("*", len(token))
+	}
+	return strings.Repeat("*", 4) + token[len(token)-4:]
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func TestCheckWriteAccess(t *testing.T) {
+	scopes := map[string]string{
+		"readonly-token-1234": `["read"]`,
+		"write-token-5678":    `["read","write"]`,
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/authorizations" {
+			t.Errorf("expected only authorizations to be listed, got %s", r.URL.Path)
+		}
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "token ")
+		switch {
+		case token == "expired-token-0000":
+			w.WriteHeader(http.StatusUnauthorized)
+		case token == "other-user-token":
+			w.WriteHeader(http.StatusForbidden)
+		case scopes[token] != "":
+			fmt.Fprintf(w, `[{"id":"auth-id","token_last_eight":%q,"scopes":%s}]`, token[len(token)-8:], scopes[token])
+		default:
+			fmt.Fprint(w, `[]`)
+		}
+	}))
+	defer srv.Close()
+
+	newTestClient := func(token string) *phraseapp.Client {
+		client := new(phraseapp.Client)
+		client.Credentials.Host = srv.URL
+		client.Credentials.Token = token
+		return client
+	}
+
+	err := checkWriteAccess(newTestClient("readonly-token-1234"), nil)
+	if err == nil || !strings.Contains(err.Error(), "lacks the write scope") {
+		t.Errorf("expected an error for a token without the write scope, got %v", err)
+	}
+	if strings.Contains(fmt.Sprint(err), "readonly-token") {
+		t.Errorf("expected the token to be masked, got %s", err)
+	}
+
+	if err := checkWriteAccess(newTestClient("write-token-5678"), nil); err != nil {
+		t.Errorf("didn't expect an error for a token with the write scope, got %s", err)
+	}
+
+	err = checkWriteAccess(newTestClient("write-token-5678"), projectTokens{"project-id": "readonly-token-1234"})
+	if err == nil {
+		t.Errorf("expected an error for a project token without the write scope")
+	}
+
+	if err := checkWriteAccess(newTestClient("expired-token-0000"), nil); err == nil {
+		t.Errorf("expected an error for an invalid token")
+	}
+
+	for _, token := range []string{"other-user-token", "unknown-token"} {
+		if err := checkWriteAccess(newTestClient(token), nil); err != nil {
+			t.Errorf("expected the check to be skipped for %s, got %s", token, err)
+		}
+	}
+}
+
+func TestCommandsCheckWriteAccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/authorizations" {
+			t.Errorf("expected the token to be checked first, got a request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `[{"id":"auth-id","token_last_eight":"ken-1234","scopes":["read"]}]`)
+	}))
+	defer srv.Close()
+
+	config := phraseapp.Config{Credentials: phraseapp.Credentials{Host: srv.URL, Token: "readonly-token-1234"}}
+	for name, cmd := range map[string]interface{ Run() error }{
+		"copy":        &CopyCommand{Config: config, FromProject: "from-id", ToProject: "to-id", FileFormat: "json", Locales: []string{"en"}},
+		"sync --push": &SyncCommand{Config: config, Conflicts: conflictsAbort, Push: true},
+	} {
+		if err := cmd.Run(); err == nil || !strings.Contains(err.Error(), "lacks the write scope") {
+			t.Errorf("%s: expected an error for a token without the write scope, got %v", name, err)
+		}
+	}
+}
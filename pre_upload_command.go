@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// runPreUploadCommand runs the pre_upload_command of a source for the file at
+// path and writes its output into a temporary file with the same name, which
+// is uploaded instead. The command is run by the shell with the path as
+// last argument, a non-zero exit status fails the upload. The returned
+// function removes the temporary file.
+func runPreUploadCommand(command, path string) (string, func(), error) {
+	cmd := shellCommand(command, path)
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		msg := fmt.Sprintf("pre_upload_command failed for %s: %s", path, err)
+		if output := strings.TrimSpace(stderr.String()); output != "" {
+			msg += ": " + output
+		}
+		return "", nil, fmt.Errorf("%s", msg)
+	}
+
+	dir, err := ioutil.TempDir(tempDirFor(""), "phraseapp")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	transformed := filepath.Join(dir, filepath.Base(path))
+	if err := ioutil.WriteFile(transformed, stdout.Bytes(), 0600); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return transformed, cleanup, nil
+}
+
+// shellCommand returns a command running command in the shell of the system
+// with arg appended.
+func shellCommand(command, arg string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command+` "`+arg+`"`)
+	}
+	return exec.Command("sh", "-c", command+` "$1"`, "sh", arg)
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+// defaultProjectName is the project_name config option, used by sources and
+// targets without project instead of a project_id.
+var defaultProjectName string
+
+// projectIDsByName maps the names of all projects of the account to their
+// IDs. It is loaded once per run, when the first project name is resolved.
+var projectIDsByName map[string]string
+
+// projectIDForName returns the ID of the project with the given name.
+func projectIDForName(client *phraseapp.Client, name string) (string, error) {
+	if projectIDsByName == nil {
+		projects, err := allProjects(client)
+		if err != nil {
+			return "", err
+		}
+
+		projectIDsByName = map[string]string{}
+		for _, project := range projects {
+			projectIDsByName[project.Name] = project.ID
+		}
+	}
+
+	if id, ok := projectIDsByName[name]; ok {
+		return id, nil
+	}
+
+	names := []string{}
+	for name := range projectIDsByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return "", fmt.Errorf("No project named %q found. Available projects are:\n  %s", name, strings.Join(names, "\n  "))
+}
+
+func allProjects(client *phraseapp.Client) ([]*phraseapp.Project, error) {
+	page := 1
+	projects, err := client.ProjectsList(page, 100)
+	if err != nil {
+		return nil, err
+	}
+	result := projects
+	for len(projects) == 100 {
+		page = page + 1
+		projects, err = client.ProjectsList(page, 100)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, projects...)
+	}
+	return result, nil
+}
+
+// ResolveProjectNames sets the project IDs of sources given by project_name.
+func (sources Sources) ResolveProjectNames(client *phraseapp.Client) error {
+	for _, source := range sources {
+		if source.ProjectID != "" || source.ProjectName == "" {
+			continue
+		}
+
+		id, err := projectIDForName(client, source.ProjectName)
+		if err != nil {
+			return err
+		}
+		source.ProjectID = id
+	}
+	return nil
+}
+
+// ResolveProjectNames sets the project IDs of targets given by project_name.
+func (targets Targets) ResolveProjectNames(client *phraseapp.Client) error {
+	for _, target := range targets {
+		if target.ProjectID != "" || target.ProjectName == "" {
+			continue
+		}
+
+		id, err := projectIDForName(client, target.ProjectName)
+		if err != nil {
+			return err
+		}
+		target.ProjectID = id
+	}
+	return nil
+}
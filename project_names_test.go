@@ -0,0 +1,84 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func TestResolveProjectNames(t *testing.T) {
+	defer func() { projectIDsByName = nil }()
+	projectIDsByName = nil
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		io.WriteString(w, `[{"id": "web-id", "name": "Web"}, {"id": "mobile-id", "name": "Mobile"}]`)
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	config := phraseapp.Config{
+		DefaultProjectID: "default-id",
+		Sources: []byte(`sources:
+- file: ./web/<locale_code>.json
+  project_name: Web
+- file: ./default/<locale_code>.json
+`),
+		Targets: []byte(`targets:
+- file: ./mobile/<locale_code>.json
+  project_name: Mobile
+`),
+	}
+
+	sources, err := SourcesFromConfig(config)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if err := sources.ResolveProjectNames(client); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if sources[0].ProjectID != "web-id" || sources[1].ProjectID != "default-id" {
+		t.Errorf("expected project IDs web-id and default-id, got %q and %q", sources[0].ProjectID, sources[1].ProjectID)
+	}
+
+	targets, err := TargetsFromConfig(config)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if err := targets.ResolveProjectNames(client); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if targets[0].ProjectID != "mobile-id" {
+		t.Errorf("expected project ID mobile-id, got %q", targets[0].ProjectID)
+	}
+
+	if requests != 1 {
+		t.Errorf("expected projects to be listed once, got %d requests", requests)
+	}
+
+	_, err = projectIDForName(client, "Desktop")
+	if err == nil || !strings.Contains(err.Error(), "Mobile\n  Web") {
+		t.Errorf("expected an error listing the available projects, got: %v", err)
+	}
+}
+
+func TestProjectNameAndID(t *testing.T) {
+	config := phraseapp.Config{
+		Sources: []byte(`sources:
+- file: ./web/<locale_code>.json
+  project_id: web-id
+  project_name: Web
+`),
+	}
+	if _, err := SourcesFromConfig(config); err == nil {
+		t.Errorf("expected an error for a source with project_id and project_name")
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/phrase/phraseapp-client/internal/print"
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+// projectTokens maps project IDs to the access tokens configured for them
+// with access_token of a source or target.
+type projectTokens map[string]string
+
+// add adds the token of a source or target of the project. Sources or
+// targets of the same project must not use different tokens.
+func (tokens projectTokens) add(projectID, token string) error {
+	if token == "" {
+		return nil
+	}
+	if existing, found := tokens[projectID]; found && existing != token {
+		return fmt.Errorf("different access tokens are configured for project %q", projectID)
+	}
+	print.Mask(token)
+	tokens[projectID] = token
+	return nil
+}
+
+// AccessTokens returns the access tokens configured for the projects of the
+// targets.
+func (targets Targets) AccessTokens() (projectTokens, error) {
+	tokens := projectTokens{}
+	for _, target := range targets {
+		if err := tokens.add(target.ProjectID, target.AccessToken); err != nil {
+			return nil, err
+		}
+	}
+	return tokens, nil
+}
+
+// AccessTokens returns the access tokens configured for the projects of the
+// sources.
+func (sources Sources) AccessTokens() (projectTokens, error) {
+	tokens := projectTokens{}
+	for _, source := range sources {
+		if err := tokens.add(source.ProjectID, source.AccessToken); err != nil {
+			return nil, err
+		}
+	}
+	return tokens, nil
+}
+
+// useProjectTokens makes the client authenticate requests for the resources
+// of a project with the token configured for it, if any. All other requests
+// use the token of the client.
+func useProjectTokens(client *phraseapp.Client, tokens projectTokens) {
+	if len(tokens) == 0 {
+		return
+	}
+	client.Transport = &projectTokenTransport{tokens: tokens, base: client.Transport}
+}
+
+type projectTokenTransport struct {
+	tokens projectTokens
+	base   http.RoundTripper
+}
+
+func (t *projectTokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	token, found := t.tokens[requestProjectID(req)]
+	if !found {
+		return base.RoundTrip(req)
+	}
+
+	// a RoundTripper must not modify the given request
+	r := new(http.Request)
+	*r = *req
+	r.Header = http.Header{}
+	for name, values := range req.Header {
+		r.Header[name] = values
+	}
+	r.Header.Set("Authorization", "token "+token)
+	return base.RoundTrip(r)
+}
+
+// requestProjectID returns the ID of the project in the path of an API
+// request, e.g. /v2/projects/:id/locales, or "" for other requests.
+func requestProjectID(req *http.Request) string {
+	segments := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	for i := 0; i+1 < len(segments); i++ {
+		if segments[i] == "projects" {
+			return segments[i+1]
+		}
+	}
+	return ""
+}
@@ -1,13 +1,23 @@
 package main
 
 import (
+	"bytes"
+	"compress/gzip"
 	"fmt"
-	"io/ioutil"
+	"net"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
+	"github.com/phrase/phraseapp-client/internal/dedupe"
+	"github.com/phrase/phraseapp-client/internal/indent"
+	"github.com/phrase/phraseapp-client/internal/keyfilter"
+	"github.com/phrase/phraseapp-client/internal/localefilter"
+	"github.com/phrase/phraseapp-client/internal/minify"
 	"github.com/phrase/phraseapp-client/internal/paths"
 	"github.com/phrase/phraseapp-client/internal/placeholders"
 	"github.com/phrase/phraseapp-client/internal/print"
@@ -20,49 +30,314 @@ const (
 
 type PullCommand struct {
 	phraseapp.Config
-	Branch string `cli:"opt --branch"`
+	Branch  string   `cli:"opt --branch"`
+	Flatten bool     `cli:"opt --flatten desc='Collapse placeholder directories into a file name prefix, e.g. feature_en.json'"`
+	Resume  bool     `cli:"opt --resume desc='Skip files already downloaded by a previous, interrupted pull'"`
+	Minify  bool     `cli:"opt --minify desc='Remove insignificant whitespace from JSON and XML files'"`
+	Gzip    bool     `cli:"opt --gzip-output desc='Write files gzip compressed, appending .gz to their paths'"`
+	Keys    []string `cli:"opt --keys desc='Only write these keys, comma separated, nested keys joined by dots. Supported for JSON files'"`
+
+	PrefixLocaleDir bool   `cli:"opt --prefix-locale-dir desc='Write files of targets without locale placeholder to a directory per locale, e.g. en/messages.json'"`
+	LocaleDetails   bool   `cli:"opt --download-locale-details desc='Write the details of each locale as JSON next to its files, with the suffix .details'"`
+	OutputBase      string `cli:"opt --output-base desc='Directory prepended to relative target paths, may reference environment variables like $XDG_DATA_HOME or %APPDATA%'"`
+
+	MaxRetriesTotal *int `cli:"opt --max-retries-total desc='Maximum number of retries for the whole run'"`
+	GithubActions   bool `cli:"opt --github-actions desc='Report results as GitHub Actions outputs and annotations, enabled automatically in GitHub Actions'"`
+
+	ErrorReport string `cli:"opt --error-report desc='Write the errors of all failed files as JSON to this file'"`
+
+	Manifest          string `cli:"opt --manifest desc='Write the checksums of all pulled files to this file'"`
+	ManifestAlgorithm string `cli:"opt --manifest-algorithm default=sha256 desc='Checksum algorithm of the manifest: md5, sha1, sha256 or sha512'"`
+
+	RequestsPerSecond int `cli:"opt --rps desc='Maximum number of API requests per second'"`
+	Parallel          int `cli:"opt --parallel default=1 desc='Number of files downloaded concurrently per target'"`
+
+	WriteConcurrency int `cli:"opt --write-concurrency desc='Maximum number of files written at once, independent of the parallel downloads. Unlimited by default'"`
+
+	ParallelTargets int `cli:"opt --parallel-targets default=1 desc='Number of targets pulled concurrently, a failed target does not stop the others'"`
+
+	VerboseErrors bool `cli:"opt --verbose-errors desc='Print the complete response of failed requests'"`
+
+	FailOnWarnings bool `cli:"opt --fail-on-warnings desc='Fail if any warnings occurred, e.g. a skipped git branch tag or a fuzzy locale match'"`
+
+	XliffStates bool `cli:"opt --xliff-states desc='Keep the state of XLIFF translation units'"`
+	XliffNotes  bool `cli:"opt --xliff-notes desc='Keep the notes of XLIFF translation units'"`
+
+	CSVDelimiter string   `cli:"opt --csv-delimiter desc='Column separator of CSV files, a single character or tab'"`
+	CSVColumns   []string `cli:"opt --csv-columns desc='Only write these columns of CSV files in this order, named as in the header row, comma separated'"`
+
+	FormatOptionsJSON string `cli:"opt --format-options-json desc='Format options as JSON object, e.g. {\"enclose_in_cdata\":true}, overriding those of the config'"`
+
+	SummaryOnly bool `cli:"opt --summary-only desc='Print a single summary instead of a line per file'"`
+
+	Interactive  bool   `cli:"opt --interactive desc='Select the locale to pull from a list'"`
+	LocaleFilter string `cli:"opt --locale-filter desc='Only pull locales matching this filter, e.g. rtl=true or code=en-*,default!=true'"`
+	LocaleRegex  string `cli:"opt --locale-regex desc='Only pull locales whose code matches this regular expression, e.g. ^es(-.*)?$'"`
+
+	ResolveFallbacks bool `cli:"opt --resolve-fallbacks desc='Fill untranslated keys with the translations of the fallback locales. Merged for all fallbacks of JSON and YAML files, other formats get the nearest fallback from the server'"`
+
+	DedupeKeys bool   `cli:"opt --dedupe-keys desc='Remove duplicate keys from JSON and YAML files, warning about each'"`
+	DedupeKeep string `cli:"opt --dedupe-keep default=last desc='Value of a duplicate key kept by --dedupe-keys: first or last'"`
+
+	NoOverwrite bool `cli:"opt --no-overwrite desc='Skip files which already exist instead of overwriting them'"`
+
+	UseCacheOnFailure bool `cli:"opt --use-cache-on-failure desc='Keep the existing copy of a file with a warning if its download fails with a network or server error'"`
+
+	EditorConfig bool `cli:"opt --editorconfig desc='Indent JSON and YAML files as configured by the nearest .editorconfig, with indent_style and indent_size'"`
+
+	MinCompletion int `cli:"opt --min-completion desc='Skip locales with a lower percentage of translated keys'"`
+
+	MaxLocales int  `cli:"opt --max-locales desc='Fail if a target resolves to more locale files, to catch patterns expanding to unexpected locales'"`
+	Yes        bool `cli:"opt --yes desc='Pull targets resolving to more locale files than --max-locales anyway'"`
+
+	TmpDir string `cli:"opt --tmp-dir desc='Directory for intermediate files, defaults to the directory of each file'"`
+
+	Commit string `cli:"opt --commit desc='Commit the pulled files with this message inside a git repository, may use {{.Count}}, {{.Locales}} and {{.Branch}}'"`
+
+	OTLPEndpoint string `cli:"opt --otlp-endpoint desc='Export metrics of the run to this OTLP/HTTP collector, e.g. http://localhost:4318'"`
 }
 
-func (cmd *PullCommand) Run() error {
+func (cmd *PullCommand) Run() (err error) {
+	if cmd.OTLPEndpoint != "" {
+		metrics = newRunMetrics()
+		defer func() {
+			if exportErr := exportMetrics(cmd.OTLPEndpoint, "pull", err); exportErr != nil {
+				print.Warning("%s", exportErr)
+			}
+		}()
+	}
+
+	actions := newGithubActions(cmd.GithubActions)
+	defer func() { actions.Error(err) }()
+	defer func() {
+		if err == nil {
+			err = warnings.check(cmd.FailOnWarnings)
+		}
+	}()
+
 	if cmd.Config.Debug {
 		// suppresses content output
 		cmd.Config.Debug = false
 		Debug = true
 	}
+	setRetryBudget(cmd.MaxRetriesTotal)
+	cmd.Branch = branchOrDefault(cmd.Branch)
+
+	if cmd.TmpDir != "" {
+		if err := setTmpDir(cmd.TmpDir); err != nil {
+			return err
+		}
+	}
+
+	if _, ok := manifestAlgorithms[strings.ToLower(cmd.ManifestAlgorithm)]; cmd.Manifest != "" && !ok {
+		return fmt.Errorf("unsupported manifest algorithm %q, use one of md5, sha1, sha256 or sha512", cmd.ManifestAlgorithm)
+	}
+
+	var report *errorReport
+	if cmd.ErrorReport != "" {
+		report = &errorReport{}
+		defer func() {
+			if reportErr := report.write(cmd.ErrorReport); reportErr != nil && err == nil {
+				err = reportErr
+			}
+		}()
+	}
+
 	client, err := newClient(cmd.Config.Credentials, cmd.Config.Debug)
 	if err != nil {
 		return err
 	}
+	recordMetrics(client)
+
+	if cmd.VerboseErrors {
+		dumpErrorResponses(client, os.Stderr)
+	}
+
+	// targets with their own request rate don't share the limit of the others
+	unlimited := *client
+	if err := limitRequestRate(client, cmd.RequestsPerSecond); err != nil {
+		return err
+	}
+
+	if cmd.DedupeKeys && cmd.DedupeKeep != "first" && cmd.DedupeKeep != "last" {
+		return fmt.Errorf("unsupported value %q for --dedupe-keep, use first or last", cmd.DedupeKeep)
+	}
+
+	if cmd.EditorConfig && cmd.Minify {
+		return fmt.Errorf("--editorconfig can't be combined with --minify")
+	}
+
+	csvDelimiter, err := parseCSVDelimiter(cmd.CSVDelimiter)
+	if err != nil {
+		return err
+	}
+
+	if cmd.MinCompletion < 0 || cmd.MinCompletion > 100 {
+		return fmt.Errorf("--min-completion must be a percentage between 0 and 100")
+	}
+
+	if cmd.MaxLocales < 0 {
+		return fmt.Errorf("--max-locales must not be negative")
+	}
+
+	if cmd.WriteConcurrency < 0 {
+		return fmt.Errorf("--write-concurrency must not be negative")
+	}
+	writeSlots := newWriteSlots(cmd.WriteConcurrency)
+
+	var localeFilter *localefilter.Filter
+	if cmd.LocaleFilter != "" {
+		if localeFilter, err = localefilter.Parse(cmd.LocaleFilter); err != nil {
+			return err
+		}
+	}
+
+	localeRegex, err := compileLocaleRegex(cmd.LocaleRegex)
+	if err != nil {
+		return err
+	}
+
+	var commitMessage *template.Template
+	if cmd.Commit != "" {
+		if commitMessage, err = parseCommitMessage(cmd.Commit); err != nil {
+			return err
+		}
+	}
+
+	formatOptions, err := parseFormatOptionsJSON(cmd.FormatOptionsJSON)
+	if err != nil {
+		return err
+	}
+
+	outputBase, err := paths.ExpandBase(cmd.OutputBase, runtime.GOOS, os.Getenv)
+	if err != nil {
+		return err
+	}
 
 	targets, err := TargetsFromConfig(cmd.Config)
 	if err != nil {
 		return err
 	}
 
-	projectIdToLocales, err := LocalesForProjects(client, targets, cmd.Branch)
+	if err := targets.ResolveProjectNames(client); err != nil {
+		return err
+	}
+
+	tokens, err := targets.AccessTokens()
 	if err != nil {
 		return err
 	}
+	useProjectTokens(client, tokens)
+	useProjectTokens(&unlimited, tokens)
 
-	for _, target := range targets {
-		val, ok := projectIdToLocales[LocaleCacheKey{target.ProjectID, cmd.Branch}]
-		if !ok || len(val) == 0 {
-			if cmd.Branch != "" {
-				continue
-			}
-			return fmt.Errorf("Could not find any locales for project %q", target.ProjectID)
+	if err := validateBranch(client, targets.ProjectIds(), cmd.Branch); err != nil {
+		return err
+	}
+
+	if err := targets.FetchRemoteLocales(client, cmd.Branch); err != nil {
+		return err
+	}
+
+	if cmd.Interactive {
+		if err := targets.PickLocale(); err != nil {
+			return err
 		}
-		target.RemoteLocales = val
+	}
+
+	// refreshes the formats cache used to validate the formats of targets.
+	// If the formats can't be listed, they are validated against the cached
+	// list, as pull doesn't need them otherwise.
+	if _, err := Formats(client); err != nil && Debug {
+		fmt.Fprintf(os.Stderr, "Listing the formats failed, using the cached list: %s\n", err)
+	}
+
+	if err := targets.LoadFormatExtensions(client); err != nil {
+		return err
+	}
+
+	if err := targets.FetchProjectNames(client); err != nil {
+		return err
+	}
+
+	session, err := newPullSession(cmd.Config, cmd.Branch, cmd.Resume)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	results := &runResults{}
+	if cmd.SummaryOnly {
+		defer func() {
+			if err != nil {
+				results.addError()
+				print.Failure("%s", results.Summary())
+				return
+			}
+			print.Success("%s", results.Summary())
+		}()
 	}
 
 	for _, target := range targets {
-		err := target.Pull(client, cmd.Branch)
-		if err != nil {
+		target.SummaryOnly = cmd.SummaryOnly
+		target.LocaleFilter = localeFilter
+		target.LocaleRegex = localeRegex
+		target.Flatten = cmd.Flatten
+		target.PrefixLocaleDir = cmd.PrefixLocaleDir
+		target.OutputBase = outputBase
+		target.WriteLocaleDetails = target.WriteLocaleDetails || cmd.LocaleDetails
+		target.Minify = cmd.Minify
+		target.EditorConfig = cmd.EditorConfig
+		target.NoOverwrite = cmd.NoOverwrite
+		target.UseCacheOnFailure = cmd.UseCacheOnFailure
+		target.DedupeKeys = cmd.DedupeKeys
+		target.ResolveFallbacks = cmd.ResolveFallbacks
+		target.KeepFirstDuplicate = cmd.DedupeKeep == "first"
+		target.Keys = cmd.Keys
+		target.Compress = target.Compress || cmd.Gzip
+		target.Xliff = xliffOptions{States: cmd.XliffStates, Notes: cmd.XliffNotes}
+		target.CSV = csvOptions{Delimiter: csvDelimiter, Columns: cmd.CSVColumns}
+		target.MinCompletion = float64(cmd.MinCompletion)
+		if !cmd.Yes {
+			target.MaxLocales = cmd.MaxLocales
+		}
+		target.Params.FormatOptions = withFormatOptions(target.Params.FormatOptions, formatOptions)
+		target.session = session
+		target.results = results
+		target.errorReport = report
+		target.writeSlots = writeSlots
+		if target.Parallel == 0 {
+			target.Parallel = cmd.Parallel
+		}
+	}
+
+	if err := targets.FetchCompletion(client, cmd.Branch); err != nil {
+		return err
+	}
+
+	err = targets.Pull(client, unlimited, cmd.Branch, cmd.ParallelTargets)
+	if !cmd.SummaryOnly {
+		results.printLocaleTable(os.Stdout)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := session.Finish(); err != nil {
+		return err
+	}
+
+	if cmd.Manifest != "" {
+		if err := writeManifest(cmd.Manifest, cmd.ManifestAlgorithm, results.pulledFiles()); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	if commitMessage != nil {
+		if err := commitPulledFiles(commitMessage, results, cmd.Branch); err != nil {
+			return err
+		}
+	}
+	return actions.SetResults(results)
 }
 
 type PullParams struct {
@@ -70,6 +345,88 @@ type PullParams struct {
 	LocaleID string
 }
 
+// Pull pulls the targets, up to parallel of them at once. A failed target
+// doesn't stop the others, the errors of all targets are reported at the end,
+// unless --error-policy is fail-fast. The timeout applies to all targets
+// together. Targets pulled concurrently buffer their output, which is printed
+// in the order of the targets.
+func (targets Targets) Pull(client *phraseapp.Client, unlimited phraseapp.Client, branch string, parallel int) error {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	deadline := time.Now().Add(timeoutInMinutes)
+	collector := newErrorCollector(errorPolicyCollect)
+	errs := make([]error, len(targets))
+	done := make([]chan struct{}, len(targets))
+	slots := make(chan struct{}, parallel)
+	for i, target := range targets {
+		done[i] = make(chan struct{})
+		target.deadline = deadline
+		if parallel > 1 {
+			target.output = &print.Buffer{}
+		}
+	}
+
+	go func() {
+		for i, target := range targets {
+			slots <- struct{}{}
+			if collector.stopped() {
+				// fail-fast, the target is skipped
+				close(done[i])
+				<-slots
+				continue
+			}
+			go func(i int, target *Target) {
+				defer func() { <-slots }()
+				defer close(done[i])
+				targetClient, err := target.client(client, unlimited)
+				if err == nil {
+					err = target.Pull(targetClient, branch)
+				}
+				if err != nil {
+					collector.add(target.File, err)
+				}
+				errs[i] = err
+			}(i, target)
+		}
+	}()
+
+	failed := []string{}
+	for i, target := range targets {
+		<-done[i]
+		target.output.Flush()
+		if errs[i] != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", target.File, errs[i]))
+		}
+	}
+
+	switch {
+	case len(failed) == 0:
+		return nil
+	case len(targets) == 1 || collector.stopped():
+		return collector.err()
+	}
+	print.Failure("%d of %d targets failed:", len(failed), len(targets))
+	for _, failure := range failed {
+		print.Failure("  %s", failure)
+	}
+	return fmt.Errorf("pulling %d of %d targets failed", len(failed), len(targets))
+}
+
+// client returns the client used for the requests of the target. A target
+// with its own request rate gets a separate limit based on unlimited, all
+// others share the limit of client.
+func (target *Target) client(client *phraseapp.Client, unlimited phraseapp.Client) (*phraseapp.Client, error) {
+	if target.RequestsPerSecond == 0 {
+		return client, nil
+	}
+	if err := limitRequestRate(&unlimited, target.RequestsPerSecond); err != nil {
+		return nil, err
+	}
+	return &unlimited, nil
+}
+
 func (target *Target) Pull(client *phraseapp.Client, branch string) error {
 	if err := target.CheckPreconditions(); err != nil {
 		return err
@@ -80,41 +437,210 @@ func (target *Target) Pull(client *phraseapp.Client, branch string) error {
 		return err
 	}
 
-	startedAt := time.Now()
+	workers := target.Parallel
+	if workers < 1 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	errs := newErrorCollector(errorPolicyFailFast)
+
+	jobs := make(chan *LocaleFile)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for localeFile := range jobs {
+				if errs.stopped() {
+					// fail-fast, files queued after the failure are skipped
+					continue
+				}
+				if err := target.pullFile(client, localeFile, branch); err != nil {
+					errs.add(localeFile.RelPath(), err)
+				}
+			}
+		}()
+	}
+
+	deadline := target.deadline
+	if deadline.IsZero() {
+		deadline = time.Now().Add(timeoutInMinutes)
+	}
+	var timeoutErr error
 	for _, localeFile := range localeFiles {
-		if time.Since(startedAt) >= timeoutInMinutes {
-			return fmt.Errorf("Timeout of %d minutes exceeded", timeoutInMinutes)
+		if errs.stopped() {
+			break
 		}
+		if !time.Now().Before(deadline) {
+			timeoutErr = fmt.Errorf("Timeout of %d minutes exceeded", timeoutInMinutes)
+			break
+		}
+		jobs <- localeFile
+	}
+	close(jobs)
+	wg.Wait()
 
-		err := createFile(localeFile.Path)
-		if err != nil {
-			return err
+	if timeoutErr != nil {
+		return timeoutErr
+	}
+	return errs.err()
+}
+
+// pullFile downloads a single locale file of the target, unless it was
+// already downloaded in the session.
+func (target *Target) pullFile(client *phraseapp.Client, localeFile *LocaleFile, branch string) error {
+	if target.session != nil && target.session.Done(localeFile.Path) {
+		if target.verbose() {
+			target.output.Line("Skipped %s, already downloaded to %s", localeFile.Message(), localeFile.RelPath())
 		}
+		target.results.addResumed(localeFile.RelPath())
+		target.results.addSkipped()
+		target.recordLocale(localeFile, "skipped")
+		return nil
+	}
 
-		err = target.DownloadAndWriteToFile(client, localeFile, branch)
-		if err != nil {
-			return fmt.Errorf("%s for %s", err, localeFile.Path)
-		} else {
-			print.Success("Downloaded %s to %s", localeFile.Message(), localeFile.RelPath())
+	if target.NoOverwrite {
+		if _, err := os.Stat(localeFile.Path); err == nil {
+			if target.verbose() {
+				target.output.Line("Skipped %s, %s already exists", localeFile.Message(), localeFile.RelPath())
+			}
+			target.results.addSkipped()
+			target.recordLocale(localeFile, "skipped")
+			return nil
+		}
+	}
+
+	err := createFile(localeFile.Path)
+	if err != nil {
+		target.errorReport.add(localeFile, err)
+		target.recordLocale(localeFile, "error")
+		return err
+	}
+
+	err = target.DownloadAndWriteToFile(client, localeFile, branch)
+	if err != nil && target.keepsCachedCopy(localeFile, err) {
+		target.warn("Kept the existing %s, downloading %s failed: %s", localeFile.RelPath(), localeFile.Message(), err)
+		target.results.addSkipped()
+		target.recordLocale(localeFile, "skipped")
+		return nil
+	}
+	if err != nil {
+		target.errorReport.add(localeFile, err)
+		target.recordLocale(localeFile, "error")
+		return fmt.Errorf("%s for %s", err, localeFile.Path)
+	} else {
+		if target.WriteLocaleDetails {
+			if err := target.writeLocaleDetails(client, localeFile, branch); err != nil {
+				target.errorReport.add(localeFile, err)
+				target.recordLocale(localeFile, "error")
+				return fmt.Errorf("%s for the locale details of %s", err, localeFile.Path)
+			}
 		}
-		if Debug {
-			fmt.Fprintln(os.Stderr, strings.Repeat("-", 10))
+		if target.verbose() {
+			target.output.Success("Downloaded %s to %s", localeFile.Message(), localeFile.RelPath())
 		}
+		target.results.addFile(localeFile.RelPath())
+		target.results.addLocale(localeFile.Code)
+		target.recordLocale(localeFile, "written")
 	}
 
+	if target.session != nil {
+		if err := target.session.MarkDone(localeFile.Path); err != nil {
+			return err
+		}
+	}
+	if Debug {
+		fmt.Fprintln(os.Stderr, strings.Repeat("-", 10))
+	}
 	return nil
 }
 
+// keepsCachedCopy returns true if the file of localeFile is kept as is after
+// its download failed with err, with --use-cache-on-failure. Only copies
+// with content are kept, and only for transient errors.
+func (target *Target) keepsCachedCopy(localeFile *LocaleFile, err error) bool {
+	if !target.UseCacheOnFailure || !isTransientError(err) {
+		return false
+	}
+	info, statErr := os.Stat(localeFile.Path)
+	return statErr == nil && info.Mode().IsRegular() && info.Size() > 0
+}
+
+// isTransientError returns true for errors which may go away by themselves,
+// network errors, exceeded rate limits and server errors. Errors like
+// invalid credentials or missing locales are not.
+func isTransientError(err error) bool {
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+	status := httpStatus(err)
+	return status == 429 || status >= 500
+}
+
+// recordLocale counts the outcome of localeFile for the locale table of the
+// run, along with the completion of the locale if it was fetched.
+func (target *Target) recordLocale(localeFile *LocaleFile, outcome string) {
+	code := localeFile.localeLabel()
+	target.results.addLocaleOutcome(code, outcome)
+	if percent, known := target.completion[localeFile.ID]; known {
+		target.results.setLocaleCompletion(code, percent)
+	}
+}
+
+// newWriteSlots returns the slots limiting the files written at once to n,
+// nil for an unlimited number.
+func newWriteSlots(n int) chan struct{} {
+	if n <= 0 {
+		return nil
+	}
+	return make(chan struct{}, n)
+}
+
+// writeFile writes content to path once one of the write slots of the pull
+// is free, so disk writes can be limited independently of the downloads.
+func (target *Target) writeFile(path string, content []byte) error {
+	if target.writeSlots != nil {
+		target.writeSlots <- struct{}{}
+		defer func() { <-target.writeSlots }()
+	}
+	return writeFileAtomic(path, content, 0700)
+}
+
 func (target *Target) DownloadAndWriteToFile(client *phraseapp.Client, localeFile *LocaleFile, branch string) error {
+	res, err := target.download(client, localeFile, branch)
+	if err != nil {
+		return err
+	}
+
+	err = target.writeFile(localeFile.Path, res)
+	if err == nil {
+		target.results.addBytes(len(res))
+		target.results.addLocaleBytes(localeFile.localeLabel(), len(res))
+	}
+	return err
+}
+
+// download returns the content of localeFile as it would be written by a pull.
+func (target *Target) download(client *phraseapp.Client, localeFile *LocaleFile, branch string) ([]byte, error) {
 	downloadParams := &phraseapp.LocaleDownloadParams{Branch: &branch}
 	if target.Params != nil {
 		*downloadParams = target.Params.LocaleDownloadParams
 		downloadParams.Branch = &branch
 	}
 
-	if downloadParams.FileFormat == nil {
+	// targets with a tag placeholder get a file per tag with only its keys
+	if localeFile.Tag != "" && placeholders.ContainsTagPlaceholder(target.File) {
+		downloadParams.Tag = &localeFile.Tag
+		downloadParams.Tags = nil
+	}
+
+	if downloadParams.FileFormat == nil || localeFile.FileFormat != target.GetFormat() {
 		downloadParams.FileFormat = &localeFile.FileFormat
 	}
+	downloadParams.FormatOptions = target.Xliff.apply(*downloadParams.FileFormat, downloadParams.FormatOptions)
+	downloadParams.FormatOptions = target.CSV.apply(*downloadParams.FileFormat, downloadParams.FormatOptions)
+	downloadParams.FormatOptions = target.withConvertPlaceholders(downloadParams.FormatOptions)
+	downloadParams.FormatOptions = withLocalePlaceholders(downloadParams.FormatOptions, localeFile)
 
 	if Debug {
 		fmt.Fprintln(os.Stderr, "Target file pattern:", target.File)
@@ -126,24 +652,138 @@ func (target *Target) DownloadAndWriteToFile(client *phraseapp.Client, localeFil
 		fmt.Fprintln(os.Stderr, "IncludeEmptyTranslations", downloadParams.IncludeEmptyTranslations)
 		fmt.Fprintln(os.Stderr, "KeepNotranslateTags", downloadParams.KeepNotranslateTags)
 		fmt.Fprintln(os.Stderr, "Tag", downloadParams.Tag)
+		fmt.Fprintln(os.Stderr, "Branch", downloadParams.Branch)
 		fmt.Fprintln(os.Stderr, "FormatOptions", downloadParams.FormatOptions)
 	}
 
-	res, err := client.LocaleDownload(target.ProjectID, localeFile.ID, downloadParams)
+	extension := filepath.Ext(target.contentPath(localeFile.Path))
+	var fallbacks []*phraseapp.LocalePreview
+	if target.ResolveFallbacks {
+		var err error
+		if fallbacks, err = target.prepareFallbacks(client, localeFile, downloadParams, extension, branch); err != nil {
+			return nil, err
+		}
+	}
+
+	var res []byte
+	err := retryOnRateLimit(func() (err error) {
+		res, err = client.LocaleDownload(target.ProjectID, localeFile.ID, downloadParams)
+		return err
+	})
 	if err != nil {
-		if rateLimitError, ok := (err).(*phraseapp.RateLimitingError); ok {
-			waitForRateLimit(rateLimitError)
-			res, err = client.LocaleDownload(target.ProjectID, localeFile.ID, downloadParams)
-			if err != nil {
-				return err
-			}
-		} else {
-			return err
+		return nil, err
+	}
+
+	if len(fallbacks) > 0 {
+		if res, err = target.applyFallbacks(client, res, fallbacks, downloadParams, extension); err != nil {
+			return nil, err
 		}
 	}
 
-	err = ioutil.WriteFile(localeFile.Path, res, 0700)
-	return err
+	if target.DedupeKeys && dedupe.Supported(extension) {
+		var duplicates []string
+		res, duplicates, err = dedupe.Keys(extension, res, target.KeepFirstDuplicate)
+		if err != nil {
+			return nil, fmt.Errorf("can't remove duplicate keys: %s", err)
+		}
+		if len(duplicates) > 0 {
+			target.warn("Removed duplicate keys from %s: %s", localeFile.Message(), strings.Join(duplicates, ", "))
+		}
+	}
+
+	if len(target.Keys) > 0 {
+		if !keyfilter.Supported(extension) {
+			return nil, fmt.Errorf("--keys is only supported for JSON files")
+		}
+		var missing []string
+		res, missing, err = keyfilter.JSON(res, target.Keys)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range missing {
+			target.warn("Key %q not found in %s", key, localeFile.Message())
+		}
+	}
+
+	if res, err = target.CSV.selectColumns(*downloadParams.FileFormat, downloadParams.FormatOptions, res); err != nil {
+		return nil, fmt.Errorf("can't select the CSV columns of %s: %s", localeFile.RelPath(), err)
+	}
+
+	if target.EditorConfig && indent.Supported(extension) {
+		style, err := indent.FromEditorConfig(localeFile.Path)
+		if err != nil {
+			return nil, err
+		}
+		if res, err = indent.Reindent(extension, res, style); err != nil {
+			return nil, fmt.Errorf("can't indent %s: %s", localeFile.RelPath(), err)
+		}
+	}
+
+	if target.Minify {
+		if res, err = minify.Minify(extension, res); err != nil {
+			return nil, err
+		}
+	}
+
+	res = withTrailingNewline(res, target.TrailingNewline)
+
+	if target.Compress {
+		return compress(res)
+	}
+	return res, nil
+}
+
+const (
+	trailingNewlineSingle   = "single"
+	trailingNewlineNone     = "none"
+	trailingNewlinePreserve = "preserve"
+)
+
+// trailingNewlineModes are the values of the trailing_newline option of a
+// target: files end with exactly one newline, without a newline or as
+// downloaded, the default.
+var trailingNewlineModes = map[string]bool{
+	trailingNewlineSingle:   true,
+	trailingNewlineNone:     true,
+	trailingNewlinePreserve: true,
+}
+
+// withTrailingNewline returns content with the newlines at its end
+// normalized according to mode. Files with CRLF line endings end with a
+// CRLF, content of only newlines becomes empty.
+func withTrailingNewline(content []byte, mode string) []byte {
+	if mode != trailingNewlineSingle && mode != trailingNewlineNone {
+		return content
+	}
+
+	trimmed := bytes.TrimRight(content, "\r\n")
+	if mode == trailingNewlineNone || len(trimmed) == 0 {
+		return trimmed
+	}
+
+	newline := "\n"
+	if bytes.Contains(content, []byte("\r\n")) {
+		newline = "\r\n"
+	}
+	return append(trimmed[:len(trimmed):len(trimmed)], newline...)
+}
+
+// withConvertPlaceholders returns options with the convert_placeholders
+// option of the target added. The given options are left untouched, an
+// explicit convert_placeholders in format_options takes precedence.
+func (target *Target) withConvertPlaceholders(options map[string]string) map[string]string {
+	if target.ConvertPlaceholders == nil {
+		return options
+	}
+	if _, found := options["convert_placeholders"]; found {
+		return options
+	}
+
+	result := map[string]string{"convert_placeholders": fmt.Sprint(*target.ConvertPlaceholders)}
+	for key, value := range options {
+		result[key] = value
+	}
+	return result
 }
 
 func (target *Target) LocaleFiles() (LocaleFiles, error) {
@@ -155,6 +795,9 @@ func (target *Target) LocaleFiles() (LocaleFiles, error) {
 		if err != nil {
 			return nil, err
 		}
+		if !target.selected(remoteLocale) {
+			return files, nil
+		}
 
 		localeFiles, err := target.createLocaleFiles(remoteLocale)
 		if err != nil {
@@ -162,9 +805,16 @@ func (target *Target) LocaleFiles() (LocaleFiles, error) {
 		}
 
 		files = append(files, localeFiles...)
-	} else if placeholders.ContainsLocalePlaceholder(target.File) {
+	} else if target.OutputTemplate != "" || placeholders.ContainsLocalePlaceholder(target.File) || target.prefixesLocaleDir() {
 		// multiple locales were requested
-		for _, remoteLocale := range target.RemoteLocales {
+		remoteLocales := target.RemoteLocales
+		if target.LocaleFilter != nil {
+			remoteLocales = target.LocaleFilter.Select(remoteLocales)
+		}
+		for _, remoteLocale := range remoteLocales {
+			if !target.selected(remoteLocale) {
+				continue
+			}
 			localesFiles, err := target.createLocaleFiles(remoteLocale)
 			if err != nil {
 				return nil, err
@@ -177,9 +827,22 @@ func (target *Target) LocaleFiles() (LocaleFiles, error) {
 		return nil, fmt.Errorf("could not find any files on your system that matches the locales for project %q", target.ProjectID)
 	}
 
+	if target.MaxLocales > 0 && len(files) > target.MaxLocales {
+		return nil, fmt.Errorf("target %q resolves to %d locale files, more than --max-locales %d. Please check the pattern, pull them anyway with --yes or raise --max-locales", target.File, len(files), target.MaxLocales)
+	}
+
 	return files, nil
 }
 
+// selected returns true if the locale matches the locale regex of the target
+// and is complete enough.
+func (target *Target) selected(locale *phraseapp.Locale) bool {
+	if target.LocaleRegex != nil && !target.LocaleRegex.MatchString(locale.Code) {
+		return false
+	}
+	return target.completeEnough(locale)
+}
+
 func (target *Target) createLocaleFiles(remoteLocale *phraseapp.Locale) (LocaleFiles, error) {
 	files := []*LocaleFile{}
 	tags := target.GetTags()
@@ -222,15 +885,41 @@ func createLocaleFile(target *Target, remoteLocale *phraseapp.Locale, tag string
 		Path:       target.File,
 	}
 
+	if format := target.localeFormat(remoteLocale); format != "" {
+		localeFile.FileFormat = format
+	}
+
 	absPath, err := target.ReplacePlaceholders(localeFile)
 	if err != nil {
 		return nil, err
 	}
 
+	if target.prefixesLocaleDir() {
+		absPath = filepath.Join(filepath.Dir(absPath), remoteLocale.Code, filepath.Base(absPath))
+	}
+
+	if localeFile.FileFormat != target.GetFormat() {
+		absPath = target.withFormatExtension(absPath, localeFile.FileFormat)
+	}
+
+	if name := target.mappedFilename(localeFile); name != "" {
+		absPath = filepath.Join(filepath.Dir(absPath), name)
+	}
+
+	if target.Compress {
+		absPath += ".gz"
+	}
+
 	localeFile.Path = absPath
 	return localeFile, nil
 }
 
+// prefixesLocaleDir returns true if the files of the target are written to a
+// directory per locale, as the path contains no locale placeholder.
+func (target *Target) prefixesLocaleDir() bool {
+	return target.PrefixLocaleDir && target.OutputTemplate == "" && !placeholders.ContainsLocalePlaceholder(target.File)
+}
+
 func createFile(path string) error {
 	err := paths.Exists(path)
 	if err != nil {
@@ -249,3 +938,17 @@ func createFile(path string) error {
 
 	return nil
 }
+
+// compress returns content gzip compressed. The header contains no name and
+// modification time, so the same content is always compressed identically.
+func compress(content []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := gzip.NewWriter(buf)
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
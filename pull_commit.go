@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/phrase/phraseapp-client/internal/git"
+	"github.com/phrase/phraseapp-client/internal/print"
+)
+
+// commitMessageData are the fields available in the message of pull --commit.
+type commitMessageData struct {
+	// Count is the number of written files.
+	Count int
+	// Locales are the codes of the written locales, comma separated.
+	Locales string
+	Branch  string
+}
+
+func parseCommitMessage(message string) (*template.Template, error) {
+	tmpl, err := template.New("commit").Option("missingkey=error").Parse(message)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commit message: %s", err)
+	}
+	if _, err := executeCommitMessage(tmpl, commitMessageData{}); err != nil {
+		return nil, fmt.Errorf("invalid commit message: %s", err)
+	}
+	return tmpl, nil
+}
+
+func executeCommitMessage(tmpl *template.Template, data commitMessageData) (string, error) {
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// commitPulledFiles commits the files written by a pull with the message
+// rendered from tmpl. Outside of a git repository nothing is committed.
+func commitPulledFiles(tmpl *template.Template, results *runResults, branch string) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if _, err := git.Root(wd); err != nil {
+		warn("Not inside a git repository, the pulled files were not committed")
+		return nil
+	}
+
+	files := []string{}
+	for _, file := range results.Files {
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		files = append(files, abs)
+	}
+
+	locales := append([]string{}, results.Locales...)
+	sort.Strings(locales)
+	message, err := executeCommitMessage(tmpl, commitMessageData{
+		Count:   len(files),
+		Locales: strings.Join(locales, ", "),
+		Branch:  branch,
+	})
+	if err != nil {
+		return err
+	}
+	if message == "" {
+		return fmt.Errorf("the commit message is empty")
+	}
+
+	committed, err := git.Commit(wd, message, files)
+	if err != nil {
+		return err
+	}
+	if committed {
+		print.Success("Committed %d pulled files: %s", len(files), message)
+	} else {
+		fmt.Println("The pulled files have no changes, nothing was committed")
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCommitMessage(t *testing.T) {
+	tmpl, err := parseCommitMessage("Update {{.Count}} translation files ({{.Locales}})")
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	message, err := executeCommitMessage(tmpl, commitMessageData{Count: 2, Locales: "de, en"})
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if exp := "Update 2 translation files (de, en)"; message != exp {
+		t.Errorf("expected message %q, got %q", exp, message)
+	}
+
+	for _, invalid := range []string{"Update {{.Count", "Update {{.Files}}"} {
+		if _, err := parseCommitMessage(invalid); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
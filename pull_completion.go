@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+// FetchCompletion loads the completion of the remote locales of all targets
+// with a minimum completion. The details of each locale are requested once
+// per project, even if several targets pull it.
+func (targets Targets) FetchCompletion(client *phraseapp.Client, branch string) error {
+	byProject := map[string]map[string]float64{}
+	for _, target := range targets {
+		if target.MinCompletion <= 0 {
+			continue
+		}
+
+		completion, found := byProject[target.ProjectID]
+		if !found {
+			completion = map[string]float64{}
+			for _, locale := range target.RemoteLocales {
+				percent, known, err := fetchLocaleCompletion(client, target.ProjectID, locale.ID, branch)
+				if err != nil {
+					return fmt.Errorf("fetching the completion of locale %s failed: %s", locale.Code, err)
+				}
+				if known {
+					completion[locale.ID] = percent
+				}
+			}
+			byProject[target.ProjectID] = completion
+		}
+		target.completion = completion
+	}
+	return nil
+}
+
+func fetchLocaleCompletion(client *phraseapp.Client, projectID, localeID, branch string) (float64, bool, error) {
+	params := &phraseapp.LocaleShowParams{}
+	if branch != "" {
+		params.Branch = &branch
+	}
+
+	var details *phraseapp.LocaleDetails
+	err := retryOnRateLimit(func() (err error) {
+		details, err = client.LocaleShow(projectID, localeID, params)
+		return err
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	percent, known := localeCompletion(details.Statistics)
+	return percent, known, nil
+}
+
+// completeEnough returns false if the locale is translated less than the
+// minimum completion of the target and reports it as skipped. Locales of
+// unknown completion are pulled.
+func (target *Target) completeEnough(locale *phraseapp.Locale) bool {
+	if target.MinCompletion <= 0 {
+		return true
+	}
+	percent, known := target.completion[locale.ID]
+	if !known || percent >= target.MinCompletion {
+		return true
+	}
+	if target.verbose() {
+		target.output.Line("Skipped locale %s, %.1f%% translated, below the minimum of %g%%", locale.Name, percent, target.MinCompletion)
+	}
+	return false
+}
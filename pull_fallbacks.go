@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/phrase/phraseapp-client/internal/fallback"
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+// maxFallbackDepth limits the fallback chain of a locale, which also ends
+// cycles of fallback locales.
+const maxFallbackDepth = 10
+
+// fallbackLocales caches the fallback locale of each locale within a run, as
+// the locales of a chain are shared by the files of many locales.
+var fallbackLocales = &fallbackCache{entries: map[string]*phraseapp.LocalePreview{}}
+
+type fallbackCache struct {
+	mu      sync.Mutex
+	entries map[string]*phraseapp.LocalePreview
+}
+
+func (cache *fallbackCache) get(key string) (*phraseapp.LocalePreview, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	locale, ok := cache.entries[key]
+	return locale, ok
+}
+
+func (cache *fallbackCache) set(key string, locale *phraseapp.LocalePreview) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.entries[key] = locale
+}
+
+// fallbackChain returns the fallback locales of the locale, nearest first.
+func (target *Target) fallbackChain(client *phraseapp.Client, localeID, branch string) ([]*phraseapp.LocalePreview, error) {
+	chain := []*phraseapp.LocalePreview{}
+	seen := map[string]bool{localeID: true}
+	for {
+		next, err := localeFallback(client, target.ProjectID, localeID, branch)
+		if err != nil {
+			return nil, err
+		}
+		if next == nil || seen[next.ID] {
+			return chain, nil
+		}
+		if len(chain) == maxFallbackDepth {
+			return nil, fmt.Errorf("the fallback chain of locale %s is longer than %d locales", localeID, maxFallbackDepth)
+		}
+		chain = append(chain, next)
+		seen[next.ID] = true
+		localeID = next.ID
+	}
+}
+
+// localeFallback returns the fallback locale of the locale, nil if it has
+// none. The API client doesn't decode the fallback, so it's read from the
+// response.
+func localeFallback(client *phraseapp.Client, projectID, localeID, branch string) (*phraseapp.LocalePreview, error) {
+	key := fmt.Sprintf("%s/%s/%s/%s", client.Credentials.Host, projectID, branch, localeID)
+	if locale, ok := fallbackLocales.get(key); ok {
+		return locale, nil
+	}
+
+	locale, err := fetchLocaleFallback(client, projectID, localeID, branch)
+	if err != nil {
+		return nil, err
+	}
+	fallbackLocales.set(key, locale)
+	return locale, nil
+}
+
+func fetchLocaleFallback(client *phraseapp.Client, projectID, localeID, branch string) (*phraseapp.LocalePreview, error) {
+	c := *client
+	recorder := recordResponses(&c)
+
+	params := &phraseapp.LocaleShowParams{}
+	if branch != "" {
+		params.Branch = &branch
+	}
+	err := retryOnRateLimit(func() error {
+		_, err := c.LocaleShow(projectID, localeID, params)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	_, body := recorder.last()
+	response := struct {
+		FallbackLocale *phraseapp.LocalePreview `json:"fallback_locale"`
+	}{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	if response.FallbackLocale == nil || response.FallbackLocale.ID == "" {
+		return nil, nil
+	}
+	return response.FallbackLocale, nil
+}
+
+// prepareFallbacks returns the fallback locales of localeFile to merge into
+// the downloaded content. For formats which can't be merged, the server is
+// asked to resolve the nearest fallback in params instead.
+func (target *Target) prepareFallbacks(client *phraseapp.Client, localeFile *LocaleFile, params *phraseapp.LocaleDownloadParams, extension, branch string) ([]*phraseapp.LocalePreview, error) {
+	chain, err := target.fallbackChain(client, localeFile.ID, branch)
+	if err != nil || len(chain) == 0 {
+		return nil, err
+	}
+	if fallback.Supported(extension) {
+		return chain, nil
+	}
+
+	includeEmpty := true
+	params.FallbackLocaleID = &chain[0].ID
+	params.IncludeEmptyTranslations = &includeEmpty
+	return nil, nil
+}
+
+// applyFallbacks downloads the fallback locales with params and fills the
+// keys of content missing or empty with their translations.
+func (target *Target) applyFallbacks(client *phraseapp.Client, content []byte, chain []*phraseapp.LocalePreview, params *phraseapp.LocaleDownloadParams, extension string) ([]byte, error) {
+	fallbacks := [][]byte{}
+	for _, locale := range chain {
+		var res []byte
+		err := retryOnRateLimit(func() (err error) {
+			res, err = client.LocaleDownload(target.ProjectID, locale.ID, params)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("downloading fallback locale %s failed: %s", locale.Code, err)
+		}
+		fallbacks = append(fallbacks, res)
+	}
+	return fallback.Apply(extension, content, fallbacks)
+}
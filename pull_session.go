@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"sync"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+// pullSession records which files of a pull were written successfully, so an
+// interrupted pull can be resumed without downloading them again. The state is
+// stored in the temp dir, keyed by a hash of the pull configuration, one JSON
+// encoded path per line appended as files are written. It is safe for
+// concurrent use.
+type pullSession struct {
+	mu        sync.Mutex
+	path      string
+	file      *os.File
+	Completed map[string]bool
+}
+
+func pullSessionPath(config phraseapp.Config, branch string) string {
+	h := sha256.New()
+	h.Write([]byte(config.Credentials.Host))
+	h.Write([]byte(config.DefaultProjectID))
+	h.Write(config.Targets)
+	h.Write([]byte(branch))
+	key := hex.EncodeToString(h.Sum(nil))[:16]
+	return tempFilePath(".phraseapp-pull-" + key + ".json")
+}
+
+// newPullSession starts a new session. If resume is set, the state of a
+// previous session for the same configuration is loaded, otherwise it is
+// discarded.
+func newPullSession(config phraseapp.Config, branch string, resume bool) (*pullSession, error) {
+	session := &pullSession{
+		path:      pullSessionPath(config, branch),
+		Completed: map[string]bool{},
+	}
+	if !resume {
+		if err := os.Remove(session.path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		return session, nil
+	}
+
+	f, err := os.Open(session.path)
+	if os.IsNotExist(err) {
+		return session, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var path string
+		// the last line is incomplete if the pull was interrupted writing it
+		if err := json.Unmarshal(scanner.Bytes(), &path); err == nil {
+			session.Completed[path] = true
+		}
+	}
+	return session, scanner.Err()
+}
+
+// Done returns true if the file at path was written in this session and
+// still exists.
+func (session *pullSession) Done(path string) bool {
+	session.mu.Lock()
+	done := session.Completed[path]
+	session.mu.Unlock()
+	if !done {
+		return false
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// MarkDone records the file at path as written and appends it to the
+// persisted state.
+func (session *pullSession) MarkDone(path string) error {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	session.Completed[path] = true
+
+	if session.file == nil {
+		f, err := os.OpenFile(session.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return err
+		}
+		session.file = f
+	}
+
+	line, err := json.Marshal(path)
+	if err != nil {
+		return err
+	}
+	_, err = session.file.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the persisted state, which is kept to resume the pull.
+func (session *pullSession) Close() error {
+	session.mu.Lock()
+	defer session.mu.Unlock()
+	if session.file == nil {
+		return nil
+	}
+	err := session.file.Close()
+	session.file = nil
+	return err
+}
+
+// Finish removes the persisted state after a pull succeeded completely.
+func (session *pullSession) Finish() error {
+	if err := session.Close(); err != nil {
+		return err
+	}
+	err := os.Remove(session.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -3,10 +3,15 @@ package main
 import (
 	"fmt"
 	"path/filepath"
+	"regexp"
 	"strings"
+	"text/template"
+	"time"
 
+	"github.com/phrase/phraseapp-client/internal/localefilter"
 	"github.com/phrase/phraseapp-client/internal/paths"
 	"github.com/phrase/phraseapp-client/internal/placeholders"
+	"github.com/phrase/phraseapp-client/internal/print"
 	"github.com/phrase/phraseapp-client/internal/shared"
 	"github.com/phrase/phraseapp-go/phraseapp"
 	"gopkg.in/yaml.v2"
@@ -22,22 +27,167 @@ func (targets Targets) ProjectIds() []string {
 	return projectIds
 }
 
+// FetchRemoteLocales loads the remote locales of all projects referenced by
+// the targets and assigns them to the respective target.
+func (targets Targets) FetchRemoteLocales(client *phraseapp.Client, branch string) error {
+	projectIdToLocales, err := LocalesForProjects(client, targets, branch)
+	if err != nil {
+		return err
+	}
+
+	for _, target := range targets {
+		val, ok := projectIdToLocales[LocaleCacheKey{target.ProjectID, branch}]
+		if !ok || len(val) == 0 {
+			if branch != "" {
+				continue
+			}
+			return fmt.Errorf("Could not find any locales for project %q", target.ProjectID)
+		}
+		target.RemoteLocales = val
+	}
+	return nil
+}
+
 type Target struct {
 	File          string
 	ProjectID     string
+	ProjectIDs    []string
+	ProjectName   string
 	AccessToken   string
 	FileFormat    string
 	Params        *PullParams
 	RemoteLocales []*phraseapp.Locale
+
+	// TagPrefix and TagSuffix are added to the tag name substituted for the
+	// <tag> placeholder, to keep tag outputs apart from locale outputs.
+	TagPrefix string
+	TagSuffix string
+
+	// FormatOptionsPreset names format options of format_options_presets
+	// added to the params.
+	FormatOptionsPreset string
+
+	// OutputTemplate is a text/template resolving to the path of a locale
+	// file, used instead of the placeholders of File if set.
+	OutputTemplate string
+	outputTemplate *template.Template
+
+	// Parallel and RequestsPerSecond override the number of concurrent
+	// downloads and the request rate of a pull for this target, if positive.
+	Parallel          int
+	RequestsPerSecond int
+
+	// LocaleFormats maps locale codes or names to a file format used for
+	// those locales instead of the format of the target.
+	LocaleFormats map[string]string
+	// formatExtensions maps format names to their file extensions, to
+	// adjust the paths of locales with a different format.
+	formatExtensions map[string]string
+
+	// Flatten collapses placeholder derived directories into the file name.
+	Flatten bool
+	// PrefixLocaleDir writes files to a directory per locale, if the path
+	// contains no locale placeholder.
+	PrefixLocaleDir bool
+	// WriteLocaleDetails writes the details of the locale of each file, like
+	// plural forms and completion, as JSON next to the file.
+	WriteLocaleDetails bool
+	// OutputBase is prepended to relative paths of the target.
+	OutputBase string
+	// Minify removes insignificant whitespace from JSON and XML files.
+	Minify bool
+	// TrailingNewline normalizes the newlines at the end of files, see
+	// trailingNewlineModes.
+	TrailingNewline string
+	// EditorConfig indents JSON and YAML files as configured by the nearest
+	// .editorconfig.
+	EditorConfig bool
+	// NoOverwrite skips files which already exist.
+	NoOverwrite bool
+	// UseCacheOnFailure keeps existing files whose download failed with a
+	// transient error, see keepsCachedCopy.
+	UseCacheOnFailure bool
+	// ResolveFallbacks fills untranslated keys with the translations of the
+	// fallback locales of each locale.
+	ResolveFallbacks bool
+	// DedupeKeys removes duplicate keys from JSON and YAML files, keeping the
+	// last value of each key unless KeepFirstDuplicate is set.
+	DedupeKeys         bool
+	KeepFirstDuplicate bool
+	// Keys restricts the written keys of JSON files, unless empty.
+	Keys []string
+	// Compress writes files gzip compressed, with .gz appended to the path.
+	Compress bool
+	// SummaryOnly suppresses the output per file, unless in debug mode.
+	SummaryOnly bool
+	// LocaleFilter restricts the locales expanded for locale placeholders.
+	LocaleFilter *localefilter.Filter
+	// LocaleRegex restricts the pulled locales to those whose code matches,
+	// unless nil.
+	LocaleRegex *regexp.Regexp
+	// Xliff are XLIFF specific format options added to the params.
+	Xliff xliffOptions
+	// CSV are CSV specific options, see csvOptions.
+	CSV csvOptions
+	// ConvertPlaceholders sets the convert_placeholders format option, which
+	// converts placeholders to the style of the format, unless nil.
+	ConvertPlaceholders    *bool
+	rawConvertPlaceholders []byte
+	// FilenameMap maps locale codes or names to file names replacing the
+	// resolved file name of those locales, e.g. en: default.json. The names
+	// may contain placeholders.
+	FilenameMap    map[string]string
+	rawFilenameMap []byte
+	// MinCompletion skips locales translated less than this percentage, if
+	// positive.
+	MinCompletion float64
+	// MaxLocales fails LocaleFiles if the target resolves to more locale
+	// files, if positive.
+	MaxLocales int
+	// completion maps locale IDs to their percentage of translated keys,
+	// loaded with FetchCompletion.
+	completion map[string]float64
+
+	session     *pullSession
+	results     *runResults
+	errorReport *errorReport
+	// writeSlots limits the files written at once across all targets, if
+	// not nil.
+	writeSlots chan struct{}
+	// output collects the output of the target while it's pulled together
+	// with other targets, nil prints right away.
+	output *print.Buffer
+	// deadline ends the pull with a timeout, if set.
+	deadline time.Time
 }
 
 func (target *Target) CheckPreconditions() error {
+	if err := validateFormat(target.GetFormat()); err != nil {
+		return err
+	}
+
+	if target.Parallel < 0 {
+		return fmt.Errorf("parallel must not be negative, got %d", target.Parallel)
+	}
+	if target.RequestsPerSecond < 0 {
+		return fmt.Errorf("rps must not be negative, got %d", target.RequestsPerSecond)
+	}
+	if target.TrailingNewline != "" && !trailingNewlineModes[target.TrailingNewline] {
+		return fmt.Errorf("trailing_newline must be single, none or preserve, got %q", target.TrailingNewline)
+	}
+
+	if target.OutputTemplate != "" {
+		_, err := target.template()
+		return err
+	}
+
 	if err := paths.Validate(target.File, target.FileFormat, ""); err != nil {
 		return err
 	}
 
 	preconditions := []func(*Target) error{
 		containsStars,
+		containsUnsupportedPlaceholders,
 		containsDuplicatePlaceholders,
 		containsAmbiguousLocaleInformation,
 		containsInvalidTagInformation,
@@ -59,9 +209,20 @@ func containsStars(target *Target) error {
 	return nil
 }
 
+// pullPlaceholders are the placeholders supported in pull targets.
+var pullPlaceholders = []string{"locale_name", "locale_code", "tag", "project"}
+
+func containsUnsupportedPlaceholders(target *Target) error {
+	unsupported := placeholders.Unsupported(target.File, pullPlaceholders)
+	if len(unsupported) > 0 {
+		return fmt.Errorf("Unsupported placeholders in %q: %s\nSupported placeholders are <locale_name>, <locale_code>, <tag> and <project>, optionally with a :lower or :upper modifier.", target.File, strings.Join(unsupported, ", "))
+	}
+	return nil
+}
+
 func containsDuplicatePlaceholders(target *Target) error {
 	duplicatedPlaceholders := []string{}
-	for _, name := range []string{"<locale_name>", "<locale_code>", "<tag>"} {
+	for _, name := range []string{"<locale_name>", "<locale_code>", "<tag>", "<project>"} {
 		if strings.Count(target.File, name) > 1 {
 			duplicatedPlaceholders = append(duplicatedPlaceholders, name)
 		}
@@ -106,18 +267,189 @@ func (target *Target) localeForRemote() (*phraseapp.Locale, error) {
 }
 
 func (target *Target) ReplacePlaceholders(localeFile *LocaleFile) (string, error) {
-	absPath, err := filepath.Abs(target.File)
+	tmpl, err := target.template()
+	if err != nil {
+		return "", err
+	}
+	if tmpl != nil {
+		return target.templatePath(tmpl, localeFile)
+	}
+
+	absPath, err := filepath.Abs(target.withOutputBase(target.File))
 	if err != nil {
 		return "", err
 	}
 
-	path := strings.Replace(absPath, "<locale_name>", localeFile.Name, -1)
-	path = strings.Replace(path, "<locale_code>", localeFile.Code, -1)
-	path = strings.Replace(path, "<tag>", localeFile.Tag, -1)
+	path := placeholders.Replace(absPath, map[string]string{
+		"locale_name": localeFile.Name,
+		"locale_code": localeFile.Code,
+		"tag":         target.tagName(localeFile.Tag),
+		"project":     target.projectName(),
+	})
+
+	if target.Flatten {
+		path = flattenPath(absPath, path)
+	}
 
 	return path, nil
 }
 
+// withOutputBase prepends the output base to path, unless it is absolute.
+func (target *Target) withOutputBase(path string) string {
+	if target.OutputBase == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(target.OutputBase, path)
+}
+
+// flattenPath moves all directories of path that were derived from a segment
+// of pattern containing a placeholder into the file name. The values are
+// prepended to the file name in order, joined by underscores. Directories
+// without placeholders are kept, so "locales/<tag>/<locale_code>.json"
+// resolves to "locales/feature_en.json" instead of "locales/feature/en.json".
+func flattenPath(pattern, path string) string {
+	patternSegments := strings.Split(filepath.ToSlash(pattern), "/")
+	pathSegments := strings.Split(filepath.ToSlash(path), "/")
+	if len(patternSegments) != len(pathSegments) {
+		// a placeholder value contained a slash, don't guess
+		return path
+	}
+
+	last := len(pathSegments) - 1
+	dirs := []string{}
+	prefix := []string{}
+	for i, segment := range pathSegments[:last] {
+		if placeholders.ContainsAnyPlaceholders(patternSegments[i]) {
+			prefix = append(prefix, segment)
+		} else {
+			dirs = append(dirs, segment)
+		}
+	}
+
+	fileName := strings.Join(append(prefix, pathSegments[last]), "_")
+	return filepath.FromSlash(strings.Join(append(dirs, fileName), "/"))
+}
+
+// projectName returns the value substituted for the <project> placeholder,
+// the name of the project if known and its ID otherwise.
+func (target *Target) projectName() string {
+	if target.ProjectName != "" {
+		return target.ProjectName
+	}
+	return target.ProjectID
+}
+
+// FetchProjectNames loads the names of the projects of all targets using the
+// <project> placeholder.
+func (targets Targets) FetchProjectNames(client *phraseapp.Client) error {
+	names := map[string]string{}
+	for _, target := range targets {
+		if target.ProjectName != "" || (!strings.Contains(target.File, "<project>") && !strings.Contains(target.OutputTemplate, ".Project")) {
+			continue
+		}
+
+		if _, ok := names[target.ProjectID]; !ok {
+			project, err := client.ProjectShow(target.ProjectID)
+			if err != nil {
+				return err
+			}
+			names[target.ProjectID] = project.Name
+		}
+		target.ProjectName = names[target.ProjectID]
+	}
+	return nil
+}
+
+// tagName returns the value substituted for the <tag> placeholder.
+func (target *Target) tagName(tag string) string {
+	if tag == "" {
+		return ""
+	}
+	return target.TagPrefix + tag + target.TagSuffix
+}
+
+// contentPath returns path without the .gz suffix of compressed files, to
+// determine the type of the content.
+func (target *Target) contentPath(path string) string {
+	if target.Compress {
+		return strings.TrimSuffix(path, ".gz")
+	}
+	return path
+}
+
+// verbose returns true if a line per file should be printed.
+func (target *Target) verbose() bool {
+	return !target.SummaryOnly || Debug
+}
+
+// warn prints a warning with the output of the target and records it.
+func (target *Target) warn(msg string, args ...interface{}) {
+	target.output.Warning(msg, args...)
+	warnings.add(msg, args...)
+}
+
+// LoadFormatExtensions fetches the file extensions of the formats configured
+// in locale_formats, if any target uses them.
+func (targets Targets) LoadFormatExtensions(client *phraseapp.Client) error {
+	needed := false
+	for _, target := range targets {
+		needed = needed || len(target.LocaleFormats) > 0
+	}
+	if !needed {
+		return nil
+	}
+
+	formats, err := Formats(client)
+	if err != nil {
+		return err
+	}
+
+	extensions := map[string]string{}
+	for _, format := range formats {
+		extensions[format.ApiName] = format.Extension
+	}
+	for _, target := range targets {
+		target.formatExtensions = extensions
+	}
+	return nil
+}
+
+// localeFormat returns the format configured for the locale in
+// locale_formats, or an empty string if it uses the format of the target.
+func (target *Target) localeFormat(locale *phraseapp.Locale) string {
+	if format, ok := target.LocaleFormats[locale.Code]; ok {
+		return format
+	}
+	return target.LocaleFormats[locale.Name]
+}
+
+// mappedFilename returns the file name of the locale in filename_map, or an
+// empty string if it uses the resolved file name.
+func (target *Target) mappedFilename(localeFile *LocaleFile) string {
+	name, ok := target.FilenameMap[localeFile.Code]
+	if !ok {
+		name = target.FilenameMap[localeFile.Name]
+	}
+	if name == "" {
+		return ""
+	}
+	return placeholders.Replace(name, map[string]string{
+		"locale_name": localeFile.Name,
+		"locale_code": localeFile.Code,
+		"tag":         target.tagName(localeFile.Tag),
+		"project":     target.projectName(),
+	})
+}
+
+// withFormatExtension replaces the extension of path with the one of format.
+func (target *Target) withFormatExtension(path, format string) string {
+	extension := target.formatExtensions[format]
+	if extension == "" {
+		return path
+	}
+	return strings.TrimSuffix(path, filepath.Ext(path)) + "." + extension
+}
+
 func (t *Target) GetFormat() string {
 	if t.Params != nil && t.Params.FileFormat != nil {
 		return *t.Params.FileFormat
@@ -174,12 +506,34 @@ func TargetsFromConfig(config phraseapp.Config) (Targets, error) {
 		if target == nil {
 			continue
 		}
-		if target.ProjectID == "" {
-			target.ProjectID = projectId
+		target.File = translateLegacyPlaceholders(target.File)
+		if target.FileFormat == "" {
+			target.FileFormat = formatForFile(target.File)
 		}
 		if target.FileFormat == "" {
 			target.FileFormat = fileFormat
 		}
+		if target.FormatOptionsPreset != "" {
+			if target.Params.FormatOptions, err = withFormatOptionsPreset(target.FormatOptionsPreset, target.Params.FormatOptions); err != nil {
+				return nil, err
+			}
+		}
+		if target.ProjectName != "" && (target.ProjectID != "" || len(target.ProjectIDs) > 0) {
+			return nil, fmt.Errorf("target %q has project_name and project_id or project_ids, please use only one of them", target.File)
+		}
+		if len(target.ProjectIDs) > 0 {
+			if target.ProjectID != "" {
+				return nil, fmt.Errorf("target %q has both project_id and project_ids, please use only one of them", target.File)
+			}
+			validTargets = append(validTargets, target.perProject()...)
+			continue
+		}
+		if target.ProjectID == "" && target.ProjectName == "" {
+			target.ProjectID = projectId
+			if projectId == "" {
+				target.ProjectName = defaultProjectName
+			}
+		}
 		validTargets = append(validTargets, target)
 	}
 
@@ -190,19 +544,84 @@ func TargetsFromConfig(config phraseapp.Config) (Targets, error) {
 	return validTargets, nil
 }
 
+// perProject returns a copy of the target for each of its project_ids.
+func (target *Target) perProject() Targets {
+	targets := Targets{}
+	for _, projectID := range target.ProjectIDs {
+		t := *target
+		t.ProjectID = projectID
+		t.ProjectIDs = nil
+		if target.Params != nil {
+			params := *target.Params
+			t.Params = &params
+		}
+		targets = append(targets, &t)
+	}
+	return targets
+}
+
+// configKeys returns the config keys of a target mapped to its fields. The
+// values of params, locale_formats and project_ids are converted later, they
+// are stored in the given variables.
+func (tgt *Target) configKeys(params, localeFormats *map[string]interface{}, projectIDs *[]byte) map[string]interface{} {
+	return map[string]interface{}{
+		"file":            &tgt.File,
+		"project_id":      &tgt.ProjectID,
+		"project_ids":     projectIDs,
+		"project_name":    &tgt.ProjectName,
+		"access_token":    &tgt.AccessToken,
+		"file_format":     &tgt.FileFormat,
+		"tag_prefix":      &tgt.TagPrefix,
+		"tag_suffix":      &tgt.TagSuffix,
+		"output_template": &tgt.OutputTemplate,
+		"compress":        &tgt.Compress,
+		"parallel":        &tgt.Parallel,
+		"rps":             &tgt.RequestsPerSecond,
+		"locale_formats":  localeFormats,
+		"params":          params,
+
+		"format_options_preset": &tgt.FormatOptionsPreset,
+		"write_locale_details":  &tgt.WriteLocaleDetails,
+		"convert_placeholders":  &tgt.rawConvertPlaceholders,
+		"filename_map":          &tgt.rawFilenameMap,
+		"trailing_newline":      &tgt.TrailingNewline,
+	}
+}
+
 func (tgt *Target) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	m := map[string]interface{}{}
-	err := phraseapp.ParseYAMLToMap(unmarshal, map[string]interface{}{
-		"file":         &tgt.File,
-		"project_id":   &tgt.ProjectID,
-		"access_token": &tgt.AccessToken,
-		"file_format":  &tgt.FileFormat,
-		"params":       &m,
-	})
+	localeFormats := map[string]interface{}{}
+	var projectIDs []byte
+	err := phraseapp.ParseYAMLToMap(unmarshal, tgt.configKeys(&m, &localeFormats, &projectIDs))
 	if err != nil {
 		return err
 	}
 
+	if len(projectIDs) > 0 {
+		if err := yaml.Unmarshal(projectIDs, &tgt.ProjectIDs); err != nil {
+			return fmt.Errorf("project_ids must be a list of project IDs: %s", err)
+		}
+	}
+
+	if len(tgt.rawConvertPlaceholders) > 0 {
+		tgt.ConvertPlaceholders = new(bool)
+		if err := yaml.Unmarshal(tgt.rawConvertPlaceholders, tgt.ConvertPlaceholders); err != nil {
+			return fmt.Errorf("convert_placeholders must be true or false: %s", err)
+		}
+	}
+
+	if len(tgt.rawFilenameMap) > 0 {
+		if err := yaml.Unmarshal(tgt.rawFilenameMap, &tgt.FilenameMap); err != nil {
+			return fmt.Errorf("filename_map must map locale codes or names to file names: %s", err)
+		}
+	}
+
+	if len(localeFormats) > 0 {
+		if tgt.LocaleFormats, err = phraseapp.ConvertToStringMap(localeFormats); err != nil {
+			return fmt.Errorf("locale_formats: %s", err)
+		}
+	}
+
 	tgt.Params = new(PullParams)
 	if v, found := m["locale_id"]; found {
 		if tgt.Params.LocaleID, err = phraseapp.ValidateIsString("params.locale_id", v); err != nil {
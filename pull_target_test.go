@@ -137,6 +137,237 @@ func TestPlaceholderPreconditions(t *testing.T) {
 	}
 }
 
+func TestUnsupportedPlaceholderPrecondition(t *testing.T) {
+	target := getBaseTarget()
+	target.File = "./locales/<locale_cod>.yml"
+
+	err := target.CheckPreconditions()
+	if err == nil {
+		t.Fatalf("expected CheckPreconditions to fail for an unsupported placeholder")
+	}
+	if !strings.Contains(err.Error(), "<locale_cod>") || !strings.Contains(err.Error(), "<locale_code>") {
+		t.Errorf("expected error to name the unsupported and the supported placeholders, got %q", err)
+	}
+}
+
 func sPt(s string) *string {
 	return &s
 }
+
+func TestFlattenedPath(t *testing.T) {
+	localeFile := &LocaleFile{
+		Name: "english",
+		Code: "en",
+		Tag:  "feature",
+	}
+
+	tests := []struct {
+		file     string
+		expected string
+	}{
+		{"./locales/<locale_code>.json", "/locales/en.json"},
+		{"./locales/<tag>/<locale_code>.json", "/locales/feature_en.json"},
+		{"./locales/<tag>/<locale_code>/strings.json", "/locales/feature_en_strings.json"},
+		{"./<locale_name>/static/<tag>/<locale_code>.json", "/static/english_feature_en.json"},
+		{"./locales/<tag>-<locale_code>/app.json", "/locales/feature-en_app.json"},
+	}
+
+	for _, test := range tests {
+		target := getBaseTarget()
+		target.File = test.file
+		target.Flatten = true
+
+		path, err := target.ReplacePlaceholders(localeFile)
+		if err != nil {
+			t.Fatalf("didn't expect an error, got: %s", err)
+		}
+
+		if !strings.HasSuffix(path, test.expected) {
+			t.Errorf("expected flattened path of %q to end with %q, got %q", test.file, test.expected, path)
+		}
+	}
+}
+
+func TestTagPrefixedPath(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		suffix   string
+		tag      string
+		expected string
+	}{
+		{"", "", "mobile", "/locales/mobile/en.json"},
+		{"tag_", "", "mobile", "/locales/tag_mobile/en.json"},
+		{"", "_tag", "mobile", "/locales/mobile_tag/en.json"},
+		{"tag_", "_strings", "mobile", "/locales/tag_mobile_strings/en.json"},
+		{"tag_", "", "", "/locales//en.json"},
+	}
+
+	for _, test := range tests {
+		target := getBaseTarget()
+		target.File = "./locales/<tag>/<locale_code>.json"
+		target.TagPrefix = test.prefix
+		target.TagSuffix = test.suffix
+
+		path, err := target.ReplacePlaceholders(&LocaleFile{Code: "en", Tag: test.tag})
+		if err != nil {
+			t.Fatalf("didn't expect an error, got: %s", err)
+		}
+
+		if !strings.HasSuffix(path, test.expected) {
+			t.Errorf("expected path with tag %q to end with %q, got %q", test.tag, test.expected, path)
+		}
+	}
+}
+
+func TestTargetTagPrefixFromConfig(t *testing.T) {
+	config := phraseapp.Config{
+		DefaultProjectID: "project-id",
+		Targets: []byte(`targets:
+- file: ./locales/<tag>/<locale_code>.json
+  tag_prefix: tag_
+  tag_suffix: _app
+  params:
+    tags: mobile
+`),
+	}
+
+	targets, err := TargetsFromConfig(config)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	if targets[0].TagPrefix != "tag_" || targets[0].TagSuffix != "_app" {
+		t.Errorf("expected tag prefix and suffix to be read, got %q and %q", targets[0].TagPrefix, targets[0].TagSuffix)
+	}
+}
+
+func TestTargetLocaleFormatsFromConfig(t *testing.T) {
+	config := phraseapp.Config{
+		DefaultProjectID: "project-id",
+		Targets: []byte(`targets:
+- file: ./locales/<locale_code>.json
+  file_format: simple_json
+  locale_formats:
+    de: xml
+`),
+	}
+
+	targets, err := TargetsFromConfig(config)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	if targets[0].LocaleFormats["de"] != "xml" {
+		t.Errorf("expected locale format of de to be xml, got %v", targets[0].LocaleFormats)
+	}
+}
+
+func TestTargetsForMultipleProjects(t *testing.T) {
+	config := phraseapp.Config{
+		DefaultProjectID: "default-project",
+		Targets: []byte(`targets:
+- file: ./locales/<project>/<locale_code>.json
+  project_ids:
+  - app
+  - marketing
+  params:
+    file_format: simple_json
+`),
+	}
+
+	targets, err := TargetsFromConfig(config)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if len(targets) != 2 || targets[0].ProjectID != "app" || targets[1].ProjectID != "marketing" {
+		t.Fatalf("expected a target per project, got %d targets", len(targets))
+	}
+
+	targets[1].ProjectName = "Marketing Site"
+	localeFile := &LocaleFile{Code: "en"}
+	expected := []string{"/locales/app/en.json", "/locales/Marketing Site/en.json"}
+	for i, target := range targets {
+		path, err := target.ReplacePlaceholders(localeFile)
+		if err != nil {
+			t.Fatalf("didn't expect an error, got: %s", err)
+		}
+		if !strings.HasSuffix(path, expected[i]) {
+			t.Errorf("expected path to end with %q, got %q", expected[i], path)
+		}
+	}
+
+	config.Targets = []byte(`targets:
+- file: ./locales/<project>/<locale_code>.json
+  project_id: app
+  project_ids: [marketing]
+`)
+	if _, err := TargetsFromConfig(config); err == nil {
+		t.Errorf("expected an error for project_id and project_ids")
+	}
+}
+
+func TestOutputTemplateWithRegion(t *testing.T) {
+	config := phraseapp.Config{
+		DefaultProjectID:  "project-id",
+		DefaultFileFormat: "simple_json",
+		Targets: []byte(`targets:
+- output_template: "./locales/{{.Language}}{{if .Region}}/{{.Region | lower}}{{end}}/{{.Project}}.json"
+`),
+	}
+
+	targets, err := TargetsFromConfig(config)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	target := targets[0]
+	if err := target.CheckPreconditions(); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	target.ProjectName = "app"
+	target.RemoteLocales = []*phraseapp.Locale{{ID: "en-id", Code: "en"}, {ID: "pt-br-id", Code: "pt-BR"}}
+	localeFiles, err := target.LocaleFiles()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	expected := []string{"/locales/en/app.json", "/locales/pt/br/app.json"}
+	if len(localeFiles) != len(expected) {
+		t.Fatalf("expected %d files, got %d", len(expected), len(localeFiles))
+	}
+	for i, localeFile := range localeFiles {
+		if !strings.HasSuffix(localeFile.Path, expected[i]) {
+			t.Errorf("expected path to end with %q, got %q", expected[i], localeFile.Path)
+		}
+	}
+}
+
+func TestOutputTemplateInvalid(t *testing.T) {
+	for _, tmpl := range []string{"./locales/{{.Code}.json", "./locales/{{.Country}}.json"} {
+		target := getBaseTarget()
+		target.OutputTemplate = tmpl
+		if err := target.CheckPreconditions(); err == nil {
+			t.Errorf("expected an error for output_template %q", tmpl)
+		}
+	}
+}
+
+func TestRestrictToLocale(t *testing.T) {
+	placeholder := getBaseTarget()
+	fixed := getBaseTarget()
+	fixed.File = "./tests/english.yml"
+	fixed.Params.LocaleID = "en-locale-id"
+
+	targets := Targets{placeholder, fixed}
+	if locales := targets.placeholderLocales(); len(locales) != 2 {
+		t.Errorf("expected the 2 locales of the placeholder target, got %d", len(locales))
+	}
+
+	targets.restrictToLocale("de")
+	if len(placeholder.RemoteLocales) != 1 || placeholder.RemoteLocales[0].Code != "de" {
+		t.Errorf("expected only the de locale to be left, got %v", placeholder.RemoteLocales)
+	}
+	if len(fixed.RemoteLocales) != 2 {
+		t.Errorf("expected the locales of the target without placeholder to be kept, got %v", fixed.RemoteLocales)
+	}
+}
@@ -1,9 +1,25 @@
 package main
 
 import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
 	"path/filepath"
+	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/phrase/phraseapp-client/internal/localefilter"
+	"github.com/phrase/phraseapp-client/internal/ratelimit"
+	"github.com/phrase/phraseapp-go/phraseapp"
 )
 
 func TestPullLocaleFiles(t *testing.T) {
@@ -40,6 +56,20 @@ func TestPullLocaleFiles(t *testing.T) {
 	}
 }
 
+func TestPullLocaleFilesMaxLocales(t *testing.T) {
+	target := getBaseTarget()
+	target.MaxLocales = 1
+	_, err := target.LocaleFiles()
+	if err == nil || !strings.Contains(err.Error(), "resolves to 2 locale files") {
+		t.Errorf("expected an error for more locale files than the limit, got %v", err)
+	}
+
+	target.MaxLocales = 2
+	if files, err := target.LocaleFiles(); err != nil || len(files) != 2 {
+		t.Errorf("expected the locale files within the limit, got %d files and %v", len(files), err)
+	}
+}
+
 func TestResolvedPath(t *testing.T) {
 	target := getBaseTarget()
 	target.File = "./<locale_code>/<tag>/<locale_name>.yml"
@@ -61,6 +91,61 @@ func TestResolvedPath(t *testing.T) {
 	}
 }
 
+func TestPrefixLocaleDir(t *testing.T) {
+	target := getBaseTarget()
+	target.File = "./locales/messages.yml"
+
+	if _, err := target.LocaleFiles(); err == nil {
+		t.Errorf("expected an error for a path without locale placeholder")
+	}
+
+	target.PrefixLocaleDir = true
+	files, err := target.LocaleFiles()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	paths := []string{}
+	for _, file := range files {
+		paths = append(paths, file.RelPath())
+	}
+	exp := []string{"locales/en/messages.yml", "locales/de/messages.yml"}
+	if !reflect.DeepEqual(paths, exp) {
+		t.Errorf("expected paths %v, got %v", exp, paths)
+	}
+
+	// paths with a locale placeholder are unchanged
+	target.File = "./locales/<locale_code>.yml"
+	localeFile, err := createLocaleFile(target, getBaseLocales()[0], "")
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if !strings.HasSuffix(localeFile.Path, "/locales/en.yml") {
+		t.Errorf("expected the path to end with /locales/en.yml, got %s", localeFile.Path)
+	}
+}
+
+func TestOutputBase(t *testing.T) {
+	target := getBaseTarget()
+	target.OutputBase = "/data/myapp"
+
+	localeFile, err := createLocaleFile(target, getBaseLocales()[0], "")
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if exp := "/data/myapp/tests/en.yml"; localeFile.Path != exp {
+		t.Errorf("expected path %q, got %q", exp, localeFile.Path)
+	}
+
+	// absolute paths are unchanged
+	target.File = "/srv/<locale_code>.yml"
+	if localeFile, err = createLocaleFile(target, getBaseLocales()[0], ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if exp := "/srv/en.yml"; localeFile.Path != exp {
+		t.Errorf("expected path %q, got %q", exp, localeFile.Path)
+	}
+}
+
 func TestLocaleFiles__PlaceholdersWithLocaleID(t *testing.T) {
 	target := &Target{
 		File:          "./tests/<locale_code>/<tag>.yml",
@@ -131,3 +216,1315 @@ func TestLocaleFiles__PlaceholdersWithoutLocaleID(t *testing.T) {
 		t.Errorf("File path is '%s' and should end with '%s'", files[1].Path, "/tests/de/abc2.yml")
 	}
 }
+
+func TestPullLocaleFilesWithLocaleFormat(t *testing.T) {
+	target := getBaseTarget()
+	target.LocaleFormats = map[string]string{"de": "xml"}
+	target.formatExtensions = map[string]string{"yml": "yml", "xml": "xml"}
+
+	localeFiles, err := target.LocaleFiles()
+	if err != nil {
+		t.Fatalf("Should not fail with: %s", err.Error())
+	}
+
+	enPath, _ := filepath.Abs("./tests/en.yml")
+	dePath, _ := filepath.Abs("./tests/de.xml")
+	expected := map[string]*LocaleFile{
+		"en": {Path: enPath, FileFormat: "yml"},
+		"de": {Path: dePath, FileFormat: "xml"},
+	}
+
+	if len(localeFiles) != len(expected) {
+		t.Fatalf("expected %d locale files, got %d", len(expected), len(localeFiles))
+	}
+	for _, localeFile := range localeFiles {
+		want := expected[localeFile.Code]
+		if localeFile.Path != want.Path || localeFile.FileFormat != want.FileFormat {
+			t.Errorf("expected locale %s to be written as %s to %s, got %s to %s", localeFile.Code, want.FileFormat, want.Path, localeFile.FileFormat, localeFile.Path)
+		}
+	}
+}
+
+func TestPullLocaleFilesWithLocaleFilter(t *testing.T) {
+	target := getBaseTarget()
+	filter, err := localefilter.Parse("code=de")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target.LocaleFilter = filter
+
+	localeFiles, err := target.LocaleFiles()
+	if err != nil {
+		t.Fatalf("Should not fail with: %s", err.Error())
+	}
+
+	if len(localeFiles) != 1 || localeFiles[0].Code != "de" {
+		t.Errorf("expected only the de locale file, got %v", localeFiles)
+	}
+}
+
+func TestPullLocaleFilesWithLocaleRegex(t *testing.T) {
+	target := getBaseTarget()
+	target.RemoteLocales = []*phraseapp.Locale{
+		{ID: "es-id", Code: "es", Name: "spanish"},
+		{ID: "es-mx-id", Code: "es-MX", Name: "spanish (mexico)"},
+		{ID: "es-ar-id", Code: "es-AR", Name: "spanish (argentina)"},
+		{ID: "et-id", Code: "et", Name: "estonian"},
+		{ID: "en-id", Code: "en", Name: "english"},
+	}
+
+	var err error
+	if target.LocaleRegex, err = compileLocaleRegex("^es(-.*)?$"); err != nil {
+		t.Fatal(err)
+	}
+
+	localeFiles, err := target.LocaleFiles()
+	if err != nil {
+		t.Fatalf("Should not fail with: %s", err.Error())
+	}
+	codes := []string{}
+	for _, localeFile := range localeFiles {
+		codes = append(codes, localeFile.Code)
+	}
+	if exp := []string{"es", "es-MX", "es-AR"}; !reflect.DeepEqual(codes, exp) {
+		t.Errorf("expected the spanish locales %v, got %v", exp, codes)
+	}
+
+	if _, err := compileLocaleRegex("^es(-.*$"); err == nil || !strings.Contains(err.Error(), "--locale-regex") {
+		t.Errorf("expected an error for an invalid regex, got: %v", err)
+	}
+}
+
+func TestPullLocaleFilesWithFilenameMap(t *testing.T) {
+	cfg, _, err := parseConfig([]byte(`phraseapp:
+  project_id: project-id
+  file_format: json
+  pull:
+    targets:
+    - file: ./locales/<locale_code>.json
+      filename_map:
+        en: default.json
+        german: <locale_code:upper>.fallback.json
+`), "", nil)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	targets, err := TargetsFromConfig(*cfg)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	target := targets[0]
+	target.RemoteLocales = []*phraseapp.Locale{
+		{ID: "en-id", Code: "en", Name: "english"},
+		{ID: "de-id", Code: "de", Name: "german"},
+		{ID: "fr-id", Code: "fr", Name: "french"},
+	}
+
+	localeFiles, err := target.LocaleFiles()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	names := []string{}
+	for _, localeFile := range localeFiles {
+		names = append(names, filepath.ToSlash(localeFile.RelPath()))
+	}
+	if exp := []string{"locales/default.json", "locales/DE.fallback.json", "locales/fr.json"}; !reflect.DeepEqual(names, exp) {
+		t.Errorf("expected the files %v, got %v", exp, names)
+	}
+
+	cfg, _, err = parseConfig([]byte("phraseapp:\n  pull:\n    targets:\n    - file: ./<locale_code>.json\n      filename_map: [default.json]\n"), "", nil)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if _, err := TargetsFromConfig(*cfg); err == nil || !strings.Contains(err.Error(), "filename_map") {
+		t.Errorf("expected an error for a filename_map that isn't a mapping, got: %v", err)
+	}
+}
+
+func TestResolvedPathWithModifiers(t *testing.T) {
+	target := getBaseTarget()
+	target.File = "./values-<locale_code:lower>/<locale_name:upper>.xml"
+	localeFile := &LocaleFile{Name: "portuguese", Code: "pt-BR"}
+
+	newPath, err := target.ReplacePlaceholders(localeFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(newPath, "/values-pt-br/PORTUGUESE.xml") {
+		t.Errorf("Expected the new path to end with '%s' and not %s", "/values-pt-br/PORTUGUESE.xml", newPath)
+	}
+}
+
+func TestDownloadXliffOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-xliff-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var formatOptions map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := phraseapp.LocaleDownloadParams{}
+		json.NewDecoder(r.Body).Decode(&params)
+		formatOptions = params.FormatOptions
+		io.WriteString(w, "<xliff/>")
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	for _, format := range []string{"xliff_2", "yml"} {
+		target := &Target{ProjectID: "project-id", Params: &PullParams{}, Xliff: xliffOptions{States: true}}
+		target.Params.FileFormat = sPt(format)
+
+		localeFile := &LocaleFile{ID: "en-id", Path: filepath.Join(dir, "en.xlf"), FileFormat: format}
+		if err := target.DownloadAndWriteToFile(client, localeFile, ""); err != nil {
+			t.Fatalf("didn't expect an error, got: %s", err)
+		}
+
+		var exp map[string]string
+		if format == "xliff_2" {
+			exp = map[string]string{"include_translation_state": "true"}
+		}
+		if !reflect.DeepEqual(formatOptions, exp) {
+			t.Errorf("expected format options %v for %s, got %v", exp, format, formatOptions)
+		}
+	}
+}
+
+func TestDownloadConvertPlaceholders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-placeholders-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var formatOptions map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := phraseapp.LocaleDownloadParams{}
+		json.NewDecoder(r.Body).Decode(&params)
+		formatOptions = params.FormatOptions
+		io.WriteString(w, "{}")
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	cfg, _, err := parseConfig([]byte(`phraseapp:
+  project_id: project-id
+  file_format: json
+  pull:
+    targets:
+    - file: ./<locale_code>.json
+      convert_placeholders: true
+    - file: ./<locale_code>.json
+      convert_placeholders: false
+    - file: ./<locale_code>.json
+      convert_placeholders: false
+      params:
+        format_options:
+          convert_placeholders: true
+    - file: ./<locale_code>.json
+`), "", nil)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	targets, err := TargetsFromConfig(*cfg)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	for i, exp := range []map[string]string{
+		{"convert_placeholders": "true"},
+		{"convert_placeholders": "false"},
+		{"convert_placeholders": "true"},
+		nil,
+	} {
+		localeFile := &LocaleFile{ID: "en-id", Path: filepath.Join(dir, "en.json"), FileFormat: "json"}
+		if err := targets[i].DownloadAndWriteToFile(client, localeFile, ""); err != nil {
+			t.Fatalf("didn't expect an error, got: %s", err)
+		}
+		if !reflect.DeepEqual(formatOptions, exp) {
+			t.Errorf("target %d: expected format options %v, got %v", i, exp, formatOptions)
+		}
+	}
+
+	cfg, _, err = parseConfig([]byte("phraseapp:\n  pull:\n    targets:\n    - file: ./<locale_code>.json\n      convert_placeholders: maybe\n"), "", nil)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if _, err := TargetsFromConfig(*cfg); err == nil || !strings.Contains(err.Error(), "convert_placeholders") {
+		t.Errorf("expected an error for a convert_placeholders value that isn't a boolean, got: %v", err)
+	}
+}
+
+func TestDownloadTagAndBranch(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-tag-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var params phraseapp.LocaleDownloadParams
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params = phraseapp.LocaleDownloadParams{}
+		json.NewDecoder(r.Body).Decode(&params)
+		io.WriteString(w, "{}")
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	cfg, _, err := parseConfig([]byte(`phraseapp:
+  project_id: project-id
+  file_format: json
+  pull:
+    targets:
+    - file: ./<locale_code>.json
+      params:
+        tag: feature
+        include_empty_translations: true
+    - file: ./<tag>/<locale_code>.json
+      params:
+        tags: one,two
+`), "", nil)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	targets, err := TargetsFromConfig(*cfg)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	expectParams := func(target *Target, tag, expTag, expTags string) {
+		t.Helper()
+		localeFile := &LocaleFile{ID: "en-id", Path: filepath.Join(dir, "en.json"), FileFormat: "json", Tag: tag}
+		if err := target.DownloadAndWriteToFile(client, localeFile, "release"); err != nil {
+			t.Fatalf("didn't expect an error, got: %s", err)
+		}
+		if params.Branch == nil || *params.Branch != "release" {
+			t.Errorf("expected the branch release, got %v", params.Branch)
+		}
+		if params.FileFormat == nil || *params.FileFormat != "json" {
+			t.Errorf("expected the format json, got %v", params.FileFormat)
+		}
+		if tag := stringValue(params.Tag); tag != expTag {
+			t.Errorf("expected the tag %q, got %q", expTag, tag)
+		}
+		if tags := stringValue(params.Tags); tags != expTags {
+			t.Errorf("expected the tags %q, got %q", expTags, tags)
+		}
+	}
+
+	expectParams(targets[0], "feature", "feature", "")
+	if params.IncludeEmptyTranslations == nil || !*params.IncludeEmptyTranslations {
+		t.Errorf("expected include_empty_translations to be kept with tag and branch")
+	}
+	expectParams(targets[1], "one", "one", "")
+	expectParams(targets[1], "two", "two", "")
+
+	if stringValue(targets[1].Params.Tags) != "one,two" {
+		t.Errorf("expected the params of the target to be left untouched, got %+v", targets[1].Params.LocaleDownloadParams)
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func TestDownloadKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-keys-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the API has no key filter, the whole locale is returned
+		io.WriteString(w, `{"greeting": "Hello", "farewell": "Bye", "app": {"title": "App", "name": "Name"}}`)
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	target := &Target{ProjectID: "project-id", Params: &PullParams{}, Keys: []string{"greeting", "app.title"}}
+	target.Params.FileFormat = sPt("nested_json")
+
+	localeFile := &LocaleFile{ID: "en-id", Path: filepath.Join(dir, "en.json"), FileFormat: "nested_json"}
+	if err := target.DownloadAndWriteToFile(client, localeFile, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	content, err := ioutil.ReadFile(localeFile.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "{\n  \"app\": {\n    \"title\": \"App\"\n  },\n  \"greeting\": \"Hello\"\n}\n"
+	if string(content) != expected {
+		t.Errorf("expected %q, got %q", expected, content)
+	}
+
+	localeFile.Path = filepath.Join(dir, "en.yml")
+	if err := target.DownloadAndWriteToFile(client, localeFile, ""); err == nil {
+		t.Errorf("expected an error for keys of a non JSON file")
+	}
+}
+
+func TestDownloadGzipOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-gzip-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer pushd(t, dir)()
+
+	content := `{"greeting": "Hello"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, content)
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	target := getBaseTarget()
+	target.File = "./locales/<locale_code>.json"
+	target.Compress = true
+	target.Keys = []string{"greeting"}
+
+	localeFile, err := createLocaleFile(target, &phraseapp.Locale{ID: "en-id", Code: "en"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(localeFile.Path, "/locales/en.json.gz") {
+		t.Errorf("expected .gz to be appended to the path, got %q", localeFile.Path)
+	}
+
+	os.Mkdir("locales", 0700)
+	if err := target.DownloadAndWriteToFile(client, localeFile, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	f, err := os.Open(localeFile.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	r, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("expected a valid gzip file, got: %s", err)
+	}
+	decompressed, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "{\n  \"greeting\": \"Hello\"\n}\n"; string(decompressed) != expected {
+		t.Errorf("expected %q, got %q", expected, decompressed)
+	}
+}
+
+func TestTargetParallelAndRPS(t *testing.T) {
+	cfg, _, err := parseConfig([]byte(`phraseapp:
+  project_id: project-id
+  pull:
+    targets:
+    - file: ./fast/<locale_code>.json
+      parallel: 4
+      rps: 20
+    - file: ./slow/<locale_code>.json
+`), "", nil)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	targets, err := TargetsFromConfig(*cfg)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	fast, slow := targets[0], targets[1]
+	if fast.Parallel != 4 || fast.RequestsPerSecond != 20 {
+		t.Errorf("expected parallel 4 and rps 20, got %d and %d", fast.Parallel, fast.RequestsPerSecond)
+	}
+
+	unlimited := phraseapp.Client{}
+	shared := unlimited
+	if err := limitRequestRate(&shared, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := fast.client(&shared, unlimited)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if client == &shared {
+		t.Errorf("expected a separate client for a target with its own rps")
+	}
+	transport, ok := client.Transport.(*ratelimit.Transport)
+	if !ok || transport.Base != nil {
+		t.Errorf("expected the target rate limit to replace the shared one, got transport %#v", client.Transport)
+	}
+
+	if client, err := slow.client(&shared, unlimited); err != nil || client != &shared {
+		t.Errorf("expected the shared client for a target without rps, got %v, %v", client, err)
+	}
+
+	slow.RequestsPerSecond = -1
+	if err := slow.CheckPreconditions(); err == nil {
+		t.Errorf("expected an error for a negative rps")
+	}
+}
+
+func TestPullParallel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-parallel-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer pushd(t, dir)()
+
+	var mu sync.Mutex
+	active, maxActive := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		active++
+		if active > maxActive {
+			maxActive = active
+		}
+		mu.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+		io.WriteString(w, "{}")
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	target := getBaseTarget()
+	target.File = "./locales/<locale_code>.json"
+	target.FileFormat = "json"
+	target.Parallel = 2
+	target.SummaryOnly = true
+	target.results = &runResults{}
+
+	if err := target.Pull(client, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	if maxActive != 2 {
+		t.Errorf("expected 2 concurrent downloads, got %d", maxActive)
+	}
+	for _, code := range []string{"en", "de"} {
+		if _, err := os.Stat(filepath.Join("locales", code+".json")); err != nil {
+			t.Errorf("expected %s to be downloaded: %s", code, err)
+		}
+	}
+}
+
+func TestPullNoOverwrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-no-overwrite-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer pushd(t, dir)()
+
+	existing := filepath.Join("locales", "de.json")
+	os.MkdirAll("locales", 0700)
+	if err := ioutil.WriteFile(existing, []byte(`{"edited": "by hand"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		io.WriteString(w, `{"greeting": "Hello"}`)
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	target := getBaseTarget()
+	target.File = "./locales/<locale_code>.json"
+	target.FileFormat = "json"
+	target.NoOverwrite = true
+	target.results = &runResults{}
+
+	if err := target.Pull(client, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	if content, _ := ioutil.ReadFile(existing); string(content) != `{"edited": "by hand"}` {
+		t.Errorf("expected the existing file to be kept, got %q", content)
+	}
+	if content, _ := ioutil.ReadFile(filepath.Join("locales", "en.json")); string(content) != `{"greeting": "Hello"}` {
+		t.Errorf("expected the missing file to be downloaded, got %q", content)
+	}
+	if requests != 1 || target.results.Skipped != 1 {
+		t.Errorf("expected 1 download and 1 skipped file, got %d and %d", requests, target.results.Skipped)
+	}
+}
+
+func TestPullDedupeKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-dedupe-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer pushd(t, dir)()
+
+	defer func(original *warningCollector) { warnings = original }(warnings)
+	warnings = &warningCollector{}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"title": "First", "title": "Second"}`)
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	target := getBaseTarget()
+	target.File = "./locales/<locale_code>.json"
+	target.FileFormat = "json"
+	target.DedupeKeys = true
+	target.KeepFirstDuplicate = true
+	target.SummaryOnly = true
+	target.results = &runResults{}
+
+	if err := target.Pull(client, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	content, _ := ioutil.ReadFile(filepath.Join("locales", "en.json"))
+	if exp := "{\n  \"title\": \"First\"\n}\n"; string(content) != exp {
+		t.Errorf("expected %q, got %q", exp, content)
+	}
+	if messages := warnings.list(); len(messages) != 2 || !strings.Contains(messages[0], "Removed duplicate keys") {
+		t.Errorf("expected a warning per file about the removed keys, got %v", messages)
+	}
+}
+
+func TestPullResolveFallbacks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-fallbacks-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer pushd(t, dir)()
+
+	var mu sync.Mutex
+	serverFallbacks := map[string]interface{}{}
+	shown := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/projects/project-id/locales/de-locale-id":
+			mu.Lock()
+			shown["de"]++
+			mu.Unlock()
+			io.WriteString(w, `{"id": "de-locale-id", "code": "de", "fallback_locale": {"id": "en-locale-id", "code": "en", "name": "english"}}`)
+		case "/v2/projects/project-id/locales/en-locale-id":
+			mu.Lock()
+			shown["en"]++
+			mu.Unlock()
+			io.WriteString(w, `{"id": "en-locale-id", "code": "en", "fallback_locale": null}`)
+		case "/v2/projects/project-id/locales/de-locale-id/download":
+			params := map[string]interface{}{}
+			json.NewDecoder(r.Body).Decode(&params)
+			mu.Lock()
+			serverFallbacks[fmt.Sprint(params["file_format"])] = params["fallback_locale_id"]
+			mu.Unlock()
+			io.WriteString(w, `{"greeting": "Hallo"}`)
+		case "/v2/projects/project-id/locales/en-locale-id/download":
+			io.WriteString(w, `{"greeting": "Hello", "cancel": "Cancel"}`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	target := getBaseTarget()
+	target.File = "./locales/<locale_code>.json"
+	target.FileFormat = "json"
+	target.ResolveFallbacks = true
+	target.SummaryOnly = true
+	target.results = &runResults{}
+
+	if err := target.Pull(client, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	content, _ := ioutil.ReadFile(filepath.Join("locales", "de.json"))
+	if exp := "{\n  \"greeting\": \"Hallo\",\n  \"cancel\": \"Cancel\"\n}\n"; string(content) != exp {
+		t.Errorf("expected the fallback translations to be merged, got %q", content)
+	}
+	if serverFallbacks["json"] != nil {
+		t.Errorf("expected no server side fallback for JSON, got %v", serverFallbacks["json"])
+	}
+
+	target.File = "./locales/<locale_code>.strings"
+	target.FileFormat = "strings"
+	if err := target.Pull(client, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if serverFallbacks["strings"] != "en-locale-id" {
+		t.Errorf("expected the server to resolve the fallback of other formats, got %v", serverFallbacks["strings"])
+	}
+	if shown["de"] != 1 || shown["en"] != 1 {
+		t.Errorf("expected the fallback of each locale to be looked up once, got %v", shown)
+	}
+}
+
+func TestPullMinCompletion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-completion-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer pushd(t, dir)()
+
+	var mu sync.Mutex
+	detailRequests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/projects/project-id/locales/en-locale-id", "/v2/projects/project-id/locales/de-locale-id":
+			mu.Lock()
+			detailRequests++
+			mu.Unlock()
+			untranslated := 0
+			if strings.HasSuffix(r.URL.Path, "de-locale-id") {
+				untranslated = 6
+			}
+			fmt.Fprintf(w, `{"statistics": {"keys_total_count": 10, "keys_untranslated_count": %d}}`, untranslated)
+		case "/v2/projects/project-id/locales/en-locale-id/download":
+			io.WriteString(w, `{"greeting": "Hello"}`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	targets := Targets{}
+	for _, file := range []string{"./a/<locale_code>.json", "./b/<locale_code>.json"} {
+		target := getBaseTarget()
+		target.File = file
+		target.FileFormat = "json"
+		target.MinCompletion = 50
+		target.results = &runResults{}
+		targets = append(targets, target)
+	}
+
+	if err := targets.FetchCompletion(client, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if detailRequests != 2 {
+		t.Errorf("expected the details of each locale to be requested once, got %d requests", detailRequests)
+	}
+
+	if err := targets[0].Pull(client, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join("a", "en.json")); err != nil {
+		t.Errorf("expected the complete locale to be pulled, got: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join("a", "de.json")); !os.IsNotExist(err) {
+		t.Errorf("expected the locale translated to 40%% to be skipped")
+	}
+}
+
+func TestPullTargetsReportsAllErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-targets-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer pushd(t, dir)()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v2/projects/broken-id/") {
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message": "Not Found"}`)
+			return
+		}
+		io.WriteString(w, "{}")
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	targets := Targets{}
+	for _, projectID := range []string{"broken-id", "project-id", "broken-id"} {
+		target := getBaseTarget()
+		target.ProjectID = projectID
+		target.File = "./" + projectID + "/<locale_code>.json"
+		target.FileFormat = "json"
+		target.SummaryOnly = true
+		target.results = &runResults{}
+		targets = append(targets, target)
+	}
+
+	err = targets.Pull(client, *client, "", 2)
+	if err == nil || err.Error() != "pulling 2 of 3 targets failed" {
+		t.Errorf("expected the failed targets to be reported together, got: %v", err)
+	}
+	for _, code := range []string{"en", "de"} {
+		if _, err := os.Stat(filepath.Join("project-id", code+".json")); err != nil {
+			t.Errorf("expected %s to be pulled despite the failed targets: %s", code, err)
+		}
+	}
+}
+
+func TestPullWriteSlots(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-write-slots-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer pushd(t, dir)()
+
+	var mu sync.Mutex
+	downloads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		downloads++
+		mu.Unlock()
+		io.WriteString(w, `{"greeting": "Hello"}`)
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	target := getBaseTarget()
+	target.File = "./locales/<locale_code>.json"
+	target.FileFormat = "json"
+	target.Parallel = 2
+	target.SummaryOnly = true
+	target.results = &runResults{}
+	target.writeSlots = newWriteSlots(1)
+
+	// occupies the only slot, so nothing can be written until it's freed
+	target.writeSlots <- struct{}{}
+	done := make(chan error)
+	go func() { done <- target.Pull(client, "") }()
+
+	n := 0
+	for i := 0; i < 100 && n < 2; i++ {
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		n = downloads
+		mu.Unlock()
+	}
+	if n != 2 {
+		t.Fatalf("expected both files to be downloaded while writes are blocked, got %d downloads", n)
+	}
+	for _, code := range []string{"en", "de"} {
+		if content, _ := ioutil.ReadFile(filepath.Join("locales", code+".json")); len(content) != 0 {
+			t.Errorf("expected %s not to be written without a free slot, got %q", code, content)
+		}
+	}
+
+	<-target.writeSlots
+	if err := <-done; err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	for _, code := range []string{"en", "de"} {
+		if content, _ := ioutil.ReadFile(filepath.Join("locales", code+".json")); string(content) != `{"greeting": "Hello"}` {
+			t.Errorf("expected %s to be written once the slot was freed, got %q", code, content)
+		}
+	}
+}
+
+func TestWriteLocaleDetails(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-details-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer pushd(t, dir)()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/projects/project-id/locales/de-locale-id/download":
+			io.WriteString(w, `{"greeting": "Hallo"}`)
+		case "/v2/projects/project-id/locales/de-locale-id":
+			io.WriteString(w, `{"id": "de-locale-id", "code": "de", "name": "german", "rtl": false, "plural_forms": ["zero", "one", "other"],
+				"statistics": {"keys_total_count": 8, "keys_untranslated_count": 2}}`)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	target := getBaseTarget()
+	target.File = "./locales/<locale_code>.json"
+	target.FileFormat = "json"
+	target.WriteLocaleDetails = true
+	target.SummaryOnly = true
+
+	localeFile, err := createLocaleFile(target, getBaseLocales()[1], "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := target.pullFile(client, localeFile, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join("locales", "de.json.details"))
+	if err != nil {
+		t.Fatalf("expected the locale details to be written: %s", err)
+	}
+	details := &localeDetails{}
+	if err := json.Unmarshal(content, details); err != nil {
+		t.Fatal(err)
+	}
+	if details.Code != "de" || details.Completion != 75 || !reflect.DeepEqual(details.PluralForms, []string{"zero", "one", "other"}) {
+		t.Errorf("unexpected locale details %s", content)
+	}
+}
+
+func TestPullTargetsWithAccessTokens(t *testing.T) {
+	defer withLocalesCache(t, time.Minute)()
+
+	dir, err := ioutil.TempDir("", "phraseapp-tokens-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer pushd(t, dir)()
+
+	authorizations := map[string]string{"project-a": "token token-a", "project-b": "token token-b"}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		projectID := requestProjectID(r)
+		if r.Header.Get("Authorization") != authorizations[projectID] {
+			w.WriteHeader(http.StatusUnauthorized)
+			io.WriteString(w, `{"message": "Unauthorized"}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/projects/" + projectID + "/locales":
+			io.WriteString(w, `[{"id": "en-locale-id", "code": "en", "name": "english"}]`)
+		case "/v2/projects/" + projectID + "/locales/en-locale-id/download":
+			fmt.Fprintf(w, `{"project": %q}`, projectID)
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	cfg, _, err := parseConfig([]byte(`phraseapp:
+  access_token: top-level-token
+  file_format: json
+  pull:
+    targets:
+    - file: ./a/<locale_code>.json
+      project_id: project-a
+      access_token: token-a
+    - file: ./b/<locale_code>.json
+      project_id: project-b
+      access_token: token-b
+`), "", nil)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	targets, err := TargetsFromConfig(*cfg)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = cfg.Credentials.Token
+
+	tokens, err := targets.AccessTokens()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	useProjectTokens(client, tokens)
+
+	if err := targets.FetchRemoteLocales(client, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	for _, target := range targets {
+		target.results = &runResults{}
+	}
+	if err := targets.Pull(client, *client, "", 1); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	for _, projectID := range []string{"a", "b"} {
+		content, err := ioutil.ReadFile(filepath.Join(projectID, "en.json"))
+		if err != nil {
+			t.Errorf("expected the file of project %s to be pulled, got: %s", projectID, err)
+			continue
+		}
+		if exp := `{"project": "project-` + projectID + `"}`; string(content) != exp {
+			t.Errorf("expected %s, got %s", exp, content)
+		}
+	}
+
+	targets[1].ProjectID = "project-a"
+	if _, err := targets.AccessTokens(); err == nil {
+		t.Errorf("expected an error for different tokens of the same project")
+	}
+}
+
+func TestDownloadCSVOptions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-csv-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var params phraseapp.LocaleDownloadParams
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params = phraseapp.LocaleDownloadParams{}
+		json.NewDecoder(r.Body).Decode(&params)
+		io.WriteString(w, "key_name;comment;en\nhello;greeting;Hello\n")
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	cfg, _, err := parseConfig([]byte(`phraseapp:
+  project_id: project-id
+  file_format: csv
+  pull:
+    targets:
+    - file: ./<locale_code>.csv
+    - file: ./explicit/<locale_code>.csv
+      params:
+        format_options:
+          column_separator: ";"
+`), "", nil)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	targets, err := TargetsFromConfig(*cfg)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	delimiter, err := parseCSVDelimiter(";")
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	targets[0].CSV = csvOptions{Delimiter: delimiter, Columns: []string{"en", "KEY_NAME"}}
+	path := filepath.Join(dir, "en.csv")
+	if err := targets[0].DownloadAndWriteToFile(client, &LocaleFile{ID: "en-id", Path: path, FileFormat: "csv"}, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if separator := params.FormatOptions["column_separator"]; separator != ";" {
+		t.Errorf("expected the column separator %q, got %q", ";", separator)
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "en;key_name\nHello;hello\n"; string(content) != exp {
+		t.Errorf("expected the columns to be selected, got %q", content)
+	}
+
+	tab, err := parseCSVDelimiter("tab")
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	targets[1].CSV = csvOptions{Delimiter: tab}
+	if err := targets[1].DownloadAndWriteToFile(client, &LocaleFile{ID: "en-id", Path: filepath.Join(dir, "explicit.csv"), FileFormat: "csv"}, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if separator := params.FormatOptions["column_separator"]; separator != ";" {
+		t.Errorf("expected the configured column separator to take precedence, got %q", separator)
+	}
+
+	targets[0].CSV = csvOptions{Delimiter: delimiter, Columns: []string{"de"}}
+	if err := targets[0].DownloadAndWriteToFile(client, &LocaleFile{ID: "en-id", Path: path, FileFormat: "csv"}, ""); err == nil {
+		t.Errorf("expected an error for an unknown column")
+	}
+
+	if _, err := parseCSVDelimiter(";;"); err == nil {
+		t.Errorf("expected an error for a delimiter of two characters")
+	}
+}
+
+func TestWithTrailingNewline(t *testing.T) {
+	tests := []struct {
+		mode, content, exp string
+	}{
+		{"single", `{"a":"b"}`, "{\"a\":\"b\"}\n"},
+		{"single", "{\"a\":\"b\"}\n", "{\"a\":\"b\"}\n"},
+		{"single", "{\"a\":\"b\"}\n\n\n", "{\"a\":\"b\"}\n"},
+		{"single", "a=b\r\nc=d\r\n\r\n", "a=b\r\nc=d\r\n"},
+		{"none", `{"a":"b"}`, `{"a":"b"}`},
+		{"none", "{\"a\":\"b\"}\n", `{"a":"b"}`},
+		{"none", "{\"a\":\"b\"}\n\n\n", `{"a":"b"}`},
+		{"preserve", `{"a":"b"}`, `{"a":"b"}`},
+		{"preserve", "{\"a\":\"b\"}\n", "{\"a\":\"b\"}\n"},
+		{"preserve", "{\"a\":\"b\"}\n\n\n", "{\"a\":\"b\"}\n\n\n"},
+		{"", "{\"a\":\"b\"}\n\n", "{\"a\":\"b\"}\n\n"},
+		{"single", "", ""},
+	}
+	for _, tt := range tests {
+		if got := string(withTrailingNewline([]byte(tt.content), tt.mode)); got != tt.exp {
+			t.Errorf("expected %q with mode %q to become %q, got %q", tt.content, tt.mode, tt.exp, got)
+		}
+	}
+
+	target := getBaseTarget()
+	target.TrailingNewline = "always"
+	if err := target.CheckPreconditions(); err == nil {
+		t.Errorf("expected an error for an unknown trailing_newline mode")
+	}
+}
+
+func TestDownloadLocalePlaceholders(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-placeholders-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	formatOptions := map[string]map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		params := phraseapp.LocaleDownloadParams{}
+		json.NewDecoder(r.Body).Decode(&params)
+		formatOptions[path.Base(path.Dir(r.URL.Path))] = params.FormatOptions
+		io.WriteString(w, "{}")
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	target := getBaseTarget()
+	target.File = filepath.Join(dir, "<locale_code>.json")
+	target.Params.FileFormat = sPt("json")
+	target.Params.FormatOptions = map[string]string{"root_node": "<locale_code>", "nested": "true"}
+
+	files, err := target.LocaleFiles()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	for _, file := range files {
+		if err := target.DownloadAndWriteToFile(client, file, ""); err != nil {
+			t.Fatalf("didn't expect an error, got: %s", err)
+		}
+	}
+
+	exp := map[string]map[string]string{
+		"en-locale-id": {"root_node": "en", "nested": "true"},
+		"de-locale-id": {"root_node": "de", "nested": "true"},
+	}
+	if !reflect.DeepEqual(formatOptions, exp) {
+		t.Errorf("expected format options per locale %v, got %v", exp, formatOptions)
+	}
+}
+
+func TestPullFileUseCacheOnFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-cache-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(original *warningCollector) { warnings = original }(warnings)
+	warnings = &warningCollector{}
+
+	status := http.StatusServiceUnavailable
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	cached := filepath.Join(dir, "en.json")
+	if err := ioutil.WriteFile(cached, []byte(`{"hello":"Hello"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	localeFile := &LocaleFile{ID: "en-id", Code: "en", Name: "english", Path: cached, FileFormat: "json"}
+
+	target := getBaseTarget()
+	if err := target.pullFile(client, localeFile, ""); err == nil {
+		t.Errorf("expected the download to fail without --use-cache-on-failure")
+	}
+
+	target.UseCacheOnFailure = true
+	if err := target.pullFile(client, localeFile, ""); err != nil {
+		t.Errorf("expected the existing copy to be kept, got: %s", err)
+	}
+	content, err := ioutil.ReadFile(cached)
+	if err != nil || string(content) != `{"hello":"Hello"}` {
+		t.Errorf("expected the existing copy to be left untouched, got %q and %v", content, err)
+	}
+	if len(warnings.list()) != 1 {
+		t.Errorf("expected a warning about the kept copy, got %v", warnings.list())
+	}
+
+	missing := &LocaleFile{ID: "de-id", Code: "de", Path: filepath.Join(dir, "de.json"), FileFormat: "json"}
+	if err := target.pullFile(client, missing, ""); err == nil {
+		t.Errorf("expected an error without an existing copy")
+	}
+
+	status = http.StatusUnauthorized
+	if err := target.pullFile(client, localeFile, ""); err == nil {
+		t.Errorf("expected an error for invalid credentials despite the existing copy")
+	}
+}
+
+func TestPullSessionResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-session")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := setTmpDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer setTmpDir("")
+
+	cfg := phraseapp.Config{DefaultProjectID: "project-id", Targets: []byte("targets:\n- file: ./<locale_code>.json\n")}
+	session, err := newPullSession(cfg, "", false)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if filepath.Dir(session.path) != dir {
+		t.Errorf("expected the session in the configured temp dir, got %s", session.path)
+	}
+	for _, path := range []string{"/locales/en.json", "/locales/de.json"} {
+		if err := session.MarkDone(path); err != nil {
+			t.Fatalf("didn't expect an error, got: %s", err)
+		}
+	}
+	if err := session.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(session.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := "\"/locales/en.json\"\n\"/locales/de.json\"\n"; string(content) != exp {
+		t.Errorf("expected a line appended per file, got %q", content)
+	}
+
+	// a line cut off by an interrupted pull is ignored
+	if err := ioutil.WriteFile(session.path, append(content, `"/locales/f`...), 0600); err != nil {
+		t.Fatal(err)
+	}
+	resumed, err := newPullSession(cfg, "", true)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if len(resumed.Completed) != 2 || !resumed.Completed["/locales/en.json"] || !resumed.Completed["/locales/de.json"] {
+		t.Errorf("expected both files to be completed, got %v", resumed.Completed)
+	}
+
+	if _, err := newPullSession(cfg, "", false); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if _, err := os.Stat(session.path); !os.IsNotExist(err) {
+		t.Errorf("expected a new session to discard the previous one, got %v", err)
+	}
+}
+
+func TestPullFileResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-resume-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer pushd(t, dir)()
+
+	downloads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		io.WriteString(w, `{"hello":"Hello"}`)
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	session := &pullSession{path: filepath.Join(dir, "session.json"), Completed: map[string]bool{}}
+	path := filepath.Join(dir, "en.json")
+	if err := ioutil.WriteFile(path, []byte(`{"hello":"Hello"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := session.MarkDone(path); err != nil {
+		t.Fatal(err)
+	}
+
+	target := getBaseTarget()
+	target.session = session
+	target.results = &runResults{}
+	localeFile := &LocaleFile{ID: "en-id", Code: "en", Name: "english", Path: path, FileFormat: "json"}
+
+	if err := target.pullFile(client, localeFile, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if downloads != 0 {
+		t.Errorf("expected the file of the interrupted pull to be skipped")
+	}
+	if files := target.results.pulledFiles(); len(files) != 1 || files[0] != "en.json" {
+		t.Errorf("expected the skipped file to be carried forward, got %v", files)
+	}
+
+	os.Remove(path)
+	if err := target.pullFile(client, localeFile, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if downloads != 1 {
+		t.Errorf("expected the file deleted since the interrupted pull to be downloaded again")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("expected the file to be written, got: %s", err)
+	}
+}
@@ -4,14 +4,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/jpillora/backoff"
+	"github.com/phrase/phraseapp-client/internal/git"
 	"github.com/phrase/phraseapp-client/internal/paths"
 	"github.com/phrase/phraseapp-client/internal/placeholders"
 	"github.com/phrase/phraseapp-client/internal/print"
 	"github.com/phrase/phraseapp-client/internal/spinner"
+	"github.com/phrase/phraseapp-client/internal/stringz"
 	"github.com/phrase/phraseapp-go/phraseapp"
 )
 
@@ -19,35 +22,206 @@ type PushCommand struct {
 	phraseapp.Config
 	Wait   bool   `cli:"opt --wait desc='Wait for files to be processed'"`
 	Branch string `cli:"opt --branch"`
+
+	// Files can be used to push without configured sources.
+	Files       []string `cli:"arg desc='Files to upload instead of the configured sources'"`
+	ProjectID   string   `cli:"opt --project-id desc='Project to upload the files given as arguments to'"`
+	ProjectName string   `cli:"opt --project-name desc='Name of the project to upload the files given as arguments to, instead of --project-id'"`
+	FileFormat  string   `cli:"opt --file-format desc='Format of the files given as arguments'"`
+	LocaleID    string   `cli:"opt --locale-id desc='Locale of the files given as arguments without a locale placeholder'"`
+
+	MaxRetriesTotal *int `cli:"opt --max-retries-total desc='Maximum number of retries for the whole run'"`
+	GithubActions   bool `cli:"opt --github-actions desc='Report results as GitHub Actions outputs and annotations, enabled automatically in GitHub Actions'"`
+
+	RequestsPerSecond int `cli:"opt --rps desc='Maximum number of API requests per second'"`
+
+	VerboseErrors bool `cli:"opt --verbose-errors desc='Print the complete response of failed requests'"`
+
+	Tags         string `cli:"opt --tag desc='Additional tags for all uploaded keys, comma separated'"`
+	TagGitBranch bool   `cli:"opt --tag-git-branch desc='Tag all uploaded keys with the name of the current git branch'"`
+
+	SinceCommit string `cli:"opt --since-commit desc='Only upload files changed since this git commit or branch'"`
+
+	FailOnWarnings bool `cli:"opt --fail-on-warnings desc='Fail if any warnings occurred, e.g. a skipped git branch tag or a fuzzy locale match'"`
+
+	XliffStates bool `cli:"opt --xliff-states desc='Keep the state of XLIFF translation units'"`
+	XliffNotes  bool `cli:"opt --xliff-notes desc='Keep the notes of XLIFF translation units'"`
+
+	FormatOptionsJSON string `cli:"opt --format-options-json desc='Format options as JSON object, e.g. {\"enclose_in_cdata\":true}, overriding those of the config'"`
+
+	ModifiedWithin string `cli:"opt --modified-within desc='Only upload files modified within this duration, e.g. 30m or 2h'"`
+
+	CreateBranch bool   `cli:"opt --create-branch desc='Create the branch given with --branch if it does not exist yet'"`
+	BranchBase   string `cli:"opt --branch-base desc='Branch a branch created by --create-branch is based on, defaults to the branch_base config option or the main branch'"`
+
+	Yes   bool `cli:"opt --yes desc='Don’t ask for confirmation before replacing existing translations'"`
+	Force bool `cli:"opt --force desc='Upload files with fewer keys than min_keys of their source'"`
+
+	FuzzyLocaleMatch bool `cli:"opt --fuzzy-locale-match desc='Match remote locales whose name contains the locale_id of a source if none matches exactly'"`
+
+	StrictPlaceholders bool `cli:"opt --strict-placeholders desc='Fail if a placeholder of a source can’t be resolved from the path of a file'"`
+
+	LocaleRegex string `cli:"opt --locale-regex desc='Only upload files whose locale code matches this regular expression, e.g. ^es(-.*)?$'"`
+
+	FromArchive string `cli:"opt --from-archive desc='Upload the files matching the sources from this zip archive instead of the file system'"`
+
+	ChangedKeys     bool   `cli:"opt --changed-keys desc='Print the keys created or updated by each upload, found by downloading the locale before and after it. Requires --wait'"`
+	ChangedKeysFile string `cli:"opt --changed-keys-file desc='Write the keys changed by each upload as JSON to this file, implies --changed-keys'"`
+
+	TmpDir string `cli:"opt --tmp-dir desc='Directory for intermediate files, defaults to the temp dir of the system'"`
+
+	SummaryFile        string `cli:"opt --summary-file desc='Write a JSON summary of the uploaded files and created locales to this file'"`
+	CreatedLocalesFile string `cli:"opt --created-locales-file desc='Write the ID, code and name of each created locale as JSON to this file'"`
+
+	OTLPEndpoint string `cli:"opt --otlp-endpoint desc='Export metrics of the run to this OTLP/HTTP collector, e.g. http://localhost:4318'"`
 }
 
-func (cmd *PushCommand) Run() error {
+func (cmd *PushCommand) Run() (err error) {
+	if cmd.OTLPEndpoint != "" {
+		metrics = newRunMetrics()
+		defer func() {
+			if exportErr := exportMetrics(cmd.OTLPEndpoint, "push", err); exportErr != nil {
+				print.Warning("%s", exportErr)
+			}
+		}()
+	}
+
+	actions := newGithubActions(cmd.GithubActions)
+	defer func() { actions.Error(err) }()
+	defer func() {
+		if err == nil {
+			err = warnings.check(cmd.FailOnWarnings)
+		}
+	}()
+
 	if cmd.Config.Debug {
 		// suppresses content output
 		cmd.Config.Debug = false
 		Debug = true
 	}
 
+	setRetryBudget(cmd.MaxRetriesTotal)
+	cmd.Branch = branchOrDefault(cmd.Branch)
+	if cmd.BranchBase == "" {
+		cmd.BranchBase = defaultBranchBase
+	}
+	if cmd.CreateBranch && cmd.Branch == "" {
+		return fmt.Errorf("--create-branch requires a branch, given with --branch or the branch config option")
+	}
+
+	if cmd.TmpDir != "" {
+		if err := setTmpDir(cmd.TmpDir); err != nil {
+			return err
+		}
+	}
+
 	client, err := newClient(cmd.Config.Credentials, cmd.Config.Debug)
 	if err != nil {
 		return err
 	}
+	recordMetrics(client)
 
-	sources, err := SourcesFromConfig(cmd.Config)
+	if err := limitRequestRate(client, cmd.RequestsPerSecond); err != nil {
+		return err
+	}
+
+	if cmd.VerboseErrors {
+		dumpErrorResponses(client, os.Stderr)
+	}
+
+	formatOptions, err := parseFormatOptionsJSON(cmd.FormatOptionsJSON)
 	if err != nil {
 		return err
 	}
 
-	if err := sources.Validate(); err != nil {
+	sources, err := cmd.sources()
+	if err != nil {
 		return err
 	}
 
+	// refreshes the formats cache used to validate the formats of sources
 	formatMap, err := formatsByApiName(client)
 	if err != nil {
 		return fmt.Errorf("Error retrieving format list from PhraseApp: %s", err)
 	}
 
+	if err := sources.Validate(); err != nil {
+		return err
+	}
+
+	if err := sources.ResolveProjectNames(client); err != nil {
+		return err
+	}
+
+	tokens, err := sources.AccessTokens()
+	if err != nil {
+		return err
+	}
+	useProjectTokens(client, tokens)
+
+	if err := checkWriteAccess(client, tokens); err != nil {
+		return err
+	}
+
+	if cmd.ModifiedWithin != "" {
+		within, err := time.ParseDuration(cmd.ModifiedWithin)
+		if err != nil {
+			return fmt.Errorf("invalid duration for --modified-within: %s", err)
+		}
+		for _, source := range sources {
+			source.ModifiedSince = time.Now().Add(-within)
+		}
+	}
+
+	localeRegex, err := compileLocaleRegex(cmd.LocaleRegex)
+	if err != nil {
+		return err
+	}
 	for _, source := range sources {
+		source.LocaleRegex = localeRegex
+	}
+
+	var changedKeys *changedKeysReport
+	if cmd.ChangedKeys || cmd.ChangedKeysFile != "" {
+		if !cmd.Wait {
+			return fmt.Errorf("--changed-keys requires --wait, the keys can only be compared once an upload is processed")
+		}
+		changedKeys = &changedKeysReport{}
+		fmt.Println("Changed keys are found by downloading each locale before and after its upload, the upload results only count them")
+	}
+
+	if cmd.FromArchive != "" {
+		if cmd.ModifiedWithin != "" || cmd.SinceCommit != "" {
+			return fmt.Errorf("--from-archive can't be combined with --modified-within or --since-commit")
+		}
+		for _, source := range sources {
+			source.Archive = cmd.FromArchive
+			defer source.removeArchiveFiles()
+		}
+	}
+
+	tags := cmd.uploadTags()
+	for _, source := range sources {
+		source.addTags(tags)
+	}
+
+	if cmd.SinceCommit != "" {
+		changed, err := changedFilesSince(cmd.SinceCommit)
+		if err != nil {
+			return err
+		}
+		for _, source := range sources {
+			source.ChangedFiles = changed
+		}
+	}
+
+	for _, source := range sources {
+		source.FuzzyLocaleMatch = source.FuzzyLocaleMatch || cmd.FuzzyLocaleMatch
+		source.StrictPlaceholders = cmd.StrictPlaceholders
+		source.Force = cmd.Force
+		source.Xliff = xliffOptions{States: cmd.XliffStates, Notes: cmd.XliffNotes}
+		source.Params.FormatOptions = withFormatOptions(source.Params.FormatOptions, formatOptions)
+
 		formatName := source.GetFileFormat()
 		if val, ok := formatMap[formatName]; ok {
 			source.Format = val
@@ -56,45 +230,25 @@ func (cmd *PushCommand) Run() error {
 		if source.Format == nil {
 			return fmt.Errorf("Format %q of source %q is not supported by PhraseApp!", formatName, source.File)
 		}
-	}
 
-	projectsAffected := map[string]bool{}
-	for _, source := range sources {
-		projectsAffected[source.ProjectID] = true
+		if len(cmd.Files) > 0 {
+			if err := source.checkFileLocale(); err != nil {
+				return err
+			}
+		}
 	}
 
-	if cmd.Branch != "" {
-		for projectID := range projectsAffected {
-			_, err := client.BranchShow(projectID, cmd.Branch)
-			if err != nil {
-				branchPrams := &phraseapp.BranchParams{Name: &cmd.Branch}
-				branch, _ := client.BranchCreate(projectID, branchPrams)
-
-				fmt.Println()
-
-				taskResult := make(chan string, 1)
-				taskErr := make(chan error, 1)
-
-				fmt.Printf("Waiting for branch %s is created!", branch.Name)
-				spinner.While(func() {
-					branchCreateResult, err := getBranchCreateResult(client, projectID, branch)
-					taskResult <- branchCreateResult
-					taskErr <- err
-				})
-				fmt.Println()
-
-				if err := <-taskErr; err != nil {
-					return err
-				}
-
-				switch <-taskResult {
-				case "success":
-					print.Success("Successfully created branch %s", branch.Name)
-				case "error":
-					print.Failure("There was an error creating branch %s.", branch.Name)
-				}
+	if cmd.CreateBranch {
+		for _, projectID := range stringz.RemoveDuplicates(sources.ProjectIds()) {
+			if err := createBranch(client, projectID, cmd.Branch, cmd.BranchBase); err != nil {
+				return err
 			}
 		}
+	} else if err := validateBranch(client, sources.ProjectIds(), cmd.Branch); err != nil {
+		if _, ok := err.(*branchNotFoundError); ok {
+			return fmt.Errorf("%s\nUse --create-branch to create it", err)
+		}
+		return err
 	}
 
 	projectIdToLocales, err := LocalesForProjects(client, sources, cmd.Branch)
@@ -108,80 +262,246 @@ func (cmd *PushCommand) Run() error {
 		}
 	}
 
-	for _, source := range sources {
-		err := source.Push(client, cmd.Wait, cmd.Branch)
+	files, err := sources.localeFiles()
+	if err != nil {
+		return err
+	}
+
+	if !cmd.Yes {
+		confirmed, err := confirmDestructivePush(os.Stdout, sources, files, cmd.Branch)
 		if err != nil {
 			return err
 		}
+		if !confirmed {
+			return fmt.Errorf("Push aborted")
+		}
+	}
+
+	results := &runResults{}
+	errs := newErrorCollector(errorPolicyFailFast)
+	for _, source := range sources {
+		source.results = results
+		source.actions = actions
+		source.changedKeys = changedKeys
+		source.errors = errs
+		err := source.pushFiles(client, files[source], cmd.Wait, cmd.Branch)
+		if err != nil && errs.add(source.File, err) {
+			return err
+		}
+	}
+
+	if cmd.ChangedKeysFile != "" {
+		if err := changedKeys.write(cmd.ChangedKeysFile); err != nil {
+			return err
+		}
+	}
+
+	if err := cmd.writeResults(results); err != nil {
+		return err
+	}
+	if err := actions.SetResults(results); err != nil {
+		return err
+	}
+	return errs.err()
+}
+
+// writeResults writes the JSON files of --summary-file and
+// --created-locales-file, if given.
+func (cmd *PushCommand) writeResults(results *runResults) error {
+	if cmd.SummaryFile != "" {
+		if err := results.writeJSON(cmd.SummaryFile); err != nil {
+			return err
+		}
+	}
+	if cmd.CreatedLocalesFile != "" {
+		if err := results.writeCreatedLocales(cmd.CreatedLocalesFile); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// sources returns the sources from the configuration or, if files were given
+// as arguments, a source for each of them.
+func (cmd *PushCommand) sources() (Sources, error) {
+	if len(cmd.Files) == 0 {
+		return SourcesFromConfig(cmd.Config)
+	}
+
+	if len(cmd.Config.Sources) > 0 {
+		return nil, fmt.Errorf("Files given as arguments, but sources are configured as well. Please use only one of them.")
+	}
+
+	projectID, projectName := cmd.ProjectID, cmd.ProjectName
+	if projectID == "" && projectName == "" {
+		projectID = cmd.Config.DefaultProjectID
+		if projectID == "" {
+			projectName = defaultProjectName
+		}
+	}
+	if projectID == "" && projectName == "" {
+		return nil, fmt.Errorf("No project given. Please specify one using --project-id or --project-name.")
+	}
+
+	sources := Sources{}
+	for _, file := range cmd.Files {
+		fileFormat := cmd.FileFormat
+		if fileFormat == "" {
+			fileFormat = formatForFile(file)
+		}
+		if fileFormat == "" {
+			fileFormat = cmd.Config.DefaultFileFormat
+		}
+		source := &Source{
+			File:        file,
+			ProjectID:   projectID,
+			ProjectName: projectName,
+			FileFormat:  fileFormat,
+			Params:      new(phraseapp.UploadParams),
+		}
+		if fileFormat != "" {
+			source.Params.FileFormat = &source.FileFormat
+		}
+		if cmd.LocaleID != "" {
+			source.Params.LocaleID = &cmd.LocaleID
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// checkFileLocale returns an error if the locale of a file given as argument
+// is unknown, i.e. neither given by a placeholder of its path, the locale_id
+// param of its source nor the file itself.
+func (source *Source) checkFileLocale() error {
+	if source.Params.LocaleID != nil || source.Format.IncludesLocaleInformation {
+		return nil
+	}
+	if strings.Contains(source.File, "<locale_code>") || strings.Contains(source.File, "<locale_name>") {
+		return nil
+	}
+	return fmt.Errorf("The locale of %s is unknown. Please specify one using --locale-id or a <locale_code> placeholder in the path.", source.File)
+}
+
 func (source *Source) Push(client *phraseapp.Client, waitForResults bool, branch string) error {
 	localeFiles, err := source.LocaleFiles()
 	if err != nil {
 		return err
 	}
+	return source.pushFiles(client, localeFiles, waitForResults, branch)
+}
+
+// localeFiles returns the files of each source, resolved once for the preview
+// and the upload of a push.
+func (sources Sources) localeFiles() (map[*Source]LocaleFiles, error) {
+	files := map[*Source]LocaleFiles{}
+	for _, source := range sources {
+		localeFiles, err := source.LocaleFiles()
+		if err != nil {
+			return nil, err
+		}
+		files[source] = localeFiles
+	}
+	return files, nil
+}
+
+// pushFiles uploads the given files of the source.
+func (source *Source) pushFiles(client *phraseapp.Client, localeFiles LocaleFiles, waitForResults bool, branch string) error {
+	if len(localeFiles) == 0 {
+		warn("No files of source %s were modified, nothing to upload", source.File)
+	}
 
 	for _, localeFile := range localeFiles {
-		fmt.Printf("Uploading %s... ", localeFile.RelPath())
-
-		if localeFile.shouldCreateLocale(source, branch) {
-			localeDetails, err := source.createLocale(client, localeFile, branch)
-			if err == nil {
-				localeFile.ID = localeDetails.ID
-				localeFile.Code = localeDetails.Code
-				localeFile.Name = localeDetails.Name
-			} else {
-				fmt.Printf("failed to create locale: %s\n", err)
-				continue
+		if err := source.pushFile(client, localeFile, waitForResults, branch); err != nil {
+			if source.errors.add(localeFile.RelPath(), err) {
+				return err
 			}
+			fmt.Println()
+			print.Failure("Uploading %s failed: %s", localeFile.RelPath(), err)
 		}
+	}
 
-		upload, err := source.uploadFile(client, localeFile, branch)
-		if err != nil {
-			return err
-		}
+	return nil
+}
 
-		if waitForResults {
+// pushFile uploads a single locale file of the source, creating its locale
+// first if necessary.
+func (source *Source) pushFile(client *phraseapp.Client, localeFile *LocaleFile, waitForResults bool, branch string) error {
+	for _, path := range localeFile.merged {
+		fmt.Printf("Merging %s into %s\n", (&LocaleFile{Path: path}).RelPath(), localeFile.RelPath())
+	}
+	fmt.Printf("Uploading %s... ", localeFile.RelPath())
+
+	if localeFile.shouldCreateLocale(source, branch) {
+		localeDetails, err := source.createLocale(client, localeFile, branch)
+		if err == nil {
+			localeFile.ID = localeDetails.ID
+			localeFile.Code = localeDetails.Code
+			localeFile.Name = localeDetails.Name
+			source.results.addCreatedLocale(localeDetails)
+		} else {
 			fmt.Println()
+			warn("Failed to create locale for %s: %s", localeFile.RelPath(), err)
+			source.actions.Warning("Failed to create locale for %s: %s", localeFile.RelPath(), err)
+			return nil
+		}
+	}
 
-			taskResult := make(chan string, 1)
-			taskErr := make(chan error, 1)
+	before, err := source.changedKeys.snapshot(client, source.ProjectID, localeFile, branch)
+	if err != nil {
+		return fmt.Errorf("downloading the locale of %s to find the changed keys failed: %s", localeFile.RelPath(), err)
+	}
 
-			fmt.Printf("Upload ID: %s, filename: %s succeeded. Waiting for your file to be processed... ", upload.ID, upload.Filename)
-			spinner.While(func() {
-				result, err := getUploadResult(client, source.ProjectID, upload, branch)
-				taskResult <- result
-				taskErr <- err
-			})
-			fmt.Println()
+	upload, err := source.uploadFile(client, localeFile, branch)
+	if err != nil {
+		return err
+	}
+	source.results.addFile(localeFile.RelPath())
 
-			if err := <-taskErr; err != nil {
-				return err
-			}
+	if waitForResults {
+		fmt.Println()
 
-			switch <-taskResult {
-			case "success":
-				print.Success("Successfully uploaded and processed %s.", localeFile.RelPath())
-			case "error":
-				print.Failure("There was an error processing %s. Your changes were not saved online.", localeFile.RelPath())
-			}
-		} else {
-			fmt.Println("done!")
-			fmt.Printf("Check upload ID: %s, filename: %s for information about processing results.\n", upload.ID, upload.Filename)
+		taskResult := make(chan string, 1)
+		taskErr := make(chan error, 1)
+
+		fmt.Printf("Upload ID: %s, filename: %s succeeded. Waiting for your file to be processed... ", upload.ID, upload.Filename)
+		spinner.While(func() {
+			result, err := getUploadResult(client, source.ProjectID, upload.Upload, branch)
+			taskResult <- result
+			taskErr <- err
+		})
+		fmt.Println()
+
+		if err := <-taskErr; err != nil {
+			return err
 		}
 
-		if Debug {
-			fmt.Fprintln(os.Stderr, strings.Repeat("-", 10))
+		switch <-taskResult {
+		case "success":
+			print.Success("Successfully uploaded and processed %s.", localeFile.RelPath())
+			if err := source.changedKeys.add(client, source.ProjectID, localeFile, branch, before); err != nil {
+				return err
+			}
+		case "error":
+			print.Failure("There was an error processing %s. Your changes were not saved online.", localeFile.RelPath())
+			warnings.add("There was an error processing %s", localeFile.RelPath())
+			source.actions.Warning("There was an error processing %s", localeFile.RelPath())
 		}
+	} else {
+		fmt.Println("done!")
+		fmt.Printf("Check upload ID: %s, filename: %s for information about processing results.\n", upload.ID, upload.Filename)
+	}
+	printUploadWarnings(localeFile, upload)
+
+	if Debug {
+		fmt.Fprintln(os.Stderr, strings.Repeat("-", 10))
 	}
 
 	return nil
 }
 
 func formatsByApiName(client *phraseapp.Client) (map[string]*phraseapp.Format, error) {
-	formats, err := client.FormatsList(1, 25)
+	formats, err := refreshFormats(client)
 	if err != nil {
 		return nil, err
 	}
@@ -192,27 +512,164 @@ func formatsByApiName(client *phraseapp.Client) (map[string]*phraseapp.Format, e
 	return formatMap, nil
 }
 
+// currentGitBranch returns the git branch of the working directory.
+var currentGitBranch = func() (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return git.CurrentBranch(wd)
+}
+
+// uploadTags returns the tags given with --tag and, with --tag-git-branch,
+// the tag for the current git branch. Without branch the git tag is skipped.
+func (cmd *PushCommand) uploadTags() []string {
+	tags := []string{}
+	for _, tag := range strings.Split(cmd.Tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	if cmd.TagGitBranch {
+		branch, err := currentGitBranch()
+		if err != nil {
+			warn("Not tagging uploads with the git branch: %s", err)
+		} else if tag := gitBranchTag(branch); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+var invalidTagCharsRegexp = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// gitBranchTag turns a branch name like "feature/new login" into a valid tag
+// like "feature-new-login".
+func gitBranchTag(branch string) string {
+	return strings.Trim(invalidTagCharsRegexp.ReplaceAllString(branch, "-"), "-.")
+}
+
+// changedFilesSince returns the absolute paths of the files changed since the
+// git reference ref in the repository of the working directory.
+func changedFilesSince(ref string) (map[string]bool, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := git.ChangedFiles(wd, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := map[string]bool{}
+	for _, file := range files {
+		changed[file] = true
+	}
+	return changed, nil
+}
+
+// compileLocaleRegex compiles the pattern of --locale-regex, nil without
+// pattern.
+func compileLocaleRegex(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --locale-regex %q: %s", pattern, err)
+	}
+	return re, nil
+}
+
 // Return all locale files from disk that match the source pattern.
 func (source *Source) LocaleFiles() (LocaleFiles, error) {
-	filePaths, err := paths.Glob(placeholders.ToGlobbingPattern(source.File))
+	var (
+		filePaths []string
+		extracted map[string]string
+		err       error
+	)
+	if source.Archive != "" {
+		filePaths, extracted, err = source.archiveFiles()
+	} else {
+		filePaths, err = paths.Glob(placeholders.ToGlobbingPattern(source.File))
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	var allowed map[string]bool
+	if source.AllowlistFile != "" {
+		if allowed, err = readAllowlist(source.AllowlistFile); err != nil {
+			return nil, err
+		}
+	}
+
 	var localeFiles LocaleFiles
+	notAllowed := []string{}
+	skipped := 0
 	for _, path := range filePaths {
 		if paths.IsPhraseAppYmlConfig(path) {
 			continue
 		}
 
+		if !source.ModifiedSince.IsZero() {
+			stat, err := os.Stat(path)
+			if err != nil {
+				return nil, err
+			}
+			if stat.ModTime().Before(source.ModifiedSince) {
+				skipped++
+				continue
+			}
+		}
+
+		if source.ChangedFiles != nil {
+			// git reports paths with symlinks resolved
+			resolved, err := filepath.EvalSymlinks(path)
+			if err != nil {
+				return nil, err
+			}
+			abs, err := filepath.Abs(resolved)
+			if err != nil {
+				return nil, err
+			}
+			if !source.ChangedFiles[abs] {
+				skipped++
+				continue
+			}
+		}
+
 		localeFile := new(LocaleFile)
-		localeFile.fillFromPath(path, source.File)
+		if err := localeFile.fillFromPath(path, source.File); err != nil {
+			if source.StrictPlaceholders {
+				return nil, fmt.Errorf("%s: %s", path, err)
+			}
+			print.Error(err)
+		}
+		if source.NormalizeLocaleCodes && localeFile.Code != "" {
+			localeFile.Code = normalizeLocaleCode(localeFile.Code)
+		}
 
-		localeFile.Path, err = filepath.Abs(path)
-		if err != nil {
+		if extracted != nil {
+			localeFile.Path = extracted[path]
+			localeFile.archiveEntry = path
+		} else if localeFile.Path, err = filepath.Abs(path); err != nil {
 			return nil, err
 		}
 
+		if allowed != nil && !allowed[localeFile.RelPath()] {
+			notAllowed = append(notAllowed, localeFile.RelPath())
+			continue
+		}
+
+		if source.StrictPlaceholders {
+			if err := localeFile.checkPlaceholders(source.File); err != nil {
+				return nil, err
+			}
+		}
+
 		locale := source.getRemoteLocaleForLocaleFile(localeFile)
 		// TODO: sinnvoll?
 		if locale != nil {
@@ -222,6 +679,11 @@ func (source *Source) LocaleFiles() (LocaleFiles, error) {
 			localeFile.ID = locale.ID
 		}
 
+		if source.LocaleRegex != nil && !source.LocaleRegex.MatchString(localeFile.Code) {
+			skipped++
+			continue
+		}
+
 		if Debug {
 			fmt.Printf(
 				"Code:%q, Name:%q, ID:%q, Tag:%q\n",
@@ -232,6 +694,19 @@ func (source *Source) LocaleFiles() (LocaleFiles, error) {
 		localeFiles = append(localeFiles, localeFile)
 	}
 
+	if len(notAllowed) > 0 {
+		return nil, notAllowedError(source.AllowlistFile, notAllowed)
+	}
+
+	if len(localeFiles) == 0 && skipped > 0 {
+		// files exist, but none of them changed
+		return localeFiles, nil
+	}
+
+	if len(localeFiles) == 0 && source.Archive != "" {
+		return nil, fmt.Errorf("Could not find any files in the archive %s that match: '%s'", source.Archive, source.File)
+	}
+
 	if len(localeFiles) == 0 {
 		abs, err := filepath.Abs(source.File)
 		if err != nil {
@@ -240,9 +715,76 @@ func (source *Source) LocaleFiles() (LocaleFiles, error) {
 		return nil, fmt.Errorf("Could not find any files on your system that matches: '%s'", abs)
 	}
 
+	if source.Merge {
+		return mergeLocaleFiles(localeFiles), nil
+	}
 	return localeFiles, nil
 }
 
+// mergeLocaleFiles combines files resolving to the same locale and tag into
+// the first of them, so they are uploaded as one file.
+func mergeLocaleFiles(localeFiles LocaleFiles) LocaleFiles {
+	merged := LocaleFiles{}
+	byLocale := map[string]*LocaleFile{}
+	for _, localeFile := range localeFiles {
+		key := strings.Join([]string{localeFile.ID, localeFile.Code, localeFile.Name, localeFile.Tag}, "\x00")
+		if first, ok := byLocale[key]; ok {
+			first.merged = append(first.merged, localeFile.Path)
+			continue
+		}
+		byLocale[key] = localeFile
+		merged = append(merged, localeFile)
+	}
+	return merged
+}
+
+// Strategies of the locale_match source option, selecting how the locales of
+// local files are matched to remote locales. The locale_id of the params
+// matches remote locales by ID as well.
+const (
+	// localeMatchExactCode matches remote locales only by code.
+	localeMatchExactCode = "exact_code"
+	// localeMatchExactName matches remote locales only by name.
+	localeMatchExactName = "exact_name"
+	// localeMatchCodeOrName matches the code and the name of remote locales,
+	// whichever the source determines. It's the default.
+	localeMatchCodeOrName = "code_or_name"
+	// localeMatchFuzzy is code_or_name, additionally matching remote locales
+	// whose name contains the locale_id if none is named exactly like it.
+	localeMatchFuzzy = "fuzzy"
+)
+
+var localeMatchStrategies = []string{localeMatchExactCode, localeMatchExactName, localeMatchCodeOrName, localeMatchFuzzy}
+
+// localeMatchStrategy returns the locale_match of the source, fuzzy with
+// fuzzy_locale_match and code_or_name by default.
+func (source *Source) localeMatchStrategy() string {
+	switch {
+	case source.LocaleMatch != "":
+		return source.LocaleMatch
+	case source.FuzzyLocaleMatch:
+		return localeMatchFuzzy
+	}
+	return localeMatchCodeOrName
+}
+
+// matchesLocale returns true if the remote locale matches value, a locale
+// given by the params of the source, by code with exact_code and by name
+// otherwise.
+func (source *Source) matchesLocale(locale *phraseapp.Locale, value string) bool {
+	if source.localeMatchStrategy() == localeMatchExactCode {
+		return source.sameLocaleCode(locale.Code, value)
+	}
+	return locale.Name == value
+}
+
+func (source *Source) sameLocaleCode(a, b string) bool {
+	if source.NormalizeLocaleCodes {
+		return normalizeLocaleCode(a) == normalizeLocaleCode(b)
+	}
+	return a == b
+}
+
 func (source *Source) getRemoteLocaleForLocaleFile(localeFile *LocaleFile) *phraseapp.Locale {
 	candidates := source.RemoteLocales
 
@@ -262,28 +804,43 @@ func (source *Source) getRemoteLocaleForLocaleFile(localeFile *LocaleFile) *phra
 		return tmpCands
 	}
 
+	strategy := source.localeMatchStrategy()
+
 	localeName := source.replacePlaceholderInParams(localeFile)
 	if localeName != "" {
 		// This means the name can contain the value specified in LocaleID, with
 		// `<locale_code>` being substituted by the value of the currently handled
 		// localeFile (like push only locales with name `en-US`).
-		candidates = filter(candidates, localeName, func(cand *phraseapp.Locale) bool {
-			return strings.Contains(cand.Name, localeName)
+		matches := filter(candidates, localeName, func(cand *phraseapp.Locale) bool {
+			return source.matchesLocale(cand, localeName)
 		})
+		if len(matches) == 0 && strategy == localeMatchFuzzy {
+			matches = filter(candidates, localeName, func(cand *phraseapp.Locale) bool {
+				return strings.Contains(cand.Name, localeName)
+			})
+			if len(matches) > 0 {
+				warn("No remote locale is named %q, using %q as its name contains it", localeName, matches[0].Name)
+			}
+		}
+		candidates = matches
 	} else {
 		localeID := source.GetLocaleID()
 		candidates = filter(candidates, localeID, func(cand *phraseapp.Locale) bool {
-			return cand.Name == localeID || cand.ID == localeID
+			return cand.ID == localeID || source.matchesLocale(cand, localeID)
 		})
 	}
 
-	candidates = filter(candidates, localeFile.Name, func(cand *phraseapp.Locale) bool {
-		return cand.Name == localeFile.Name
-	})
+	if strategy != localeMatchExactCode {
+		candidates = filter(candidates, localeFile.Name, func(cand *phraseapp.Locale) bool {
+			return cand.Name == localeFile.Name
+		})
+	}
 
-	candidates = filter(candidates, localeFile.Code, func(cand *phraseapp.Locale) bool {
-		return cand.Code == localeFile.Code
-	})
+	if strategy != localeMatchExactName {
+		candidates = filter(candidates, localeFile.Code, func(cand *phraseapp.Locale) bool {
+			return source.sameLocaleCode(cand.Code, localeFile.Code)
+		})
+	}
 
 	// If no filter was applied the candidates list still contains all remote
 	// locales, while actually nothing matches.
@@ -297,24 +854,40 @@ func (source *Source) getRemoteLocaleForLocaleFile(localeFile *LocaleFile) *phra
 	case 1:
 		return candidates[0]
 	default:
-		// TODO I guess this should return an error, as this is a problem.
-		return candidates[0]
+		return preferredLocale(candidates, localeFile)
 	}
 }
 
-func (localeFile *LocaleFile) fillFromPath(path, pattern string) {
+// preferredLocale picks one of several matching locales, preferring an exact
+// match of the code over an exact match of the name.
+func preferredLocale(candidates []*phraseapp.Locale, localeFile *LocaleFile) *phraseapp.Locale {
+	for _, cand := range candidates {
+		if localeFile.Code != "" && cand.Code == localeFile.Code {
+			return cand
+		}
+	}
+	for _, cand := range candidates {
+		if localeFile.Name != "" && cand.Name == localeFile.Name {
+			return cand
+		}
+	}
+	return candidates[0]
+}
+
+// fillFromPath sets the locale and tag of localeFile from the placeholders
+// of pattern matched by path. Placeholders which can't be resolved are left
+// empty, the first error is returned.
+func (localeFile *LocaleFile) fillFromPath(path, pattern string) error {
 	path = filepath.ToSlash(path)
 	pathStart, patternStart, pathEnd, patternEnd, err := paths.SplitAtDirGlobOperator(path, pattern)
 	if err != nil {
-		print.Error(err)
-		return
+		return err
 	}
 
-	fillFrom := func(path, pattern string) {
+	fillFrom := func(path, pattern string) error {
 		params, err := placeholders.Resolve(path, pattern)
 		if err != nil {
-			print.Error(err)
-			return
+			return err
 		}
 
 		for placeholder, value := range params {
@@ -327,10 +900,31 @@ func (localeFile *LocaleFile) fillFromPath(path, pattern string) {
 				localeFile.Tag = value
 			}
 		}
+		return nil
 	}
 
-	fillFrom(pathStart, patternStart)
-	fillFrom(pathEnd, patternEnd)
+	errStart := fillFrom(pathStart, patternStart)
+	errEnd := fillFrom(pathEnd, patternEnd)
+	if errStart != nil {
+		return errStart
+	}
+	return errEnd
+}
+
+// checkPlaceholders returns an error if a placeholder of pattern resolved to
+// no value for localeFile.
+func (localeFile *LocaleFile) checkPlaceholders(pattern string) error {
+	values := map[string]string{
+		"locale_code": localeFile.Code,
+		"locale_name": localeFile.Name,
+		"tag":         localeFile.Tag,
+	}
+	for _, name := range []string{"locale_code", "locale_name", "tag"} {
+		if strings.Contains(pattern, "<"+name+">") && values[name] == "" {
+			return fmt.Errorf("placeholder <%s> of %q resolved to no value for %s", name, pattern, localeFile.RelPath())
+		}
+	}
+	return nil
 }
 
 func (localeFile *LocaleFile) shouldCreateLocale(source *Source, branch string) bool {
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// readAllowlist reads the allowlist_file of a source, one path relative to
+// the working directory per line. Blank lines and lines starting with # are
+// ignored.
+func readAllowlist(path string) (map[string]bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading allowlist_file failed: %s", err)
+	}
+	defer file.Close()
+
+	allowed := map[string]bool{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		allowed[filepath.Clean(filepath.FromSlash(line))] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading allowlist_file failed: %s", err)
+	}
+	return allowed, nil
+}
+
+// notAllowedError lists the files found for a source which are not on its
+// allowlist.
+func notAllowedError(allowlistFile string, paths []string) error {
+	sort.Strings(paths)
+	return fmt.Errorf("%d file(s) not on the allowlist %s, refusing to push:\n  %s", len(paths), allowlistFile, strings.Join(paths, "\n  "))
+}
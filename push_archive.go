@@ -0,0 +1,92 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/phrase/phraseapp-client/internal/paths"
+	"github.com/phrase/phraseapp-client/internal/placeholders"
+)
+
+// archiveFiles extracts the entries of the zip archive of the source which
+// match its file pattern into a temporary directory. It returns the entry
+// names, as paths with the separator of the system, mapped to their
+// extracted files. The directory is reused by further calls and removed by
+// removeArchiveFiles.
+func (source *Source) archiveFiles() ([]string, map[string]string, error) {
+	archive, err := zip.OpenReader(source.Archive)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading archive %s failed: %s", source.Archive, err)
+	}
+	defer archive.Close()
+
+	if source.archiveDir == "" {
+		if source.archiveDir, err = ioutil.TempDir(tempDirFor(""), "phraseapp-archive"); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	pattern := placeholders.ToGlobbingPattern(source.File)
+	names := []string{}
+	extracted := map[string]string{}
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		name := path.Clean(entry.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, nil, fmt.Errorf("archive %s contains the invalid path %s", source.Archive, entry.Name)
+		}
+		match, err := paths.Match(pattern, name)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !match {
+			continue
+		}
+
+		target := filepath.Join(source.archiveDir, filepath.FromSlash(name))
+		if err := extractArchiveEntry(entry, target); err != nil {
+			return nil, nil, fmt.Errorf("extracting %s from %s failed: %s", entry.Name, source.Archive, err)
+		}
+		names = append(names, filepath.FromSlash(name))
+		extracted[filepath.FromSlash(name)] = target
+	}
+	return names, extracted, nil
+}
+
+func extractArchiveEntry(entry *zip.File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		return err
+	}
+	r, err := entry.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// removeArchiveFiles removes the files extracted from the archive of the
+// source.
+func (source *Source) removeArchiveFiles() {
+	if source.archiveDir != "" {
+		os.RemoveAll(source.archiveDir)
+		source.archiveDir = ""
+	}
+}
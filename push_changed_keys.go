@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+// changedKeysMode names how changed keys are determined. The upload response
+// only counts created and updated keys, so their names are computed by
+// downloading the locale before and after each upload and comparing both.
+const changedKeysMode = "download-diff"
+
+// changedKeysReport collects the keys changed by the uploads of a push, with
+// --changed-keys. All methods may be called on a nil *changedKeysReport.
+type changedKeysReport struct {
+	mu      sync.Mutex
+	entries []changedKeysEntry
+}
+
+type changedKeysEntry struct {
+	Path    string   `json:"path"`
+	Locale  string   `json:"locale"`
+	Created []string `json:"created"`
+	Updated []string `json:"updated"`
+}
+
+// snapshot returns the translations of the locale of localeFile before its
+// upload. It returns nil if the locale isn't known yet, all keys of it count
+// as created then.
+func (report *changedKeysReport) snapshot(client *phraseapp.Client, projectID string, localeFile *LocaleFile, branch string) (map[string]string, error) {
+	if report == nil || localeFile.ID == "" {
+		return nil, nil
+	}
+	return localeTranslations(client, projectID, localeFile.ID, branch)
+}
+
+// add downloads the locale of localeFile after its upload was processed,
+// compares it to before and prints and records the changed keys.
+func (report *changedKeysReport) add(client *phraseapp.Client, projectID string, localeFile *LocaleFile, branch string, before map[string]string) error {
+	if report == nil {
+		return nil
+	}
+	if localeFile.ID == "" {
+		warn("Can't report the changed keys of %s, its locale is determined by the server", localeFile.RelPath())
+		return nil
+	}
+
+	after, err := localeTranslations(client, projectID, localeFile.ID, branch)
+	if err != nil {
+		return fmt.Errorf("downloading the locale of %s to find the changed keys failed: %s", localeFile.RelPath(), err)
+	}
+	entry := changedKeysEntry{Path: localeFile.RelPath(), Locale: localeFile.Code}
+	entry.Created, entry.Updated = diffTranslations(before, after)
+
+	switch {
+	case len(entry.Created) == 0 && len(entry.Updated) == 0:
+		fmt.Println("No keys changed")
+	default:
+		if len(entry.Created) > 0 {
+			fmt.Printf("Created keys: %s\n", strings.Join(entry.Created, ", "))
+		}
+		if len(entry.Updated) > 0 {
+			fmt.Printf("Updated keys: %s\n", strings.Join(entry.Updated, ", "))
+		}
+	}
+
+	report.mu.Lock()
+	defer report.mu.Unlock()
+	report.entries = append(report.entries, entry)
+	return nil
+}
+
+// write writes the report as JSON to path.
+func (report *changedKeysReport) write(path string) error {
+	report.mu.Lock()
+	defer report.mu.Unlock()
+
+	entries := report.entries
+	if entries == nil {
+		entries = []changedKeysEntry{}
+	}
+	content, err := json.MarshalIndent(struct {
+		Mode  string             `json:"mode"`
+		Files []changedKeysEntry `json:"files"`
+	}{changedKeysMode, entries}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(content, '\n'), 0644)
+}
+
+// localeTranslations downloads the translations of the locale as flat map of
+// key names to translations, including keys without translation.
+func localeTranslations(client *phraseapp.Client, projectID, localeID, branch string) (map[string]string, error) {
+	format, includeEmpty := "simple_json", true
+	params := &phraseapp.LocaleDownloadParams{FileFormat: &format, IncludeEmptyTranslations: &includeEmpty}
+	if branch != "" {
+		params.Branch = &branch
+	}
+
+	var content []byte
+	err := retryOnRateLimit(func() (err error) {
+		content, err = client.LocaleDownload(projectID, localeID, params)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string]interface{}{}
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, fmt.Errorf("unexpected download: %s", err)
+	}
+	translations := map[string]string{}
+	for key, value := range raw {
+		translations[key] = fmt.Sprint(value)
+	}
+	return translations, nil
+}
+
+// diffTranslations returns the sorted keys of after which are missing in
+// before, and those with a different translation.
+func diffTranslations(before, after map[string]string) (created, updated []string) {
+	created, updated = []string{}, []string{}
+	for key, translation := range after {
+		previous, found := before[key]
+		switch {
+		case !found:
+			created = append(created, key)
+		case previous != translation:
+			updated = append(updated, key)
+		}
+	}
+	sort.Strings(created)
+	sort.Strings(updated)
+	return created, updated
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/phrase/phraseapp-client/internal/keycount"
+	"github.com/phrase/phraseapp-client/internal/prompt"
+)
+
+// stdinIsTerminal reports whether the user can be asked for confirmation.
+var stdinIsTerminal = prompt.IsTerminal
+
+// pushPreview summarizes the changes of a push that can be determined
+// without uploading anything.
+type pushPreview struct {
+	Files                []string
+	LocalesCreated       []string
+	TranslationsReplaced []string
+	DescriptionsReplaced []string
+	// KeysAffected is the number of keys in the files replacing content.
+	// Files whose keys can't be counted are in UncountedFiles.
+	KeysAffected   int
+	UncountedFiles int
+}
+
+// IsDestructive returns true if the push overwrites existing content.
+func (preview *pushPreview) IsDestructive() bool {
+	return len(preview.TranslationsReplaced) > 0 || len(preview.DescriptionsReplaced) > 0
+}
+
+func (preview *pushPreview) Print(w io.Writer) {
+	fmt.Fprintf(w, "This push will upload %d file(s):\n", len(preview.Files))
+	fmt.Fprintf(w, "  locales created:                %d\n", len(preview.LocalesCreated))
+	fmt.Fprintf(w, "  files replacing translations:   %d\n", len(preview.TranslationsReplaced))
+	fmt.Fprintf(w, "  files replacing descriptions:   %d\n", len(preview.DescriptionsReplaced))
+	for _, file := range preview.TranslationsReplaced {
+		fmt.Fprintf(w, "    %s\n", file)
+	}
+	fmt.Fprintf(w, "  keys affected:                  %s\n", preview.keysAffected())
+	fmt.Fprintln(w, "The number of keys created, updated or deleted is only known once the files are processed.")
+}
+
+// keysAffected describes the number of keys whose content may be replaced.
+func (preview *pushPreview) keysAffected() string {
+	if preview.UncountedFiles > 0 {
+		return fmt.Sprintf("at least %d", preview.KeysAffected)
+	}
+	return fmt.Sprintf("%d", preview.KeysAffected)
+}
+
+// previewPush collects the changes the sources would make uploading their
+// files.
+func previewPush(sources Sources, files map[*Source]LocaleFiles, branch string) (*pushPreview, error) {
+	preview := &pushPreview{}
+	for _, source := range sources {
+		for _, localeFile := range files[source] {
+			path := localeFile.RelPath()
+			preview.Files = append(preview.Files, path)
+
+			if localeFile.shouldCreateLocale(source, branch) {
+				name := localeFile.Name
+				if name == "" {
+					name = localeFile.Code
+				}
+				preview.LocalesCreated = append(preview.LocalesCreated, name)
+			}
+			replacing := false
+			if source.Params.UpdateTranslations != nil && *source.Params.UpdateTranslations {
+				preview.TranslationsReplaced = append(preview.TranslationsReplaced, path)
+				replacing = true
+			}
+			if source.Params.UpdateDescriptions != nil && *source.Params.UpdateDescriptions {
+				preview.DescriptionsReplaced = append(preview.DescriptionsReplaced, path)
+				replacing = true
+			}
+			if replacing {
+				if err := preview.countKeys(localeFile); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return preview, nil
+}
+
+// countKeys adds the keys of localeFile to the keys affected.
+func (preview *pushPreview) countKeys(localeFile *LocaleFile) error {
+	content, err := ioutil.ReadFile(localeFile.Path)
+	if err != nil {
+		return err
+	}
+	count, ok, err := keycount.Count(filepath.Ext(localeFile.Path), content)
+	if err != nil || !ok {
+		preview.UncountedFiles++
+		return nil
+	}
+	preview.KeysAffected += count
+	return nil
+}
+
+// confirmDestructivePush shows what a push overwriting existing content will
+// change and asks the user to proceed. Pushes only adding content are not
+// confirmed. Without terminal to ask the user, e.g. in CI, the changes are
+// only shown and the push proceeds.
+func confirmDestructivePush(w io.Writer, sources Sources, files map[*Source]LocaleFiles, branch string) (bool, error) {
+	preview, err := previewPush(sources, files, branch)
+	if err != nil {
+		return false, err
+	}
+	if !preview.IsDestructive() {
+		return true, nil
+	}
+
+	preview.Print(w)
+
+	if !stdinIsTerminal() {
+		return true, nil
+	}
+
+	confirmation := ""
+	msg := fmt.Sprintf("This replaces the content of %s keys. Are you sure you want to continue? (y/n)", preview.keysAffected())
+	if err := prompt.WithDefault(msg, &confirmation, "n"); err != nil {
+		return false, err
+	}
+	return strings.ToLower(confirmation) == "y", nil
+}
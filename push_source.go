@@ -2,10 +2,19 @@ package main
 
 import (
 	"fmt"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strings"
+	"time"
 
+	"github.com/phrase/phraseapp-client/internal/charset"
+	"github.com/phrase/phraseapp-client/internal/jsonschema"
+	"github.com/phrase/phraseapp-client/internal/keycount"
+	"github.com/phrase/phraseapp-client/internal/merge"
 	"github.com/phrase/phraseapp-client/internal/paths"
+	"github.com/phrase/phraseapp-client/internal/stringz"
 	"github.com/phrase/phraseapp-go/phraseapp"
 	yaml "gopkg.in/yaml.v2"
 )
@@ -16,7 +25,8 @@ func SourcesFromConfig(config phraseapp.Config) (Sources, error) {
 	}
 
 	tmp := struct {
-		Sources Sources
+		Sources  Sources
+		Defaults map[string]interface{}
 	}{}
 	err := yaml.Unmarshal(config.Sources, &tmp)
 	if err != nil {
@@ -32,17 +42,37 @@ func SourcesFromConfig(config phraseapp.Config) (Sources, error) {
 		if source == nil {
 			continue
 		}
-		if source.ProjectID == "" {
+		source.File = translateLegacyPlaceholders(source.File)
+		if source.ProjectID != "" && source.ProjectName != "" {
+			return nil, fmt.Errorf("source %q has both project_id and project_name, please use only one of them", source.File)
+		}
+		if source.ProjectID == "" && source.ProjectName == "" {
 			source.ProjectID = projectId
+			if projectId == "" {
+				source.ProjectName = defaultProjectName
+			}
+		}
+		if len(tmp.Defaults) > 0 {
+			if err := source.applyDefaultParams(tmp.Defaults); err != nil {
+				return nil, err
+			}
 		}
 		if source.Params == nil {
 			source.Params = new(phraseapp.UploadParams)
 		}
+		if source.FormatOptionsPreset != "" {
+			if source.Params.FormatOptions, err = withFormatOptionsPreset(source.FormatOptionsPreset, source.Params.FormatOptions); err != nil {
+				return nil, err
+			}
+		}
 
 		if source.Params.FileFormat == nil {
 			switch {
 			case source.FileFormat != "":
 				source.Params.FileFormat = &source.FileFormat
+			case formatForFile(source.File) != "":
+				format := formatForFile(source.File)
+				source.Params.FileFormat = &format
 			case fileFormat != "":
 				source.Params.FileFormat = &fileFormat
 			}
@@ -71,13 +101,96 @@ func (sources Sources) Validate() error {
 type Source struct {
 	File        string
 	ProjectID   string
+	ProjectName string
 	Branch      string
 	AccessToken string
 	FileFormat  string
 	Params      *phraseapp.UploadParams
 
+	// NormalizeLocaleCodes canonicalizes locale codes like en_us to en-US.
+	NormalizeLocaleCodes bool
+	// SourceEncoding is the charset of the local files. Files are converted
+	// to UTF-8 before they are uploaded.
+	SourceEncoding string
+	// ModifiedSince skips files not modified since then, unless zero.
+	ModifiedSince time.Time
+	// ChangedFiles restricts the uploaded files to these absolute paths,
+	// unless nil.
+	ChangedFiles map[string]bool
+	// FuzzyLocaleMatch matches remote locales whose name contains the
+	// locale_id of the source, if none matches exactly. It's the fuzzy
+	// strategy of LocaleMatch, which takes precedence.
+	FuzzyLocaleMatch bool
+	// LocaleMatch is the strategy matching files to remote locales, one of
+	// exact_code, exact_name, code_or_name or fuzzy. See
+	// localeMatchStrategy for the default.
+	LocaleMatch string
+	// MinKeys refuses uploads of files with fewer keys, unless Force is set,
+	// to protect against uploading accidentally emptied files. The check is
+	// opt-in, it's disabled without min_keys.
+	MinKeys int
+	Force   bool
+	// Merge uploads all files resolving to the same locale and tag as one
+	// file, merging their keys.
+	Merge bool
+	// StrictPlaceholders fails if a placeholder of File can't be resolved
+	// from the path of a file, instead of leaving its value empty.
+	StrictPlaceholders bool
+	// Xliff are XLIFF specific format options added to the params.
+	Xliff xliffOptions
+	// FormatOptionsPreset names format options of format_options_presets
+	// added to the params.
+	FormatOptionsPreset string
+	// PreUploadCommand is run for each file, its output is uploaded instead
+	// of the file.
+	PreUploadCommand string
+	// AllowlistFile lists the only files which may be pushed, finding any
+	// other file fails the push.
+	AllowlistFile string
+	// LocaleRegex restricts the uploaded files to those whose locale code
+	// matches, unless nil.
+	LocaleRegex *regexp.Regexp
+	// Archive is a zip archive whose entries matching File are pushed
+	// instead of files on disk.
+	Archive    string
+	archiveDir string
+	// Schema is a JSON Schema JSON files are validated against before they
+	// are uploaded.
+	Schema string
+	schema *jsonschema.Schema
+
 	RemoteLocales []*phraseapp.Locale
 	Format        *phraseapp.Format
+
+	rawParams   map[string]interface{}
+	results     *runResults
+	actions     *githubActions
+	changedKeys *changedKeysReport
+	errors      *errorCollector
+}
+
+// addTags adds tags to the tags of all uploads of the source, skipping tags
+// which are already set.
+func (source *Source) addTags(tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+	if source.Params == nil {
+		source.Params = new(phraseapp.UploadParams)
+	}
+
+	all := []string{}
+	if source.Params.Tags != nil && *source.Params.Tags != "" {
+		all = strings.Split(*source.Params.Tags, ",")
+	}
+	for _, tag := range tags {
+		if !stringz.Contains(all, tag) {
+			all = append(all, tag)
+		}
+	}
+
+	joined := strings.Join(all, ",")
+	source.Params.Tags = &joined
 }
 
 func (source *Source) GetLocaleID() string {
@@ -102,6 +215,10 @@ func (source *Source) CheckPreconditions() error {
 		return err
 	}
 
+	if err := validateFormat(source.GetFileFormat()); err != nil {
+		return err
+	}
+
 	duplicatedPlaceholders := []string{}
 	for _, name := range []string{"<locale_name>", "<locale_code>", "<tag>"} {
 		if strings.Count(source.File, name) > 1 {
@@ -126,26 +243,82 @@ func (source *Source) CheckPreconditions() error {
 		return fmt.Errorf(fmt.Sprintf("%s can only occur once in a file pattern!", dups))
 	}
 
+	if source.SourceEncoding != "" && !charset.Supported(source.SourceEncoding) {
+		return fmt.Errorf("source_encoding %q of source %q is not supported", source.SourceEncoding, source.File)
+	}
+
+	if source.Merge && !merge.Supported(filepath.Ext(source.File)) {
+		return fmt.Errorf("merge of source %q is only supported for JSON and YAML files", source.File)
+	}
+
+	if source.LocaleMatch != "" && !stringz.Contains(localeMatchStrategies, source.LocaleMatch) {
+		return fmt.Errorf("unsupported locale_match %q of source %q, use one of %s", source.LocaleMatch, source.File, strings.Join(localeMatchStrategies, ", "))
+	}
+
+	if source.Schema != "" && source.schema == nil {
+		schema, err := jsonschema.Load(source.Schema)
+		if err != nil {
+			return fmt.Errorf("schema of source %q: %s", source.File, err)
+		}
+		source.schema = schema
+	}
+
 	return nil
 }
 
-func (src *Source) UnmarshalYAML(unmarshal func(interface{}) error) error {
-	m := map[string]interface{}{}
-	err := phraseapp.ParseYAMLToMap(unmarshal, map[string]interface{}{
+// configKeys returns the config keys of a source mapped to its fields, the
+// params are stored in params.
+func (src *Source) configKeys(params *map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
 		"file":         &src.File,
 		"project_id":   &src.ProjectID,
+		"project_name": &src.ProjectName,
 		"access_token": &src.AccessToken,
 		"file_format":  &src.FileFormat,
-		"params":       &m,
-	})
+		"params":       params,
+
+		"normalize_locale_codes": &src.NormalizeLocaleCodes,
+		"source_encoding":        &src.SourceEncoding,
+		"fuzzy_locale_match":     &src.FuzzyLocaleMatch,
+		"locale_match":           &src.LocaleMatch,
+		"format_options_preset":  &src.FormatOptionsPreset,
+		"merge":                  &src.Merge,
+		"min_keys":               &src.MinKeys,
+		"pre_upload_command":     &src.PreUploadCommand,
+		"allowlist_file":         &src.AllowlistFile,
+		"schema":                 &src.Schema,
+	}
+}
+
+func (src *Source) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	m := map[string]interface{}{}
+	err := phraseapp.ParseYAMLToMap(unmarshal, src.configKeys(&m))
 	if err != nil {
 		return err
 	}
 
+	src.rawParams = m
 	src.Params = new(phraseapp.UploadParams)
 	return src.Params.ApplyValuesFromMap(m)
 }
 
+// applyDefaultParams rebuilds the params of the source from the given defaults
+// and the params configured for the source, the latter taking precedence.
+func (src *Source) applyDefaultParams(defaults map[string]interface{}) error {
+	merged := mergeParams(defaults, src.rawParams)
+	if _, found := src.rawParams["file_format"]; !found && src.FileFormat != "" {
+		// the file format of the source takes precedence over the defaults
+		delete(merged, "file_format")
+	}
+
+	params := new(phraseapp.UploadParams)
+	if err := params.ApplyValuesFromMap(merged); err != nil {
+		return err
+	}
+	src.Params = params
+	return nil
+}
+
 func (sources Sources) ProjectIds() []string {
 	projectIds := []string{}
 	for _, source := range sources {
@@ -153,7 +326,7 @@ func (sources Sources) ProjectIds() []string {
 	}
 	return projectIds
 }
-func (source *Source) uploadFile(client *phraseapp.Client, localeFile *LocaleFile, branch string) (*phraseapp.Upload, error) {
+func (source *Source) uploadFile(client *phraseapp.Client, localeFile *LocaleFile, branch string) (*uploadResult, error) {
 	if Debug {
 		fmt.Fprintln(os.Stdout, "Source file pattern:", source.File)
 		fmt.Fprintln(os.Stdout, "Actual file location:", localeFile.Path)
@@ -163,6 +336,33 @@ func (source *Source) uploadFile(client *phraseapp.Client, localeFile *LocaleFil
 	*params = *source.Params
 
 	params.File = &localeFile.Path
+	params.FormatOptions = source.Xliff.apply(source.GetFileFormat(), params.FormatOptions)
+	params.FormatOptions = withLocalePlaceholders(params.FormatOptions, localeFile)
+
+	if len(localeFile.merged) > 0 {
+		path, cleanup, err := mergeFiles(localeFile, source.SourceEncoding)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		params.File = &path
+	} else if source.SourceEncoding != "" && !charset.IsUTF8(source.SourceEncoding) {
+		path, cleanup, err := transcodeFile(localeFile.Path, source.SourceEncoding)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		params.File = &path
+	}
+
+	if source.PreUploadCommand != "" {
+		path, cleanup, err := runPreUploadCommand(source.PreUploadCommand, *params.File)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanup()
+		params.File = &path
+	}
 
 	if params.LocaleID == nil {
 		switch {
@@ -173,6 +373,14 @@ func (source *Source) uploadFile(client *phraseapp.Client, localeFile *LocaleFil
 		}
 	}
 
+	if err := source.checkMinKeys(localeFile, *params.File); err != nil {
+		return nil, err
+	}
+
+	if err := source.checkSchema(localeFile, *params.File); err != nil {
+		return nil, err
+	}
+
 	if localeFile.Tag != "" {
 		var v string
 		if params.Tags != nil {
@@ -186,7 +394,132 @@ func (source *Source) uploadFile(client *phraseapp.Client, localeFile *LocaleFil
 		params.Branch = &branch
 	}
 
-	return client.UploadCreate(source.ProjectID, params)
+	client, recorded := withWarnings(client)
+
+	// The uploads endpoint takes the whole file as one multipart request, the
+	// API offers no chunked or resumable uploads a failed upload could be
+	// continued with. There is no chunk size option for the same reason, it
+	// would always fall back to this request.
+	var upload *phraseapp.Upload
+	err := retryOnRateLimit(func() (err error) {
+		upload, err = client.UploadCreate(source.ProjectID, params)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &uploadResult{Upload: upload, Warnings: recorded.list()}, nil
+}
+
+// checkSchema returns an error listing the violations if the file at path,
+// uploaded for localeFile, doesn't conform to the schema of the source. Only
+// JSON files are validated.
+func (source *Source) checkSchema(localeFile *LocaleFile, path string) error {
+	if source.schema == nil || !strings.EqualFold(filepath.Ext(localeFile.Path), ".json") {
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	violations, err := source.schema.Validate(content)
+	if err != nil {
+		return fmt.Errorf("%s: can't validate against the schema %s: %s", localeFile.RelPath(), source.Schema, err)
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("%s doesn't conform to the schema %s:\n  %s", localeFile.RelPath(), source.Schema, strings.Join(violations, "\n  "))
+	}
+	return nil
+}
+
+// checkMinKeys returns an error if the file at path, uploaded for localeFile,
+// has fewer keys than MinKeys. Files whose keys can't be counted pass, unless
+// they are empty.
+func (source *Source) checkMinKeys(localeFile *LocaleFile, path string) error {
+	if source.MinKeys <= 0 || source.Force {
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	count, ok, err := keycount.Count(filepath.Ext(localeFile.Path), content)
+	if err != nil {
+		return fmt.Errorf("%s: can't count keys for min_keys: %s", localeFile.RelPath(), err)
+	}
+	if ok && count < source.MinKeys {
+		return fmt.Errorf("%s has %d keys, less than min_keys %d of its source. Use --force to upload it anyway", localeFile.RelPath(), count, source.MinKeys)
+	}
+	return nil
+}
+
+// transcodeFile writes the content of the file at path converted from the
+// given charset to UTF-8 into a temporary file with the same name. The
+// returned function removes the temporary file.
+func transcodeFile(path, encoding string) (string, func(), error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	content, err = charset.Decode(encoding, content)
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	dir, err := ioutil.TempDir(tempDirFor(""), "phraseapp")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	transcoded := filepath.Join(dir, filepath.Base(path))
+	if err := ioutil.WriteFile(transcoded, content, 0600); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return transcoded, cleanup, nil
+}
+
+// mergeFiles writes the merged content of localeFile and the files merged
+// with it into a temporary file with the name of localeFile. The files are
+// converted to UTF-8 first. The returned function removes the temporary file.
+func mergeFiles(localeFile *LocaleFile, encoding string) (string, func(), error) {
+	files := []merge.File{}
+	for _, path := range append([]string{localeFile.Path}, localeFile.merged...) {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", nil, err
+		}
+		if encoding != "" && !charset.IsUTF8(encoding) {
+			if content, err = charset.Decode(encoding, content); err != nil {
+				return "", nil, fmt.Errorf("%s: %s", path, err)
+			}
+		}
+		files = append(files, merge.File{Name: path, Content: content})
+	}
+
+	content, err := merge.Files(filepath.Ext(localeFile.Path), files)
+	if err != nil {
+		return "", nil, err
+	}
+
+	dir, err := ioutil.TempDir(tempDirFor(""), "phraseapp")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	merged := filepath.Join(dir, filepath.Base(localeFile.Path))
+	if err := ioutil.WriteFile(merged, content, 0600); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return merged, cleanup, nil
 }
 
 func (source *Source) createLocale(client *phraseapp.Client, localeFile *LocaleFile, branch string) (*phraseapp.LocaleDetails, error) {
@@ -227,6 +560,7 @@ func (source *Source) createLocale(client *phraseapp.Client, localeFile *LocaleF
 	if err != nil {
 		return nil, err
 	}
+	invalidateLocalesCache(client.Credentials.Host, source.ProjectID)
 	return localeDetails, nil
 }
 
@@ -270,3 +604,25 @@ func (source *Source) replacePlaceholderInParams(localeFile *LocaleFile) string
 	}
 	return ""
 }
+
+// normalizeLocaleCode returns the canonical form of a locale code, i.e. the
+// parts are separated by hyphens, the language is lower case, regions are
+// upper case and scripts are title case (en_us becomes en-US, zh_hans becomes
+// zh-Hans).
+func normalizeLocaleCode(code string) string {
+	parts := strings.FieldsFunc(code, func(r rune) bool {
+		return r == '_' || r == '-'
+	})
+
+	for i, part := range parts {
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(part)
+		case len(part) == 2:
+			parts[i] = strings.ToUpper(part)
+		case len(part) == 4:
+			parts[i] = strings.ToUpper(part[:1]) + strings.ToLower(part[1:])
+		}
+	}
+	return strings.Join(parts, "-")
+}
@@ -1,6 +1,10 @@
 package main
 
 import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -8,8 +12,14 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	"github.com/phrase/phraseapp-client/internal/paths"
 	"github.com/phrase/phraseapp-client/internal/placeholders"
@@ -419,8 +429,14 @@ func TestLocaleFiles(t *testing.T) {
 
 type testHandler struct {
 	lastFilename string
+	lastContent  []byte
 	lastLocaleID string
 	lastTag      string
+
+	lastFormatOptions map[string]string
+
+	// response is the body of the response, an empty object if not set
+	response string
 }
 
 func (th *testHandler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
@@ -435,11 +451,24 @@ func (th *testHandler) ServeHTTP(resp http.ResponseWriter, req *http.Request) {
 	}
 
 	th.lastFilename = req.MultipartForm.File["file"][0].Filename
+	if f, err := req.MultipartForm.File["file"][0].Open(); err == nil {
+		th.lastContent, _ = ioutil.ReadAll(f)
+		f.Close()
+	}
 	th.lastLocaleID = getVal("locale_id")
 	th.lastTag = getVal("tags")
+	th.lastFormatOptions = map[string]string{}
+	for key := range req.MultipartForm.Value {
+		if strings.HasPrefix(key, "format_options[") {
+			th.lastFormatOptions[strings.TrimSuffix(strings.TrimPrefix(key, "format_options["), "]")] = getVal(key)
+		}
+	}
 
 	resp.WriteHeader(http.StatusCreated)
-	io.WriteString(resp, `{}`)
+	if th.response == "" {
+		th.response = `{}`
+	}
+	io.WriteString(resp, th.response)
 }
 
 func TestUploadFile(t *testing.T) {
@@ -483,6 +512,109 @@ func TestUploadFile(t *testing.T) {
 	}
 }
 
+func TestUploadFileXliffOptions(t *testing.T) {
+	d := setupFiles(t, "en.xlf")
+	defer os.RemoveAll(d)
+	th := new(testHandler)
+
+	srv := httptest.NewServer(th)
+	defer srv.Close()
+
+	c := new(phraseapp.Client)
+	c.Credentials.Host = srv.URL
+	c.Credentials.Token = "some_token"
+
+	for _, format := range []string{"xlf", "yml"} {
+		src := &Source{Xliff: xliffOptions{States: true, Notes: true}}
+		src.Params = &phraseapp.UploadParams{FileFormat: sPt(format), FormatOptions: map[string]string{"include_notes": "false"}}
+
+		file := &LocaleFile{Path: filepath.Join(d, "en.xlf"), ID: "locale_id"}
+		if _, err := src.uploadFile(c, file, ""); err != nil {
+			t.Fatalf("didn't expect an error, got: %s", err)
+		}
+
+		exp := map[string]string{"include_notes": "false"}
+		if format == "xlf" {
+			exp["include_translation_state"] = "true"
+		}
+		if !reflect.DeepEqual(th.lastFormatOptions, exp) {
+			t.Errorf("expected format options %v for %s, got %v", exp, format, th.lastFormatOptions)
+		}
+	}
+}
+
+func TestUploadFileLocalePlaceholders(t *testing.T) {
+	d := setupFiles(t, "en.xlf")
+	defer os.RemoveAll(d)
+	th := new(testHandler)
+
+	srv := httptest.NewServer(th)
+	defer srv.Close()
+
+	c := new(phraseapp.Client)
+	c.Credentials.Host = srv.URL
+	c.Credentials.Token = "some_token"
+
+	src := &Source{}
+	src.Params = &phraseapp.UploadParams{FileFormat: sPt("xlf"), FormatOptions: map[string]string{"root_node": "<locale_code:lower>_<locale_name>", "enclose_in_cdata": "true"}}
+
+	for _, file := range []*LocaleFile{
+		{Path: filepath.Join(d, "en.xlf"), ID: "en-id", Code: "en-US", Name: "english"},
+		{Path: filepath.Join(d, "en.xlf"), ID: "de-id", Code: "de-DE", Name: "german"},
+	} {
+		if _, err := src.uploadFile(c, file, ""); err != nil {
+			t.Fatalf("didn't expect an error, got: %s", err)
+		}
+		exp := map[string]string{"root_node": strings.ToLower(file.Code) + "_" + file.Name, "enclose_in_cdata": "true"}
+		if !reflect.DeepEqual(th.lastFormatOptions, exp) {
+			t.Errorf("expected format options %v, got %v", exp, th.lastFormatOptions)
+		}
+	}
+	if src.Params.FormatOptions["root_node"] != "<locale_code:lower>_<locale_name>" {
+		t.Errorf("expected the format options of the source to be left untouched, got %v", src.Params.FormatOptions)
+	}
+}
+
+func TestUploadFileWarnings(t *testing.T) {
+	d := setupFiles(t, "en.json")
+	defer os.RemoveAll(d)
+	defer func(original *warningCollector) { warnings = original }(warnings)
+	warnings = &warningCollector{}
+
+	th := &testHandler{response: `{"id": "upload-id", "state": "processing", "warnings": ["key a.b ignored", {"message": "empty translation for c"}]}`}
+	srv := httptest.NewServer(th)
+	defer srv.Close()
+
+	c := new(phraseapp.Client)
+	c.Credentials.Host = srv.URL
+	c.Credentials.Token = "some_token"
+
+	src := &Source{Params: new(phraseapp.UploadParams)}
+	file := &LocaleFile{Path: filepath.Join(d, "en.json"), ID: "locale_id"}
+	upload, err := src.uploadFile(c, file, "")
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	if upload.ID != "upload-id" {
+		t.Errorf("expected upload ID %q, got %q", "upload-id", upload.ID)
+	}
+	exp := []string{"key a.b ignored", "empty translation for c"}
+	if !reflect.DeepEqual(upload.Warnings, exp) {
+		t.Errorf("expected warnings %v, got %v", exp, upload.Warnings)
+	}
+
+	printUploadWarnings(file, upload)
+	if messages := warnings.list(); len(messages) != 2 {
+		t.Errorf("expected the upload warnings to be recorded, got %v", messages)
+	}
+
+	th.response = `{"id": "upload-id"}`
+	if upload, err := src.uploadFile(c, file, ""); err != nil || len(upload.Warnings) != 0 {
+		t.Errorf("expected no warnings, got %v, %v", upload, err)
+	}
+}
+
 func TestRemoteLocaleForLocaleFile(t *testing.T) {
 	rlEN := &phraseapp.Locale{ID: "en-locale-id", Name: "english", Code: "en"}
 	rlDE := &phraseapp.Locale{ID: "de-locale-id", Name: "deutsch", Code: "de"}
@@ -532,6 +664,126 @@ func TestRemoteLocaleForLocaleFile(t *testing.T) {
 	}
 }
 
+func TestRemoteLocaleForLocaleFileAmbiguity(t *testing.T) {
+	rlEN := &phraseapp.Locale{ID: "en-locale-id", Name: "en", Code: "en"}
+	rlENGB := &phraseapp.Locale{ID: "en-gb-locale-id", Name: "en-GB", Code: "en"}
+
+	tt := []struct {
+		remotes  []*phraseapp.Locale
+		fuzzy    bool
+		expected *phraseapp.Locale
+	}{
+		{[]*phraseapp.Locale{rlENGB, rlEN}, false, rlEN},
+		{[]*phraseapp.Locale{rlENGB}, false, nil},
+		{[]*phraseapp.Locale{rlENGB}, true, rlENGB},
+		{[]*phraseapp.Locale{rlENGB, rlEN}, true, rlEN},
+	}
+
+	for i, tti := range tt {
+		localeID := "<locale_code>"
+		src := new(Source)
+		src.Params = new(phraseapp.UploadParams)
+		src.Params.LocaleID = &localeID
+		src.RemoteLocales = tti.remotes
+		src.FuzzyLocaleMatch = tti.fuzzy
+
+		r := src.getRemoteLocaleForLocaleFile(&LocaleFile{Code: "en"})
+		switch {
+		case tti.expected == nil && r != nil:
+			t.Errorf("%d: didn't expect a locale, got %q", i, r.ID)
+		case tti.expected != nil && r == nil:
+			t.Errorf("%d: expected locale %q, but got none", i, tti.expected.ID)
+		case tti.expected != nil && r != nil && tti.expected.ID != r.ID:
+			t.Errorf("%d: expected locale %q, but got %q", i, tti.expected.ID, r.ID)
+		}
+	}
+}
+
+func TestRemoteLocaleForLocaleFileStrategies(t *testing.T) {
+	defer func(original *warningCollector) { warnings = original }(warnings)
+	warnings = &warningCollector{}
+
+	rlEN := &phraseapp.Locale{ID: "en-locale-id", Name: "english", Code: "en"}
+	rlDE := &phraseapp.Locale{ID: "de-locale-id", Name: "de", Code: "de-DE"}
+	rlFR := &phraseapp.Locale{ID: "fr-locale-id", Name: "fr (default)", Code: "fr"}
+	remotes := []*phraseapp.Locale{rlEN, rlDE, rlFR}
+
+	tt := []struct {
+		strategy string
+		localeID string
+		file     LocaleFile
+		expected *phraseapp.Locale
+	}{
+		{localeMatchExactCode, "", LocaleFile{Code: "en"}, rlEN},
+		{localeMatchExactCode, "", LocaleFile{Code: "en", Name: "other"}, rlEN},
+		{localeMatchExactCode, "", LocaleFile{Name: "english"}, nil},
+		{localeMatchExactCode, "<locale_code>", LocaleFile{Code: "de"}, nil},
+		{localeMatchExactCode, "<locale_code>", LocaleFile{Code: "de-DE"}, rlDE},
+		{localeMatchExactCode, "fr", LocaleFile{}, rlFR},
+
+		{localeMatchExactName, "", LocaleFile{Name: "english"}, rlEN},
+		{localeMatchExactName, "", LocaleFile{Code: "en"}, nil},
+		{localeMatchExactName, "", LocaleFile{Code: "fr", Name: "english"}, rlEN},
+		{localeMatchExactName, "<locale_code>", LocaleFile{Code: "de"}, rlDE},
+		{localeMatchExactName, "fr", LocaleFile{}, nil},
+
+		{localeMatchCodeOrName, "", LocaleFile{Code: "en"}, rlEN},
+		{localeMatchCodeOrName, "", LocaleFile{Name: "english"}, rlEN},
+		{localeMatchCodeOrName, "", LocaleFile{Code: "fr", Name: "english"}, nil},
+		{localeMatchCodeOrName, "<locale_code>", LocaleFile{Code: "fr"}, nil},
+		{localeMatchCodeOrName, "de-locale-id", LocaleFile{}, rlDE},
+
+		{localeMatchFuzzy, "<locale_code>", LocaleFile{Code: "fr"}, rlFR},
+		{localeMatchFuzzy, "<locale_code>", LocaleFile{Code: "en"}, rlEN},
+		{localeMatchFuzzy, "<locale_code>", LocaleFile{Code: "it"}, nil},
+	}
+
+	for i, tti := range tt {
+		src := getBaseSource()
+		src.Params.LocaleID = sPt(tti.localeID)
+		src.RemoteLocales = remotes
+		src.LocaleMatch = tti.strategy
+		localeFile := tti.file
+
+		r := src.getRemoteLocaleForLocaleFile(&localeFile)
+		switch {
+		case tti.expected == nil && r != nil:
+			t.Errorf("%d %s: didn't expect a locale, got %q", i, tti.strategy, r.ID)
+		case tti.expected != nil && r == nil:
+			t.Errorf("%d %s: expected locale %q, but got none", i, tti.strategy, tti.expected.ID)
+		case tti.expected != nil && r != nil && tti.expected.ID != r.ID:
+			t.Errorf("%d %s: expected locale %q, but got %q", i, tti.strategy, tti.expected.ID, r.ID)
+		}
+	}
+
+	src := getBaseSource()
+	src.FuzzyLocaleMatch = true
+	if strategy := src.localeMatchStrategy(); strategy != localeMatchFuzzy {
+		t.Errorf("expected fuzzy_locale_match to select the fuzzy strategy, got %s", strategy)
+	}
+	src.LocaleMatch = localeMatchExactCode
+	if strategy := src.localeMatchStrategy(); strategy != localeMatchExactCode {
+		t.Errorf("expected locale_match to take precedence, got %s", strategy)
+	}
+
+	src.LocaleMatch = "loose"
+	if err := src.CheckPreconditions(); err == nil || !strings.Contains(err.Error(), "locale_match") {
+		t.Errorf("expected an error for an unsupported strategy, got: %v", err)
+	}
+}
+
+func TestPreferredLocale(t *testing.T) {
+	rlEN := &phraseapp.Locale{ID: "en-locale-id", Name: "english", Code: "en"}
+	rlENGB := &phraseapp.Locale{ID: "en-gb-locale-id", Name: "english", Code: "en-GB"}
+
+	if r := preferredLocale([]*phraseapp.Locale{rlENGB, rlEN}, &LocaleFile{Name: "english", Code: "en"}); r != rlEN {
+		t.Errorf("expected exact code match to be preferred, got %q", r.ID)
+	}
+	if r := preferredLocale([]*phraseapp.Locale{rlENGB, rlEN}, &LocaleFile{Name: "english"}); r != rlENGB {
+		t.Errorf("expected first exact name match, got %q", r.ID)
+	}
+}
+
 type patternShouldCreateLocale struct {
 	Name         string
 	Code         string
@@ -611,6 +863,69 @@ func TestShouldCreateLocale(t *testing.T) {
 	}
 }
 
+func TestPushCommandSources(t *testing.T) {
+	d := setupFiles(t, "locales/en.yml")
+	defer os.RemoveAll(d)
+	defer pushd(t, d)()
+
+	cmd := &PushCommand{Files: []string{"locales/en.yml", "locales/<locale_code>.yml"}, ProjectID: "project-id"}
+	cmd.Config.DefaultFileFormat = "yml"
+	sources, err := cmd.sources()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if len(sources) != 2 {
+		t.Fatalf("expected a source for each file, got %d", len(sources))
+	}
+	for i, source := range sources {
+		if source.File != cmd.Files[i] || source.ProjectID != "project-id" || *source.Params.FileFormat != "yml" {
+			t.Errorf("expected source of %s in project-id with format yml, got %s in %q with %q", cmd.Files[i], source.File, source.ProjectID, source.FileFormat)
+		}
+		source.Format = &phraseapp.Format{}
+	}
+
+	err = sources[0].checkFileLocale()
+	if err == nil || !strings.Contains(err.Error(), "The locale of locales/en.yml is unknown") {
+		t.Errorf("expected an error for a path without locale placeholder, got %v", err)
+	}
+	if err := sources[1].checkFileLocale(); err != nil {
+		t.Errorf("didn't expect an error for a path with locale placeholder, got: %s", err)
+	}
+	sources[0].Format.IncludesLocaleInformation = true
+	if err := sources[0].checkFileLocale(); err != nil {
+		t.Errorf("didn't expect an error for a format including the locale, got: %s", err)
+	}
+
+	cmd.LocaleID = "en-locale-id"
+	sources, err = cmd.sources()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	sources[0].Format = &phraseapp.Format{}
+	if err := sources[0].checkFileLocale(); err != nil {
+		t.Errorf("didn't expect an error with --locale-id, got: %s", err)
+	}
+	sources[0].RemoteLocales = []*phraseapp.Locale{{ID: "de-locale-id", Code: "de", Name: "german"}, {ID: "en-locale-id", Code: "en", Name: "english"}}
+	localeFiles, err := sources[0].LocaleFiles()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if len(localeFiles) != 1 || localeFiles[0].ID != "en-locale-id" {
+		t.Errorf("expected the file to be uploaded to the locale of --locale-id, got %v", localeFiles)
+	}
+
+	cmd.ProjectID = ""
+	if _, err := cmd.sources(); err == nil || !strings.Contains(err.Error(), "No project given") {
+		t.Errorf("expected an error without a project, got %v", err)
+	}
+
+	cmd.ProjectID = "project-id"
+	cmd.Config.Sources = []byte("sources:\n- file: ./locales/<locale_code>.yml\n")
+	if _, err := cmd.sources(); err == nil || !strings.Contains(err.Error(), "sources are configured as well") {
+		t.Errorf("expected an error for files given with configured sources, got %v", err)
+	}
+}
+
 // Push pattern tests
 type Patterns []*Pattern
 
@@ -951,3 +1266,823 @@ func (pattern *Pattern) TestPattern(t *testing.T, idx int) {
 		t.Errorf("Expected LocaleName to equal '%s' but was '%s' Pattern: %d", pattern.ExpectedName, localeFile.Name, idx+1)
 	}
 }
+
+func TestSourcesFromConfigDefaults(t *testing.T) {
+	cfg := phraseapp.Config{}
+	cfg.DefaultProjectID = "project-id"
+	cfg.Sources = []byte(`
+defaults:
+  tags: common
+  file_format: yml
+  format_options:
+    a: "1"
+    b: "2"
+sources:
+- file: ./a/<locale_code>.yml
+- file: ./b/<locale_code>.json
+  file_format: simple_json
+  params:
+    tags: override
+    format_options:
+      b: "3"
+`)
+
+	sources, err := SourcesFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	if len(sources) != 2 {
+		t.Fatalf("expected 2 sources, got %d", len(sources))
+	}
+
+	tt := []struct {
+		tags          string
+		fileFormat    string
+		formatOptions map[string]string
+	}{
+		{"common", "yml", map[string]string{"a": "1", "b": "2"}},
+		{"override", "simple_json", map[string]string{"a": "1", "b": "3"}},
+	}
+
+	for i, tti := range tt {
+		params := sources[i].Params
+		if params.Tags == nil || *params.Tags != tti.tags {
+			t.Errorf("%d: expected tags %q, got %v", i, tti.tags, params.Tags)
+		}
+		if sources[i].GetFileFormat() != tti.fileFormat {
+			t.Errorf("%d: expected file format %q, got %q", i, tti.fileFormat, sources[i].GetFileFormat())
+		}
+		for k, v := range tti.formatOptions {
+			if params.FormatOptions[k] != v {
+				t.Errorf("%d: expected format option %q to be %q, got %q", i, k, v, params.FormatOptions[k])
+			}
+		}
+	}
+}
+
+func TestNormalizeLocaleCodes(t *testing.T) {
+	d := setupFiles(t, "locales/en_US.yml", "locales/zh_hans.yml")
+	defer os.RemoveAll(d)
+	defer pushd(t, d)()
+
+	rlUS := &phraseapp.Locale{ID: "en-us-locale-id", Name: "english", Code: "en-US"}
+	rlZH := &phraseapp.Locale{ID: "zh-hans-locale-id", Name: "chinese", Code: "zh-Hans"}
+
+	src := new(Source)
+	src.File = "./locales/<locale_code>.yml"
+	src.RemoteLocales = []*phraseapp.Locale{rlUS, rlZH}
+
+	files, err := src.LocaleFiles()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	for _, lf := range files {
+		if lf.ExistsRemote {
+			t.Errorf("expected %s not to match a remote locale without normalization", lf.Path)
+		}
+	}
+
+	src.NormalizeLocaleCodes = true
+	files, err = src.LocaleFiles()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	expected := map[string]string{
+		"en_US.yml":   rlUS.ID,
+		"zh_hans.yml": rlZH.ID,
+	}
+	for _, lf := range files {
+		id := expected[filepath.Base(lf.Path)]
+		if !lf.ExistsRemote || lf.ID != id {
+			t.Errorf("expected %s to match remote locale %q, got %q", lf.Path, id, lf.ID)
+		}
+	}
+
+	for code, exp := range map[string]string{"en_us": "en-US", "EN-gb": "en-GB", "zh_HANS_cn": "zh-Hans-CN", "de": "de"} {
+		if got := normalizeLocaleCode(code); got != exp {
+			t.Errorf("expected %q to be normalized to %q, got %q", code, exp, got)
+		}
+	}
+}
+
+func TestSourcesFromConfigSourceEncoding(t *testing.T) {
+	cfg := phraseapp.Config{
+		DefaultProjectID:  "project-id",
+		DefaultFileFormat: "properties",
+		Sources: []byte(`sources:
+- file: ./legacy/<locale_code>.properties
+  source_encoding: ISO-8859-1
+- file: ./locales/<locale_code>.properties
+  source_encoding: ebcdic
+`),
+	}
+
+	sources, err := SourcesFromConfig(cfg)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if sources[0].SourceEncoding != "ISO-8859-1" {
+		t.Errorf("expected source_encoding ISO-8859-1, got %q", sources[0].SourceEncoding)
+	}
+	if err := sources[0].CheckPreconditions(); err != nil {
+		t.Errorf("didn't expect an error for a supported source_encoding, got: %s", err)
+	}
+	if err := sources[1].CheckPreconditions(); err == nil || !strings.Contains(err.Error(), `source_encoding "ebcdic"`) {
+		t.Errorf("expected an error for an unsupported source_encoding, got %v", err)
+	}
+}
+
+func TestUploadFileSourceEncoding(t *testing.T) {
+	d := setupFiles(t)
+	defer os.RemoveAll(d)
+
+	path := filepath.Join(d, "de.properties")
+	// "grüße=Grüße" encoded as ISO-8859-1
+	latin1 := []byte{'g', 'r', 0xFC, 0xDF, 'e', '=', 'G', 'r', 0xFC, 0xDF, 'e', '\n'}
+	if err := ioutil.WriteFile(path, latin1, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	th := new(testHandler)
+	srv := httptest.NewServer(th)
+	defer srv.Close()
+
+	c := new(phraseapp.Client)
+	c.Credentials.Host = srv.URL
+	c.Credentials.Token = "some_token"
+
+	src := new(Source)
+	src.Params = new(phraseapp.UploadParams)
+	src.SourceEncoding = "latin1"
+
+	file := new(LocaleFile)
+	file.Path = path
+	file.ID = "locale_id"
+
+	if _, err := src.uploadFile(c, file, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	if th.lastFilename != "de.properties" {
+		t.Errorf("expected file name %q, got %q", "de.properties", th.lastFilename)
+	}
+	if !utf8.Valid(th.lastContent) {
+		t.Errorf("expected uploaded content to be valid UTF-8, got %q", th.lastContent)
+	}
+	if exp := "grüße=Grüße\n"; string(th.lastContent) != exp {
+		t.Errorf("expected uploaded content %q, got %q", exp, th.lastContent)
+	}
+}
+
+func TestLocaleFilesModifiedSince(t *testing.T) {
+	d := setupFiles(t, "locales/en.json", "locales/de.json", "locales/fr.json")
+	defer os.RemoveAll(d)
+	defer pushd(t, d)()
+
+	old := time.Now().Add(-2 * time.Hour)
+	for _, path := range []string{"locales/de.json", "locales/fr.json"} {
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	source := getBaseSource()
+	source.File = "./locales/<locale_code>.json"
+	source.ModifiedSince = time.Now().Add(-time.Hour)
+
+	localeFiles, err := source.LocaleFiles()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if len(localeFiles) != 1 || localeFiles[0].Code != "en" {
+		t.Errorf("expected only the recently modified en.json, got %v", localeFiles)
+	}
+
+	source.ModifiedSince = time.Now().Add(time.Hour)
+	localeFiles, err = source.LocaleFiles()
+	if err != nil {
+		t.Fatalf("didn't expect an error if all files are older, got: %s", err)
+	}
+	if len(localeFiles) != 0 {
+		t.Errorf("expected no files, got %d", len(localeFiles))
+	}
+}
+
+func TestLocaleFilesChangedFiles(t *testing.T) {
+	d := setupFiles(t, "locales/en.json", "locales/de.json")
+	defer os.RemoveAll(d)
+	defer pushd(t, d)()
+
+	resolved, err := filepath.EvalSymlinks(filepath.Join(d, "locales/de.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	source := getBaseSource()
+	source.File = "./locales/<locale_code>.json"
+	source.ChangedFiles = map[string]bool{resolved: true}
+
+	localeFiles, err := source.LocaleFiles()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if len(localeFiles) != 1 || localeFiles[0].Code != "de" {
+		t.Errorf("expected only the changed de.json, got %v", localeFiles)
+	}
+}
+
+func TestLocaleFilesStrictPlaceholders(t *testing.T) {
+	d := setupFiles(t, "locales/web-en.yml", "locales/-de.yml")
+	defer os.RemoveAll(d)
+	defer pushd(t, d)()
+
+	source := getBaseSource()
+	source.File = "./locales/<tag>-<locale_code>.yml"
+
+	localeFiles, err := source.LocaleFiles()
+	if err != nil {
+		t.Fatalf("didn't expect an error without strict placeholders, got: %s", err)
+	}
+	if len(localeFiles) != 2 {
+		t.Errorf("expected both files, got %v", localeFiles)
+	}
+
+	source.StrictPlaceholders = true
+	if _, err := source.LocaleFiles(); err == nil || !strings.Contains(err.Error(), "-de.yml") {
+		t.Errorf("expected an error for the under-matching -de.yml, got: %v", err)
+	}
+
+	os.Remove(filepath.Join(d, "locales/-de.yml"))
+	localeFiles, err = source.LocaleFiles()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if len(localeFiles) != 1 || localeFiles[0].Tag != "web" || localeFiles[0].Code != "en" {
+		t.Errorf("expected web-en.yml to be resolved, got %v", localeFiles)
+	}
+}
+
+func TestLocaleFilesAllowlist(t *testing.T) {
+	d := setupFiles(t, "locales/en.json", "locales/de.json", "locales/fr.json")
+	defer os.RemoveAll(d)
+	defer pushd(t, d)()
+
+	allowlist := "# approved translation files\nlocales/en.json\n./locales/de.json\n"
+	if err := ioutil.WriteFile("allowlist.txt", []byte(allowlist), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	source := getBaseSource()
+	source.File = "./locales/<locale_code>.json"
+	source.AllowlistFile = "allowlist.txt"
+
+	_, err := source.LocaleFiles()
+	if err == nil || !strings.Contains(err.Error(), "locales/fr.json") {
+		t.Fatalf("expected an error for fr.json missing from the allowlist, got: %v", err)
+	}
+	if strings.Contains(err.Error(), "de.json") || strings.Contains(err.Error(), "en.json") {
+		t.Errorf("expected only fr.json to be rejected, got: %s", err)
+	}
+
+	os.Remove(filepath.Join(d, "locales/fr.json"))
+	localeFiles, err := source.LocaleFiles()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if len(localeFiles) != 2 {
+		t.Errorf("expected the two allowed files, got %v", localeFiles)
+	}
+}
+
+func TestPushFromArchive(t *testing.T) {
+	d := setupFiles(t)
+	defer os.RemoveAll(d)
+	defer pushd(t, d)()
+
+	buf := &bytes.Buffer{}
+	w := zip.NewWriter(buf)
+	for _, entry := range []struct{ name, content string }{
+		{"locales/en.json", `{"greeting": "Hello"}`},
+		{"locales/de.json", `{"greeting": "Hallo"}`},
+		{"other/fr.json", `{"greeting": "Bonjour"}`},
+	} {
+		f, err := w.Create(entry.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.WriteString(f, entry.content)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile("locales.zip", buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	th := new(testHandler)
+	srv := httptest.NewServer(th)
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	source := getBaseSource()
+	source.File = "./locales/<locale_code>.json"
+	source.FileFormat = "json"
+	source.Format = &phraseapp.Format{}
+	source.Archive = "locales.zip"
+	source.results = &runResults{}
+
+	if err := source.Push(client, false, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	exp := []string{filepath.Join("locales", "en.json"), filepath.Join("locales", "de.json")}
+	if !reflect.DeepEqual(source.results.Files, exp) {
+		t.Errorf("expected the matching archive entries %v to be uploaded, got %v", exp, source.results.Files)
+	}
+	if th.lastLocaleID != "de-locale-id" || string(th.lastContent) != `{"greeting": "Hallo"}` {
+		t.Errorf("expected de.json to be uploaded from the archive, got %q for %q", th.lastContent, th.lastLocaleID)
+	}
+	if _, err := os.Stat("locales"); !os.IsNotExist(err) {
+		t.Errorf("expected the archive not to be unpacked into the working directory")
+	}
+
+	dir := source.archiveDir
+	source.removeArchiveFiles()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected the extracted files to be removed, got: %v", err)
+	}
+}
+
+func TestLocaleFilesLocaleRegex(t *testing.T) {
+	d := setupFiles(t, "locales/es.json", "locales/es-MX.json", "locales/et.json", "locales/en.json")
+	defer os.RemoveAll(d)
+	defer pushd(t, d)()
+
+	source := getBaseSource()
+	source.File = "./locales/<locale_code>.json"
+	source.RemoteLocales = nil
+	source.LocaleRegex = regexp.MustCompile("^es(-.*)?$")
+
+	localeFiles, err := source.LocaleFiles()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	codes := []string{}
+	for _, localeFile := range localeFiles {
+		codes = append(codes, localeFile.Code)
+	}
+	if exp := []string{"es-MX", "es"}; !reflect.DeepEqual(codes, exp) {
+		t.Errorf("expected the spanish locales %v, got %v", exp, codes)
+	}
+}
+
+func TestPushMergeFiles(t *testing.T) {
+	d := setupFiles(t)
+	defer os.RemoveAll(d)
+	defer pushd(t, d)()
+
+	os.Mkdir("locales", 0755)
+	for name, content := range map[string]string{
+		"locales/common.en.json":  `{"cancel": "Cancel", "app": {"title": "App"}}`,
+		"locales/feature.en.json": `{"app": {"feature": "Feature"}}`,
+		"locales/common.de.json":  `{"cancel": "Abbrechen"}`,
+	} {
+		if err := ioutil.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	source := getBaseSource()
+	source.File = "./locales/*.<locale_code>.json"
+	source.FileFormat = "simple_json"
+	source.Merge = true
+	if err := source.CheckPreconditions(); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	localeFiles, err := source.LocaleFiles()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if len(localeFiles) != 2 {
+		t.Fatalf("expected one file per locale, got %d", len(localeFiles))
+	}
+
+	var en *LocaleFile
+	for _, localeFile := range localeFiles {
+		if localeFile.Code == "en" {
+			en = localeFile
+		}
+	}
+	if en == nil || len(en.merged) != 1 {
+		t.Fatalf("expected the en files to be merged, got %v", en)
+	}
+
+	th := new(testHandler)
+	srv := httptest.NewServer(th)
+	defer srv.Close()
+
+	c := new(phraseapp.Client)
+	c.Credentials.Host = srv.URL
+	c.Credentials.Token = "some_token"
+
+	if _, err := source.uploadFile(c, en, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if th.lastFilename != "common.en.json" {
+		t.Errorf("expected the merged file to be uploaded as %q, got %q", "common.en.json", th.lastFilename)
+	}
+	exp := "{\n  \"app\": {\n    \"feature\": \"Feature\",\n    \"title\": \"App\"\n  },\n  \"cancel\": \"Cancel\"\n}\n"
+	if string(th.lastContent) != exp {
+		t.Errorf("expected merged content %q, got %q", exp, th.lastContent)
+	}
+
+	source.File = "./locales/*.<locale_code>.strings"
+	source.FileFormat = "strings"
+	if err := source.CheckPreconditions(); err == nil || !strings.Contains(err.Error(), "merge") {
+		t.Errorf("expected an error for merging unsupported files, got: %v", err)
+	}
+}
+
+func TestUploadFileMinKeys(t *testing.T) {
+	d := setupFiles(t)
+	defer os.RemoveAll(d)
+
+	path := filepath.Join(d, "en.json")
+	if err := ioutil.WriteFile(path, []byte(`{"app": {"title": "App"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	th := new(testHandler)
+	srv := httptest.NewServer(th)
+	defer srv.Close()
+
+	c := new(phraseapp.Client)
+	c.Credentials.Host = srv.URL
+	c.Credentials.Token = "some_token"
+
+	src := &Source{Params: new(phraseapp.UploadParams), MinKeys: 2}
+	file := &LocaleFile{Path: path, ID: "locale_id"}
+	if _, err := src.uploadFile(c, file, ""); err == nil || !strings.Contains(err.Error(), "has 1 keys, less than min_keys 2") {
+		t.Errorf("expected the nearly empty file to be rejected, got: %v", err)
+	}
+	if th.lastFilename != "" {
+		t.Errorf("expected nothing to be uploaded, got %q", th.lastFilename)
+	}
+
+	src.Force = true
+	if _, err := src.uploadFile(c, file, ""); err != nil {
+		t.Errorf("didn't expect an error with --force, got: %s", err)
+	}
+
+	src.Force = false
+	src.MinKeys = 1
+	if _, err := src.uploadFile(c, file, ""); err != nil {
+		t.Errorf("didn't expect an error for enough keys, got: %s", err)
+	}
+}
+
+func TestUploadFileSchema(t *testing.T) {
+	d := setupFiles(t)
+	defer os.RemoveAll(d)
+
+	schemaPath := filepath.Join(d, "locale.schema.json")
+	schema := `{
+  "type": "object",
+  "additionalProperties": {"type": "string", "minLength": 1}
+}`
+	if err := ioutil.WriteFile(schemaPath, []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(d, "en.json")
+	if err := ioutil.WriteFile(path, []byte(`{"title": "App", "nested": {"cancel": "Cancel"}, "empty": ""}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	th := new(testHandler)
+	srv := httptest.NewServer(th)
+	defer srv.Close()
+
+	c := new(phraseapp.Client)
+	c.Credentials.Host = srv.URL
+	c.Credentials.Token = "some_token"
+
+	src := getBaseSource()
+	src.File = filepath.Join(d, "<locale_code>.json")
+	src.Schema = schemaPath
+	if err := src.CheckPreconditions(); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	file := &LocaleFile{Path: path, ID: "locale_id"}
+	_, err := src.uploadFile(c, file, "")
+	if err == nil {
+		t.Fatalf("expected the file not conforming to the schema to be rejected")
+	}
+	for _, violation := range []string{"/empty: expected at least 1 characters, got 0", "/nested: expected string, got object"} {
+		if !strings.Contains(err.Error(), violation) {
+			t.Errorf("expected the violation %q, got: %s", violation, err)
+		}
+	}
+	if th.lastFilename != "" {
+		t.Errorf("expected nothing to be uploaded, got %q", th.lastFilename)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(`{"title": "App", "cancel": "Cancel"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := src.uploadFile(c, file, ""); err != nil {
+		t.Errorf("didn't expect an error for a conforming file, got: %s", err)
+	}
+
+	src = getBaseSource()
+	src.File = filepath.Join(d, "<locale_code>.json")
+	src.Schema = filepath.Join(d, "missing.json")
+	if err := src.CheckPreconditions(); err == nil {
+		t.Errorf("expected an error for a missing schema")
+	}
+}
+
+func TestUploadFilePreUploadCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the test commands need a POSIX shell")
+	}
+
+	d := setupFiles(t)
+	defer os.RemoveAll(d)
+
+	path := filepath.Join(d, "en.json")
+	if err := ioutil.WriteFile(path, []byte(`{"title": "app"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	th := new(testHandler)
+	srv := httptest.NewServer(th)
+	defer srv.Close()
+
+	c := new(phraseapp.Client)
+	c.Credentials.Host = srv.URL
+	c.Credentials.Token = "some_token"
+
+	src := &Source{Params: new(phraseapp.UploadParams), PreUploadCommand: "tr a-z A-Z <"}
+	file := &LocaleFile{Path: path, ID: "locale_id"}
+	if _, err := src.uploadFile(c, file, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if string(th.lastContent) != `{"TITLE": "APP"}` || th.lastFilename != "en.json" {
+		t.Errorf("expected the output of the command to be uploaded as en.json, got %q as %q", th.lastContent, th.lastFilename)
+	}
+
+	th.lastContent = nil
+	src.PreUploadCommand = "echo extraction failed >&2; exit 3; cat"
+	_, err := src.uploadFile(c, file, "")
+	if err == nil || !strings.Contains(err.Error(), "exit status 3: extraction failed") {
+		t.Errorf("expected the failed command to fail the upload, got: %v", err)
+	}
+	if th.lastContent != nil {
+		t.Errorf("expected nothing to be uploaded, got %q", th.lastContent)
+	}
+}
+
+func TestPushCreatedLocalesSummary(t *testing.T) {
+	d := setupFiles(t, "locales/fr.json")
+	defer os.RemoveAll(d)
+	defer pushd(t, d)()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/v2/projects/project-id/locales/fr":
+			w.WriteHeader(http.StatusNotFound)
+			io.WriteString(w, `{"message": "Not Found"}`)
+		case r.Method == "POST" && r.URL.Path == "/v2/projects/project-id/locales":
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"id": "fr-locale-id", "code": "fr", "name": "french"}`)
+		case r.Method == "POST" && r.URL.Path == "/v2/projects/project-id/uploads":
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"id": "upload-id", "filename": "fr.json"}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	source := getBaseSource()
+	source.File = "./locales/<locale_code>.json"
+	source.FileFormat = "json"
+	source.Format = &phraseapp.Format{}
+	source.results = &runResults{}
+
+	if err := source.Push(client, false, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	cmd := &PushCommand{SummaryFile: "summary.json", CreatedLocalesFile: "created.json"}
+	if err := cmd.writeResults(source.results); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	exp := []createdLocale{{ID: "fr-locale-id", Code: "fr", Name: "french"}}
+	summary := struct {
+		Files          []string        `json:"files"`
+		CreatedLocales []createdLocale `json:"created_locales"`
+	}{}
+	content, err := ioutil.ReadFile("summary.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(content, &summary); err != nil {
+		t.Fatalf("expected a JSON summary, got %q: %s", content, err)
+	}
+	if !reflect.DeepEqual(summary.CreatedLocales, exp) || !reflect.DeepEqual(summary.Files, []string{filepath.Join("locales", "fr.json")}) {
+		t.Errorf("expected the created locale and uploaded file in the summary, got %s", content)
+	}
+
+	created := []createdLocale{}
+	content, err = ioutil.ReadFile("created.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(content, &created); err != nil || !reflect.DeepEqual(created, exp) {
+		t.Errorf("expected the created locales file to list %v, got %s", exp, content)
+	}
+}
+
+func TestPushChangedKeys(t *testing.T) {
+	d := setupFiles(t, "locales/en.json")
+	defer os.RemoveAll(d)
+	defer pushd(t, d)()
+
+	var mu sync.Mutex
+	uploaded := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.URL.Path == "/v2/projects/project-id/locales/en-locale-id/download":
+			params := map[string]interface{}{}
+			json.NewDecoder(r.Body).Decode(&params)
+			if params["file_format"] != "simple_json" || params["include_empty_translations"] != true {
+				t.Errorf("expected a flat download including empty translations, got %v", params)
+			}
+			if uploaded {
+				io.WriteString(w, `{"cancel": "Cancel", "greeting": "Hello!", "title": "Title"}`)
+				return
+			}
+			io.WriteString(w, `{"greeting": "Hello", "title": "Title"}`)
+		case r.Method == "POST" && r.URL.Path == "/v2/projects/project-id/uploads":
+			uploaded = true
+			w.WriteHeader(http.StatusCreated)
+			io.WriteString(w, `{"id": "upload-id", "filename": "en.json", "state": "processing"}`)
+		case r.URL.Path == "/v2/projects/project-id/uploads/upload-id":
+			io.WriteString(w, `{"id": "upload-id", "filename": "en.json", "state": "success"}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	source := getBaseSource()
+	source.File = "./locales/<locale_code>.json"
+	source.FileFormat = "json"
+	source.Format = &phraseapp.Format{}
+	source.changedKeys = &changedKeysReport{}
+
+	if err := source.Push(client, true, ""); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	if err := source.changedKeys.write("changed.json"); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile("changed.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := struct {
+		Mode  string             `json:"mode"`
+		Files []changedKeysEntry `json:"files"`
+	}{}
+	if err := json.Unmarshal(content, &report); err != nil {
+		t.Fatalf("expected a JSON report, got %q: %s", content, err)
+	}
+	exp := []changedKeysEntry{{Path: filepath.Join("locales", "en.json"), Locale: "en", Created: []string{"cancel"}, Updated: []string{"greeting"}}}
+	if report.Mode != changedKeysMode || !reflect.DeepEqual(report.Files, exp) {
+		t.Errorf("expected changed keys %+v, got %s", exp, content)
+	}
+}
+
+func TestPreviewPush(t *testing.T) {
+	d := setupFiles(t, "locales/en.json", "locales/fr.json")
+	defer os.RemoveAll(d)
+	defer pushd(t, d)()
+
+	updateTranslations := true
+	source := getBaseSource()
+	source.File = "./locales/<locale_code>.json"
+	source.Format = &phraseapp.Format{ApiName: "simple_json"}
+	source.RemoteLocales = []*phraseapp.Locale{{ID: "en-id", Code: "en", Name: "english"}}
+
+	files, err := Sources{source}.localeFiles()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	preview, err := previewPush(Sources{source}, files, "")
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if preview.IsDestructive() {
+		t.Errorf("expected push without update_translations not to be destructive")
+	}
+	if len(preview.Files) != 2 || len(preview.LocalesCreated) != 1 || preview.LocalesCreated[0] != "fr" {
+		t.Errorf("expected 2 files and the fr locale to be created, got %v and %v", preview.Files, preview.LocalesCreated)
+	}
+
+	source.Params.UpdateTranslations = &updateTranslations
+	preview, err = previewPush(Sources{source}, files, "")
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if !preview.IsDestructive() || len(preview.TranslationsReplaced) != 2 {
+		t.Errorf("expected both files to replace translations, got %v", preview.TranslationsReplaced)
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(d, "locales", "en.json"), []byte(`{"a": "A", "b": {"c": "C"}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	preview, err = previewPush(Sources{source}, files, "")
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if preview.KeysAffected != 2 || preview.keysAffected() != "2" {
+		t.Errorf("expected 2 keys affected, got %d", preview.KeysAffected)
+	}
+}
+
+func TestConfirmDestructivePushWithoutTerminal(t *testing.T) {
+	d := setupFiles(t, "locales/en.json")
+	defer os.RemoveAll(d)
+	defer pushd(t, d)()
+	defer func(original func() bool) { stdinIsTerminal = original }(stdinIsTerminal)
+	stdinIsTerminal = func() bool { return false }
+
+	updateTranslations := true
+	source := getBaseSource()
+	source.File = "./locales/<locale_code>.json"
+	source.RemoteLocales = []*phraseapp.Locale{{ID: "en-id", Code: "en", Name: "english"}}
+	source.Params.UpdateTranslations = &updateTranslations
+
+	files, err := Sources{source}.localeFiles()
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	buf := &bytes.Buffer{}
+	confirmed, err := confirmDestructivePush(buf, Sources{source}, files, "")
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if !confirmed {
+		t.Errorf("expected the push to proceed without terminal")
+	}
+	if !strings.Contains(buf.String(), "files replacing translations:   1") {
+		t.Errorf("expected the preview to be printed, got %q", buf.String())
+	}
+}
+
+func TestUploadTagsWithGitBranch(t *testing.T) {
+	defer func(original func() (string, error)) { currentGitBranch = original }(currentGitBranch)
+	currentGitBranch = func() (string, error) { return "feature/new login", nil }
+
+	cmd := &PushCommand{Tags: "release, app", TagGitBranch: true}
+	tags := cmd.uploadTags()
+	exp := []string{"release", "app", "feature-new-login"}
+	if !reflect.DeepEqual(tags, exp) {
+		t.Errorf("expected tags %v, got %v", exp, tags)
+	}
+
+	source := getBaseSource()
+	source.Params.Tags = sPt("app,web")
+	source.addTags(tags)
+	if *source.Params.Tags != "app,web,release,feature-new-login" {
+		t.Errorf("expected tags to be added once, got %q", *source.Params.Tags)
+	}
+
+	currentGitBranch = func() (string, error) { return "", fmt.Errorf("HEAD is detached") }
+	if tags := cmd.uploadTags(); !reflect.DeepEqual(tags, []string{"release", "app"}) {
+		t.Errorf("expected the git branch to be skipped, got %v", tags)
+	}
+}
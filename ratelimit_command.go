@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+type RateLimitCommand struct {
+	phraseapp.Config
+	Format string `cli:"opt --format default=text desc='Output format, text or json'"`
+}
+
+// rateLimit is the state of the rate limit reported with every API response.
+type rateLimit struct {
+	Limit     int       `json:"limit"`
+	Remaining int       `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+	// ResetIn is the number of seconds until the limit is reset.
+	ResetIn int64 `json:"reset_in_seconds"`
+}
+
+func (cmd *RateLimitCommand) Run() error {
+	if cmd.Config.Debug {
+		// suppresses content output
+		cmd.Config.Debug = false
+		Debug = true
+	}
+
+	if cmd.Format != "text" && cmd.Format != "json" {
+		return fmt.Errorf("unknown output format %q, use text or json", cmd.Format)
+	}
+
+	client, err := newClient(cmd.Config.Credentials, cmd.Config.Debug)
+	if err != nil {
+		return err
+	}
+
+	limit, err := currentRateLimit(client)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Format == "json" {
+		return json.NewEncoder(os.Stdout).Encode(limit)
+	}
+	fmt.Printf("Limit:     %d requests\n", limit.Limit)
+	fmt.Printf("Remaining: %d requests\n", limit.Remaining)
+	fmt.Printf("Reset:     %s (in %s)\n", limit.Reset.Local().Format(time.RFC1123), time.Duration(limit.ResetIn)*time.Second)
+	return nil
+}
+
+// currentRateLimit requests the current user, one of the cheapest requests,
+// and returns the rate limit reported with the response.
+func currentRateLimit(client *phraseapp.Client) (*rateLimit, error) {
+	c := *client
+	recorder := recordResponses(&c)
+
+	_, err := c.ShowUser()
+	if rateLimitError, ok := err.(*phraseapp.RateLimitingError); ok {
+		return newRateLimit(rateLimitError.Limit, rateLimitError.Remaining, rateLimitError.Reset), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	header, _ := recorder.last()
+	return parseRateLimit(header)
+}
+
+// parseRateLimit returns the rate limit of the X-Rate-Limit headers of an API
+// response.
+func parseRateLimit(header http.Header) (*rateLimit, error) {
+	values := map[string]int64{}
+	for _, name := range []string{"X-Rate-Limit-Limit", "X-Rate-Limit-Remaining", "X-Rate-Limit-Reset"} {
+		value := header.Get(name)
+		if value == "" {
+			return nil, fmt.Errorf("the response has no %s header", name)
+		}
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s header %q", name, value)
+		}
+		values[name] = parsed
+	}
+	return newRateLimit(int(values["X-Rate-Limit-Limit"]), int(values["X-Rate-Limit-Remaining"]), time.Unix(values["X-Rate-Limit-Reset"], 0)), nil
+}
+
+func newRateLimit(limit, remaining int, reset time.Time) *rateLimit {
+	resetIn := int64(time.Until(reset).Seconds())
+	if resetIn < 0 {
+		resetIn = 0
+	}
+	return &rateLimit{Limit: limit, Remaining: remaining, Reset: reset.UTC(), ResetIn: resetIn}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func TestCurrentRateLimit(t *testing.T) {
+	reset := time.Now().Add(90 * time.Second).Unix()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/user" {
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+		w.Header().Set("X-Rate-Limit-Limit", "1000")
+		w.Header().Set("X-Rate-Limit-Remaining", "987")
+		w.Header().Set("X-Rate-Limit-Reset", strconv.FormatInt(reset, 10))
+		io.WriteString(w, `{"id": "user-id"}`)
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	limit, err := currentRateLimit(client)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if limit.Limit != 1000 || limit.Remaining != 987 || limit.Reset.Unix() != reset {
+		t.Errorf("expected the limit of the headers, got %+v", limit)
+	}
+	if limit.ResetIn < 85 || limit.ResetIn > 90 {
+		t.Errorf("expected the limit to be reset in about 90 seconds, got %d", limit.ResetIn)
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-Rate-Limit-Limit", "1000")
+	header.Set("X-Rate-Limit-Remaining", "many")
+	header.Set("X-Rate-Limit-Reset", "1600000000")
+	if _, err := parseRateLimit(header); err == nil {
+		t.Errorf("expected an error for an invalid header")
+	}
+
+	header.Del("X-Rate-Limit-Remaining")
+	if _, err := parseRateLimit(header); err == nil {
+		t.Errorf("expected an error for a missing header")
+	}
+}
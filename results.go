@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/phrase/phraseapp-client/internal/stringz"
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+// runResults collects what a push or pull changed, so it can be reported
+// after the run. All methods may be called on a nil *runResults.
+type runResults struct {
+	mu sync.Mutex
+	// locales is the outcome per locale code of the files of a pull.
+	locales map[string]*localeStatus
+	// resumed are the files a resumed pull skipped, as the interrupted pull
+	// wrote them already.
+	resumed []string
+
+	Files          []string        `json:"files"`
+	Locales        []string        `json:"locales"`
+	CreatedLocales []createdLocale `json:"created_locales"`
+	Skipped        int             `json:"skipped"`
+	Errors         int             `json:"errors"`
+	Bytes          int64           `json:"bytes"`
+}
+
+// localeStatus counts the outcomes of the files of a locale.
+type localeStatus struct {
+	Written, Skipped, Errors int
+	Bytes                    int64
+	// Completion is the percentage of translated keys, if known.
+	Completion *float64
+}
+
+// status returns error if any file of the locale failed, otherwise written
+// if any was written.
+func (status *localeStatus) status() string {
+	switch {
+	case status.Errors > 0:
+		return "error"
+	case status.Written > 0:
+		return "written"
+	default:
+		return "skipped"
+	}
+}
+
+// createdLocale is a locale created by a push.
+type createdLocale struct {
+	ID   string `json:"id"`
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+func (results *runResults) addFile(path string) {
+	if results == nil {
+		return
+	}
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	results.Files = append(results.Files, path)
+}
+
+// addResumed records a file written by the interrupted pull a pull resumes.
+func (results *runResults) addResumed(path string) {
+	if results == nil {
+		return
+	}
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	results.resumed = append(results.resumed, path)
+}
+
+// pulledFiles returns the files written by a pull, including those written
+// by the interrupted pull it resumed.
+func (results *runResults) pulledFiles() []string {
+	if results == nil {
+		return nil
+	}
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	return append(append([]string{}, results.Files...), results.resumed...)
+}
+
+// addLocale records the code of a locale whose files were written, once.
+func (results *runResults) addLocale(code string) {
+	if results == nil || code == "" {
+		return
+	}
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	if !stringz.Contains(results.Locales, code) {
+		results.Locales = append(results.Locales, code)
+	}
+}
+
+func (results *runResults) addCreatedLocale(locale *phraseapp.LocaleDetails) {
+	if results == nil {
+		return
+	}
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	results.CreatedLocales = append(results.CreatedLocales, createdLocale{ID: locale.ID, Code: locale.Code, Name: locale.Name})
+}
+
+// createdLocaleNames returns the names of the created locales.
+func (results *runResults) createdLocaleNames() []string {
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	names := []string{}
+	for _, locale := range results.CreatedLocales {
+		names = append(names, locale.Name)
+	}
+	return names
+}
+
+// locale returns the status of the locale with code, the lock must be held.
+func (results *runResults) locale(code string) *localeStatus {
+	if results.locales == nil {
+		results.locales = map[string]*localeStatus{}
+	}
+	status, found := results.locales[code]
+	if !found {
+		status = &localeStatus{}
+		results.locales[code] = status
+	}
+	return status
+}
+
+// addLocaleOutcome counts a file of the locale with code as written, skipped
+// or error.
+func (results *runResults) addLocaleOutcome(code, outcome string) {
+	if results == nil {
+		return
+	}
+	results.mu.Lock()
+	defer results.mu.Unlock()
+
+	status := results.locale(code)
+	switch outcome {
+	case "written":
+		status.Written++
+	case "skipped":
+		status.Skipped++
+	default:
+		status.Errors++
+	}
+}
+
+func (results *runResults) addLocaleBytes(code string, n int) {
+	if results == nil {
+		return
+	}
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	results.locale(code).Bytes += int64(n)
+}
+
+func (results *runResults) setLocaleCompletion(code string, percent float64) {
+	if results == nil {
+		return
+	}
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	results.locale(code).Completion = &percent
+}
+
+// printLocaleTable prints a row per locale with its bytes written, status
+// and completion, if any files of locales were pulled.
+func (results *runResults) printLocaleTable(w io.Writer) {
+	if results == nil {
+		return
+	}
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	if len(results.locales) == 0 {
+		return
+	}
+
+	codes := []string{}
+	for code := range results.locales {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "LOCALE\tBYTES\tSTATUS\tCOMPLETION")
+	for _, code := range codes {
+		status := results.locales[code]
+		completion := "-"
+		if status.Completion != nil {
+			completion = fmt.Sprintf("%.1f%%", *status.Completion)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", code, formatBytes(status.Bytes), status.status(), completion)
+	}
+	table.Flush()
+}
+
+func (results *runResults) addSkipped() {
+	if results == nil {
+		return
+	}
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	results.Skipped++
+}
+
+func (results *runResults) addError() {
+	if results == nil {
+		return
+	}
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	results.Errors++
+}
+
+func (results *runResults) addBytes(n int) {
+	if results == nil {
+		return
+	}
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	results.Bytes += int64(n)
+}
+
+// Summary returns a one line tally of the run.
+func (results *runResults) Summary() string {
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	return fmt.Sprintf("%d files written, %d skipped, %d errors, %s total",
+		len(results.Files), results.Skipped, results.Errors, formatBytes(results.Bytes))
+}
+
+// writeJSON writes the results as JSON to path.
+func (results *runResults) writeJSON(path string) error {
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	return writeJSONFile(path, results)
+}
+
+// writeCreatedLocales writes the created locales as JSON array to path.
+func (results *runResults) writeCreatedLocales(path string) error {
+	results.mu.Lock()
+	defer results.mu.Unlock()
+	locales := results.CreatedLocales
+	if locales == nil {
+		locales = []createdLocale{}
+	}
+	return writeJSONFile(path, locales)
+}
+
+func writeJSONFile(path string, value interface{}) error {
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(content, '\n'), 0644)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
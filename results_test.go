@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRunResultsSummary(t *testing.T) {
+	results := &runResults{}
+	results.addFile("en.json")
+	results.addFile("de.json")
+	results.addBytes(1024)
+	results.addBytes(512)
+	results.addSkipped()
+
+	expected := "2 files written, 1 skipped, 0 errors, 1.5 KiB total"
+	if summary := results.Summary(); summary != expected {
+		t.Errorf("expected summary %q, got %q", expected, summary)
+	}
+
+	var nilResults *runResults
+	nilResults.addFile("en.json")
+	nilResults.addError()
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[int64]string{
+		0:       "0 B",
+		1023:    "1023 B",
+		1024:    "1.0 KiB",
+		5 << 20: "5.0 MiB",
+	}
+	for n, expected := range tests {
+		if got := formatBytes(n); got != expected {
+			t.Errorf("expected %d bytes to be formatted as %q, got %q", n, expected, got)
+		}
+	}
+}
+
+func TestPrintLocaleTable(t *testing.T) {
+	results := &runResults{}
+	results.addLocaleOutcome("en", "written")
+	results.addLocaleBytes("en", 2048)
+	results.setLocaleCompletion("en", 100)
+	results.addLocaleOutcome("de", "written")
+	results.addLocaleOutcome("de", "error")
+	results.addLocaleOutcome("fr", "skipped")
+
+	out := &bytes.Buffer{}
+	results.printLocaleTable(out)
+
+	expected := "LOCALE  BYTES    STATUS   COMPLETION\n" +
+		"de      0 B      error    -\n" +
+		"en      2.0 KiB  written  100.0%\n" +
+		"fr      0 B      skipped  -\n"
+	if out.String() != expected {
+		t.Errorf("expected table\n%s\ngot\n%s", expected, out.String())
+	}
+
+	out.Reset()
+	(&runResults{}).printLocaleTable(out)
+	if out.Len() != 0 {
+		t.Errorf("expected no table without locales, got %q", out.String())
+	}
+}
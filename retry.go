@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+// retryBudget limits the number of retries of all operations of a run, so a
+// run with many failing calls has a predictable worst case runtime. A negative
+// budget is unlimited.
+type retryBudget struct {
+	mu        sync.Mutex
+	remaining int
+}
+
+var retries = &retryBudget{remaining: -1}
+
+// setRetryBudget limits the retries of the run to max, nil means unlimited.
+func setRetryBudget(max *int) {
+	retries.mu.Lock()
+	defer retries.mu.Unlock()
+
+	retries.remaining = -1
+	if max != nil && *max >= 0 {
+		retries.remaining = *max
+	}
+}
+
+// take returns false if the budget is exhausted, otherwise it consumes one
+// retry from it.
+func (budget *retryBudget) take() bool {
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+
+	switch {
+	case budget.remaining < 0:
+		return true
+	case budget.remaining == 0:
+		return false
+	default:
+		budget.remaining--
+		return true
+	}
+}
+
+func (budget *retryBudget) String() string {
+	budget.mu.Lock()
+	defer budget.mu.Unlock()
+
+	if budget.remaining < 0 {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%d", budget.remaining)
+}
+
+// retryOnRateLimit calls f and, if the rate limit was exceeded, waits for the
+// limit to be reset and calls f once more. Each retry is taken from the retry
+// budget of the run, once it is exhausted the rate limit error is returned.
+func retryOnRateLimit(f func() error) error {
+	err := f()
+	rateLimitError, ok := err.(*phraseapp.RateLimitingError)
+	if !ok {
+		return err
+	}
+
+	if !retries.take() {
+		return fmt.Errorf("%s (no retries left)", err)
+	}
+	metrics.addRetry()
+	if Debug {
+		fmt.Fprintf(os.Stderr, "Retrying after rate limit was exceeded, retries left: %s\n", retries)
+	}
+
+	waitForRateLimit(rateLimitError)
+	return f()
+}
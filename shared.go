@@ -1,6 +1,11 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jpillora/backoff"
 	"github.com/phrase/phraseapp-go/phraseapp"
 )
 
@@ -17,7 +22,11 @@ type LocaleCacheKey struct {
 
 type LocaleCache map[LocaleCacheKey][]*phraseapp.Locale
 
+// LocalesForProjects returns the locales of the projects. Only pull targets
+// wait for the locales of projects without any, sources create them.
 func LocalesForProjects(client *phraseapp.Client, projectLocales ProjectLocales, branch string) (LocaleCache, error) {
+	_, retryEmpty := projectLocales.(Targets)
+
 	projectIdToLocales := LocaleCache{}
 	for _, pid := range projectLocales.ProjectIds() {
 		key := LocaleCacheKey{
@@ -26,7 +35,13 @@ func LocalesForProjects(client *phraseapp.Client, projectLocales ProjectLocales,
 		}
 
 		if _, ok := projectIdToLocales[key]; !ok {
-			remoteLocales, err := RemoteLocales(client, key)
+			var remoteLocales []*phraseapp.Locale
+			var err error
+			if retryEmpty {
+				remoteLocales, err = remoteLocalesRetryingEmpty(client, key)
+			} else {
+				remoteLocales, err = RemoteLocales(client, key)
+			}
 			if err != nil {
 				if _, ok := (err).(phraseapp.ErrNotFound); ok && branch != "" {
 					// skip this key if we targeted a branch in
@@ -43,7 +58,52 @@ func LocalesForProjects(client *phraseapp.Client, projectLocales ProjectLocales,
 
 }
 
+// RemoteLocales returns the locales of the project and branch of key. They
+// are cached for a short time, see localesCacheTTL.
 func RemoteLocales(client *phraseapp.Client, key LocaleCacheKey) ([]*phraseapp.Locale, error) {
+	if locales, ok := cachedLocales(client, key); ok {
+		return locales, nil
+	}
+
+	locales, err := listLocales(client, key)
+	if err != nil {
+		return nil, err
+	}
+	if len(locales) > 0 {
+		cacheLocales(client, key, locales)
+	}
+	return locales, nil
+}
+
+// emptyLocalesRetries is the number of times the locales of a project are
+// listed again if there are none, as the locales of a project created just
+// now may not be listed yet.
+var emptyLocalesRetries = 3
+
+// emptyLocalesRetryDelay is the delay before the first retry, doubled for
+// every further one.
+var emptyLocalesRetryDelay = time.Second
+
+// remoteLocalesRetryingEmpty returns the locales of key like RemoteLocales,
+// retrying with backoff while the project exists, but has no locales.
+func remoteLocalesRetryingEmpty(client *phraseapp.Client, key LocaleCacheKey) ([]*phraseapp.Locale, error) {
+	b := &backoff.Backoff{
+		Min:    emptyLocalesRetryDelay,
+		Max:    8 * emptyLocalesRetryDelay,
+		Factor: 2,
+	}
+
+	locales, err := RemoteLocales(client, key)
+	for retry := 0; err == nil && len(locales) == 0 && retry < emptyLocalesRetries; retry++ {
+		delay := b.Duration()
+		fmt.Fprintf(os.Stderr, "No locales found in project %q yet, retrying in %s\n", key.ProjectID, delay)
+		time.Sleep(delay)
+		locales, err = RemoteLocales(client, key)
+	}
+	return locales, err
+}
+
+func listLocales(client *phraseapp.Client, key LocaleCacheKey) ([]*phraseapp.Locale, error) {
 	page := 1
 	locales, err := client.LocalesList(key.ProjectID, page, 25, &phraseapp.LocalesListParams{Branch: &key.Branch})
 	if err != nil {
@@ -60,3 +120,34 @@ func RemoteLocales(client *phraseapp.Client, key LocaleCacheKey) ([]*phraseapp.L
 	}
 	return result, nil
 }
+
+// mergeParams returns a copy of params extended by all values of defaults not
+// set in params. Format options are merged key by key.
+func mergeParams(defaults, params map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+	for k, v := range defaults {
+		merged[k] = v
+	}
+	for k, v := range params {
+		merged[k] = v
+	}
+
+	defaultOptions, ok := defaults["format_options"].(map[interface{}]interface{})
+	if !ok {
+		return merged
+	}
+	options, ok := params["format_options"].(map[interface{}]interface{})
+	if !ok {
+		return merged
+	}
+
+	mergedOptions := map[interface{}]interface{}{}
+	for k, v := range defaultOptions {
+		mergedOptions[k] = v
+	}
+	for k, v := range options {
+		mergedOptions[k] = v
+	}
+	merged["format_options"] = mergedOptions
+	return merged
+}
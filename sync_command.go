@@ -0,0 +1,296 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/phrase/phraseapp-client/internal/print"
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+// Policies of the sync command for files changed locally and remotely.
+const (
+	conflictsPreferRemote = "prefer-remote"
+	conflictsPreferLocal  = "prefer-local"
+	conflictsAbort        = "abort"
+)
+
+type SyncCommand struct {
+	phraseapp.Config
+	Branch    string `cli:"opt --branch"`
+	State     string `cli:"opt --state default=.phraseapp.sync desc='Checksums of the files of the last sync, used to detect local modifications'"`
+	Conflicts string `cli:"opt --conflicts default=abort desc='How to resolve files changed locally and remotely: prefer-remote, prefer-local or abort'"`
+	Push      bool   `cli:"opt --push desc='Upload locally modified files kept by the sync'"`
+}
+
+// syncAction is what a sync does with a file of a pull target.
+type syncAction int
+
+const (
+	syncUnchanged syncAction = iota
+	// syncWriteRemote writes the remote content, there are no local changes.
+	syncWriteRemote
+	// syncKeepLocal keeps the local file, only it was modified.
+	syncKeepLocal
+	// syncConflict means the file was modified locally and remotely.
+	syncConflict
+)
+
+// syncFile is a file of a pull target with its remote content.
+type syncFile struct {
+	target     *Target
+	localeFile *LocaleFile
+	remote     []byte
+	action     syncAction
+}
+
+func (cmd *SyncCommand) Run() error {
+	if cmd.Config.Debug {
+		// suppresses content output
+		cmd.Config.Debug = false
+		Debug = true
+	}
+
+	switch cmd.Conflicts {
+	case conflictsPreferRemote, conflictsPreferLocal, conflictsAbort:
+	default:
+		return fmt.Errorf("unknown conflict policy %q, use prefer-remote, prefer-local or abort", cmd.Conflicts)
+	}
+
+	client, err := newClient(cmd.Config.Credentials, cmd.Config.Debug)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Push {
+		if err := checkWriteAccess(client, nil); err != nil {
+			return err
+		}
+	}
+
+	state, err := readManifest(cmd.State)
+	if os.IsNotExist(err) {
+		print.Warning("No previous sync found in %s, files differing locally and remotely are considered conflicts", cmd.State)
+		state = map[string]string{}
+	} else if err != nil {
+		return err
+	}
+
+	files, err := cmd.remoteFiles(client, state)
+	if err != nil {
+		return err
+	}
+
+	conflicts := 0
+	for _, file := range files {
+		if file.action == syncConflict {
+			conflicts++
+			print.Failure("Conflict: %s was modified locally and remotely", file.localeFile.RelPath())
+		}
+	}
+	if conflicts > 0 && cmd.Conflicts == conflictsAbort {
+		return fmt.Errorf("%d files were modified locally and remotely, nothing was written. Use --conflicts prefer-remote or prefer-local to resolve them", conflicts)
+	}
+
+	var sources map[string]*Source
+	if cmd.Push {
+		if sources, err = cmd.sourcesByPath(client); err != nil {
+			return err
+		}
+	}
+
+	// Files which failed or weren't synced keep the checksum of the last
+	// sync, so their changes are still detected by the next one.
+	checksums := map[string]string{}
+	errs := newErrorCollector(errorPolicyFailFast)
+	for _, file := range files {
+		path := file.localeFile.RelPath()
+		sum, ok := state[path]
+		if !errs.stopped() {
+			synced, err := cmd.apply(client, file, sources)
+			if err != nil {
+				errs.add(file.localeFile.Path, err)
+			} else {
+				sum, ok = synced, true
+			}
+		}
+		if ok {
+			checksums[path] = sum
+		}
+	}
+
+	if err := writeChecksums(cmd.State, checksums); err != nil {
+		return err
+	}
+	return errs.err()
+}
+
+// remoteFiles downloads the files of all targets and determines what to do
+// with them. Nothing is written yet, so a sync can be aborted on conflicts.
+func (cmd *SyncCommand) remoteFiles(client *phraseapp.Client, state map[string]string) ([]*syncFile, error) {
+	targets, err := TargetsFromConfig(cmd.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := targets.ResolveProjectNames(client); err != nil {
+		return nil, err
+	}
+
+	if err := validateBranch(client, targets.ProjectIds(), cmd.Branch); err != nil {
+		return nil, err
+	}
+
+	if err := targets.FetchRemoteLocales(client, cmd.Branch); err != nil {
+		return nil, err
+	}
+
+	if err := targets.LoadFormatExtensions(client); err != nil {
+		return nil, err
+	}
+
+	if err := targets.FetchProjectNames(client); err != nil {
+		return nil, err
+	}
+
+	files := []*syncFile{}
+	for _, target := range targets {
+		if err := target.CheckPreconditions(); err != nil {
+			return nil, err
+		}
+
+		localeFiles, err := target.LocaleFiles()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, localeFile := range localeFiles {
+			remote, err := target.download(client, localeFile, cmd.Branch)
+			if err != nil {
+				return nil, fmt.Errorf("%s for %s", err, localeFile.Path)
+			}
+
+			local, err := ioutil.ReadFile(localeFile.Path)
+			if err != nil && !os.IsNotExist(err) {
+				return nil, err
+			}
+
+			action := syncActionFor(state[localeFile.RelPath()], local, remote, err == nil)
+			files = append(files, &syncFile{target: target, localeFile: localeFile, remote: remote, action: action})
+		}
+	}
+	return files, nil
+}
+
+// sourcesByPath returns the configured sources by the absolute paths of
+// their files, so local changes are uploaded with the params of the source
+// they belong to.
+func (cmd *SyncCommand) sourcesByPath(client *phraseapp.Client) (map[string]*Source, error) {
+	sources, err := SourcesFromConfig(cmd.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := sources.ResolveProjectNames(client); err != nil {
+		return nil, err
+	}
+
+	byPath := map[string]*Source{}
+	for _, source := range sources {
+		localeFiles, err := source.LocaleFiles()
+		if err != nil {
+			return nil, err
+		}
+		for _, localeFile := range localeFiles {
+			if _, ok := byPath[localeFile.Path]; !ok {
+				byPath[localeFile.Path] = source
+			}
+		}
+	}
+	return byPath, nil
+}
+
+// syncActionFor compares the local and remote content of a file with the
+// checksum base recorded by the last sync. Without base, a file differing
+// locally and remotely is a conflict, as it's unknown which side changed.
+func syncActionFor(base string, local, remote []byte, exists bool) syncAction {
+	localSum, remoteSum := checksum(local), checksum(remote)
+	switch {
+	case !exists:
+		return syncWriteRemote
+	case localSum == remoteSum:
+		return syncUnchanged
+	case base == "":
+		return syncConflict
+	case localSum == base:
+		return syncWriteRemote
+	case remoteSum == base:
+		return syncKeepLocal
+	default:
+		return syncConflict
+	}
+}
+
+// apply writes or uploads file according to its action and returns the
+// checksum recorded as its state. Local changes which are kept but not
+// uploaded record the remote checksum, so they are still detected as local
+// modifications by the next sync.
+func (cmd *SyncCommand) apply(client *phraseapp.Client, file *syncFile, sources map[string]*Source) (string, error) {
+	localeFile := file.localeFile
+	remoteSum := checksum(file.remote)
+	switch {
+	case file.action == syncUnchanged:
+		return remoteSum, nil
+	case file.action == syncWriteRemote || (file.action == syncConflict && cmd.Conflicts == conflictsPreferRemote):
+		if err := createFile(localeFile.Path); err != nil {
+			return "", err
+		}
+		if err := writeFileAtomic(localeFile.Path, file.remote, 0700); err != nil {
+			return "", err
+		}
+		if file.action == syncConflict {
+			print.Warning("Overwrote local changes of %s with %s", localeFile.RelPath(), localeFile.Message())
+		} else {
+			print.Success("Downloaded %s to %s", localeFile.Message(), localeFile.RelPath())
+		}
+		return remoteSum, nil
+	case !cmd.Push:
+		print.Warning("Kept local changes of %s, use --push to upload them", localeFile.RelPath())
+		return remoteSum, nil
+	}
+
+	configured, ok := sources[localeFile.Path]
+	if !ok {
+		return "", fmt.Errorf("no source configured to upload local changes")
+	}
+	source := *configured
+	params := *configured.Params
+	if params.FileFormat == nil {
+		params.FileFormat = &localeFile.FileFormat
+	}
+	if params.LocaleID == nil {
+		params.LocaleID = &localeFile.ID
+	}
+	if params.UpdateTranslations == nil {
+		updateTranslations := true
+		params.UpdateTranslations = &updateTranslations
+	}
+	source.Params = &params
+
+	upload, err := source.uploadFile(client, localeFile, cmd.Branch)
+	if err != nil {
+		return "", err
+	}
+	print.Success("Uploaded local changes of %s (upload ID: %s)", localeFile.RelPath(), upload.ID)
+	printUploadWarnings(localeFile, upload)
+
+	return fileChecksum(localeFile.Path, sha256.New())
+}
+
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
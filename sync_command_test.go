@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func TestSyncActionFor(t *testing.T) {
+	base := checksum([]byte("base"))
+	tt := []struct {
+		name   string
+		base   string
+		local  string
+		remote string
+		exists bool
+		exp    syncAction
+	}{
+		{"missing locally", base, "", "remote", false, syncWriteRemote},
+		{"same content", base, "same", "same", true, syncUnchanged},
+		{"changed remotely", base, "base", "remote", true, syncWriteRemote},
+		{"changed locally", base, "local", "base", true, syncKeepLocal},
+		{"changed on both sides", base, "local", "remote", true, syncConflict},
+		{"no previous sync", "", "local", "remote", true, syncConflict},
+		{"no previous sync, same content", "", "same", "same", true, syncUnchanged},
+	}
+
+	for _, tc := range tt {
+		if action := syncActionFor(tc.base, []byte(tc.local), []byte(tc.remote), tc.exists); action != tc.exp {
+			t.Errorf("%s: expected action %d, got %d", tc.name, tc.exp, action)
+		}
+	}
+}
+
+func TestSyncPushUsesSourceParams(t *testing.T) {
+	d := setupFiles(t, "en.json")
+	defer os.RemoveAll(d)
+	defer pushd(t, d)()
+
+	th := new(testHandler)
+	srv := httptest.NewServer(th)
+	defer srv.Close()
+
+	c := new(phraseapp.Client)
+	c.Credentials.Host = srv.URL
+	c.Credentials.Token = "some_token"
+
+	cfg, _, err := parseConfig([]byte(`phraseapp:
+  project_id: project-id
+  push:
+    sources:
+    - file: ./<locale_code>.json
+      params:
+        file_format: json
+        tags: synced
+        format_options:
+          nested: true
+`), "", nil)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	cmd := &SyncCommand{Config: *cfg, Push: true}
+	sources, err := cmd.sourcesByPath(c)
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	path, err := filepath.Abs("en.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := &syncFile{
+		target:     &Target{ProjectID: "project-id"},
+		localeFile: &LocaleFile{Path: path, ID: "en-id", Code: "en", FileFormat: "json"},
+		action:     syncKeepLocal,
+	}
+	if _, err := cmd.apply(c, file, sources); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	if th.lastLocaleID != "en-id" {
+		t.Errorf("expected locale ID %q, got %q", "en-id", th.lastLocaleID)
+	}
+	if th.lastTag != "synced" {
+		t.Errorf("expected the tags of the source, got %q", th.lastTag)
+	}
+	if th.lastFormatOptions["nested"] != "true" {
+		t.Errorf("expected the format options of the source, got %v", th.lastFormatOptions)
+	}
+
+	delete(sources, path)
+	if _, err := cmd.apply(c, file, sources); err == nil {
+		t.Errorf("expected an error for a file without source")
+	}
+}
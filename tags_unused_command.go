@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/phrase/phraseapp-client/internal/paths"
+	"github.com/phrase/phraseapp-client/internal/placeholders"
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+type TagsUnusedCommand struct {
+	phraseapp.Config
+	ProjectID     string `cli:"opt --project-id desc='Project to check, defaults to the project of the config'"`
+	Branch        string `cli:"opt --branch"`
+	RecentUploads int    `cli:"opt --recent-uploads default=25 desc='Number of recent uploads whose tags count as used'"`
+	Format        string `cli:"opt --format default=table desc='Output format, table or json'"`
+}
+
+func (cmd *TagsUnusedCommand) Run() error {
+	if cmd.Config.Debug {
+		// suppresses content output
+		cmd.Config.Debug = false
+		Debug = true
+	}
+
+	projectID := cmd.ProjectID
+	if projectID == "" {
+		projectID = cmd.Config.DefaultProjectID
+	}
+	if projectID == "" {
+		return fmt.Errorf("No project given. Please specify one using --project-id.")
+	}
+
+	client, err := newClient(cmd.Config.Credentials, cmd.Config.Debug)
+	if err != nil {
+		return err
+	}
+
+	used, err := cmd.configuredTags(client, projectID)
+	if err != nil {
+		return err
+	}
+
+	uploadFilenames, err := cmd.recentUploadFilenames(client, projectID)
+	if err != nil {
+		return err
+	}
+
+	tags, err := cmd.projectTags(client, projectID)
+	if err != nil {
+		return err
+	}
+
+	unused := unusedTags(tags, used, uploadFilenames)
+
+	switch cmd.Format {
+	case "json":
+		return json.NewEncoder(os.Stdout).Encode(unused)
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "TAG\tKEYS")
+		for _, tag := range unused {
+			fmt.Fprintf(w, "%s\t%d\n", tag.Name, tag.KeysCount)
+		}
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown output format %q, use table or json", cmd.Format)
+	}
+}
+
+// configuredTags returns the tags the push sources and pull targets of the
+// config use for the project, including the values of <tag> placeholders
+// matched by local files.
+func (cmd *TagsUnusedCommand) configuredTags(client *phraseapp.Client, projectID string) (map[string]bool, error) {
+	used := map[string]bool{}
+	errs := newErrorCollector(errorPolicyFailFast)
+
+	if len(cmd.Config.Sources) > 0 {
+		sources, err := SourcesFromConfig(cmd.Config)
+		if err != nil {
+			return nil, err
+		}
+		if err := sources.ResolveProjectNames(client); err != nil {
+			return nil, err
+		}
+		for _, source := range sources {
+			if source.ProjectID != projectID {
+				continue
+			}
+			if source.Params.Tags != nil {
+				for _, tag := range strings.Split(*source.Params.Tags, ",") {
+					used[strings.TrimSpace(tag)] = true
+				}
+			}
+
+			localeFiles, err := sourceTagFiles(source)
+			if err != nil {
+				if errs.add(source.File, err) {
+					return nil, errs.err()
+				}
+				continue
+			}
+			for _, localeFile := range localeFiles {
+				if localeFile.Tag != "" {
+					used[localeFile.Tag] = true
+				}
+			}
+		}
+	}
+
+	if len(cmd.Config.Targets) > 0 {
+		targets, err := TargetsFromConfig(cmd.Config)
+		if err != nil {
+			return nil, err
+		}
+		if err := targets.ResolveProjectNames(client); err != nil {
+			return nil, err
+		}
+		for _, target := range targets {
+			if target.ProjectID != projectID {
+				continue
+			}
+			for _, tag := range target.GetTags() {
+				used[tag] = true
+			}
+		}
+	}
+
+	return used, errs.err()
+}
+
+// sourceTagFiles returns the local files of source to read the values of
+// <tag> placeholders from. Sources without matching files don't use any
+// tags, they're no error.
+func sourceTagFiles(source *Source) (LocaleFiles, error) {
+	if source.Archive == "" {
+		filePaths, err := paths.Glob(placeholders.ToGlobbingPattern(source.File))
+		if err != nil {
+			return nil, err
+		}
+		if len(filePaths) == 0 {
+			return nil, nil
+		}
+	}
+	return source.LocaleFiles()
+}
+
+func (cmd *TagsUnusedCommand) recentUploadFilenames(client *phraseapp.Client, projectID string) ([]string, error) {
+	if cmd.RecentUploads <= 0 {
+		return nil, nil
+	}
+
+	perPage := cmd.RecentUploads
+	if perPage > 100 {
+		perPage = 100
+	}
+
+	uploads, err := client.UploadsList(projectID, 1, perPage, &phraseapp.UploadsListParams{Branch: &cmd.Branch})
+	if err != nil {
+		return nil, err
+	}
+
+	filenames := []string{}
+	for _, upload := range uploads {
+		filenames = append(filenames, upload.Filename)
+	}
+	return filenames, nil
+}
+
+func (cmd *TagsUnusedCommand) projectTags(client *phraseapp.Client, projectID string) ([]*phraseapp.Tag, error) {
+	params := &phraseapp.TagsListParams{Branch: &cmd.Branch}
+
+	page := 1
+	tags, err := client.TagsList(projectID, page, 100, params)
+	if err != nil {
+		return nil, err
+	}
+	result := tags
+	for len(tags) == 100 {
+		page = page + 1
+		tags, err = client.TagsList(projectID, page, 100, params)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, tags...)
+	}
+	return result, nil
+}
+
+// unusedTags returns the tags neither used by the config nor created for one
+// of the uploads. Upload tags are named after the uploaded file.
+func unusedTags(tags []*phraseapp.Tag, used map[string]bool, uploadFilenames []string) []*phraseapp.Tag {
+	unused := []*phraseapp.Tag{}
+	for _, tag := range tags {
+		if used[tag.Name] || isUploadTag(tag.Name, uploadFilenames) {
+			continue
+		}
+		unused = append(unused, tag)
+	}
+	return unused
+}
+
+func isUploadTag(name string, uploadFilenames []string) bool {
+	for _, filename := range uploadFilenames {
+		if filename != "" && strings.HasPrefix(name, filename) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func TestUnusedTags(t *testing.T) {
+	tags := []*phraseapp.Tag{
+		{Name: "app"},
+		{Name: "marketing"},
+		{Name: "legacy"},
+		{Name: "en.yml-2019-02-08"},
+		{Name: "de.yml-2018-01-01"},
+	}
+	used := map[string]bool{"app": true, "marketing": true}
+
+	unused := unusedTags(tags, used, []string{"en.yml"})
+
+	names := []string{}
+	for _, tag := range unused {
+		names = append(names, tag.Name)
+	}
+	if len(names) != 2 || names[0] != "legacy" || names[1] != "de.yml-2018-01-01" {
+		t.Errorf("expected legacy and de.yml-2018-01-01 to be unused, got %v", names)
+	}
+}
+
+func TestConfiguredTags(t *testing.T) {
+	d := setupFiles(t, "locales/app/en.json", "locales/web/en.json")
+	defer os.RemoveAll(d)
+	defer pushd(t, d)()
+	defer func() { projectIDsByName = nil }()
+	projectIDsByName = map[string]string{"Web": "project-id"}
+
+	cmd := &TagsUnusedCommand{Config: phraseapp.Config{
+		DefaultProjectID: "project-id",
+		Sources: []byte(`sources:
+- file: ./locales/<tag>/<locale_code>.json
+  params:
+    file_format: simple_json
+    tags: shared
+- file: ./other/<locale_code>.json
+  project_id: other-project
+  params:
+    tags: foreign
+- file: ./web/<locale_code>.json
+  project_name: Web
+  params:
+    tags: web-named
+`),
+		Targets: []byte(`targets:
+- file: ./locales/<locale_code>.json
+  params:
+    tags: mobile
+- file: ./web/<locale_code>.json
+  project_name: Web
+  params:
+    tags: web-target
+`),
+	}}
+
+	used, err := cmd.configuredTags(new(phraseapp.Client), "project-id")
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	for _, tag := range []string{"shared", "app", "web", "mobile", "web-named", "web-target"} {
+		if !used[tag] {
+			t.Errorf("expected tag %q to be used, got %v", tag, used)
+		}
+	}
+	if used["foreign"] {
+		t.Errorf("expected tags of other projects to be ignored")
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// tmpDir is the directory intermediate files are created in, if set with the
+// tmp_dir config option or --tmp-dir.
+var tmpDir string
+
+// setTmpDir sets the directory for intermediate files, after checking it is
+// writable. An empty dir resets to the defaults.
+func setTmpDir(dir string) error {
+	if dir == "" {
+		tmpDir = ""
+		return nil
+	}
+
+	stat, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("temp dir: %s", err)
+	}
+	if !stat.IsDir() {
+		return fmt.Errorf("temp dir %q is not a directory", dir)
+	}
+
+	f, err := ioutil.TempFile(dir, ".phraseapp")
+	if err != nil {
+		return fmt.Errorf("temp dir %q is not writable: %s", dir, err)
+	}
+	f.Close()
+	os.Remove(f.Name())
+
+	tmpDir = dir
+	return nil
+}
+
+// tempDirFor returns the directory for an intermediate file. Files replacing
+// the file at path are created next to it, so they can be renamed atomically.
+// Other files go to the temp dir of the system. A configured temp dir is
+// used in both cases.
+func tempDirFor(path string) string {
+	switch {
+	case tmpDir != "":
+		return tmpDir
+	case path != "":
+		return filepath.Dir(path)
+	default:
+		return os.TempDir()
+	}
+}
+
+// tempFilePath returns the path of a file kept between runs, like caches, in
+// the configured temp dir or the temp dir of the system.
+func tempFilePath(name string) string {
+	return filepath.Join(tempDirFor(""), name)
+}
+
+// writeFileAtomic writes content to a temporary file and renames it to path,
+// so path never contains partially written content. If the temporary file is
+// on another file system the content is written to path directly.
+func writeFileAtomic(path string, content []byte, perm os.FileMode) error {
+	f, err := ioutil.TempFile(tempDirFor(path), "."+filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	_, err = f.Write(content)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chmod(f.Name(), perm); err != nil {
+		return err
+	}
+
+	if err := os.Rename(f.Name(), path); err != nil {
+		return ioutil.WriteFile(path, content, perm)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTempDirFor(t *testing.T) {
+	defer setTmpDir("")
+
+	if dir := tempDirFor("locales/en.json"); dir != "locales" {
+		t.Errorf("expected the directory of the file, got %q", dir)
+	}
+	if dir := tempDirFor(""); dir != os.TempDir() {
+		t.Errorf("expected the temp dir of the system, got %q", dir)
+	}
+
+	dir, err := ioutil.TempDir("", "phraseapp-tmp-dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := setTmpDir(dir); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+	if got := tempDirFor("locales/en.json"); got != dir {
+		t.Errorf("expected the configured temp dir, got %q", got)
+	}
+	if got := tempFilePath(".phraseapp.formats.json"); got != filepath.Join(dir, ".phraseapp.formats.json") {
+		t.Errorf("expected files kept between runs in the configured temp dir, got %q", got)
+	}
+	if got := remoteConfigCachePath("https://example.com/.phraseapp.yml"); filepath.Dir(got) != dir {
+		t.Errorf("expected the config cache in the configured temp dir, got %q", got)
+	}
+
+	if err := setTmpDir(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("expected an error for a missing directory")
+	}
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phraseapp-atomic")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "en.json")
+	ioutil.WriteFile(path, []byte("old"), 0600)
+
+	if err := writeFileAtomic(path, []byte("new"), 0600); err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	content, _ := ioutil.ReadFile(path)
+	if string(content) != "new" {
+		t.Errorf("expected new content, got %q", content)
+	}
+
+	files, _ := ioutil.ReadDir(dir)
+	if len(files) != 1 {
+		t.Errorf("expected the temporary file to be removed, got %d files", len(files))
+	}
+}
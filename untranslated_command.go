@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+type UntranslatedCommand struct {
+	phraseapp.Config
+	ProjectID string `cli:"opt --project-id desc='Project of the keys, defaults to the project of the config'"`
+	Branch    string `cli:"opt --branch"`
+	Locale    string `cli:"opt --locale required desc='Code, name or ID of the locale'"`
+	Tags      string `cli:"opt --tag desc='Only list keys with these tags, comma separated'"`
+	Count     bool   `cli:"opt --count desc='Only print the number of keys'"`
+	Format    string `cli:"opt --format default=table desc='Output format, table or json'"`
+}
+
+// untranslatedKey is a key without a translation or with an unverified one
+// in a locale.
+type untranslatedKey struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Status is untranslated or unverified.
+	Status string `json:"status"`
+}
+
+func (cmd *UntranslatedCommand) Run() error {
+	if cmd.Config.Debug {
+		// suppresses content output
+		cmd.Config.Debug = false
+		Debug = true
+	}
+
+	if cmd.Format != "table" && cmd.Format != "json" {
+		return fmt.Errorf("unknown output format %q, use table or json", cmd.Format)
+	}
+
+	projectID, err := projectIDOrDefault(cmd.ProjectID, cmd.Config)
+	if err != nil {
+		return err
+	}
+
+	client, err := newClient(cmd.Config.Credentials, cmd.Config.Debug)
+	if err != nil {
+		return err
+	}
+
+	branch := branchOrDefault(cmd.Branch)
+	locale, err := projectLocale(client, projectID, branch, cmd.Locale)
+	if err != nil {
+		return err
+	}
+
+	keys, err := untranslatedKeys(client, projectID, branch, locale.ID, cmd.Tags)
+	if err != nil {
+		return err
+	}
+	return printUntranslatedKeys(os.Stdout, keys, cmd.Format, cmd.Count)
+}
+
+// untranslatedKeys returns the keys of the project without a translation in
+// the locale, followed by those with an unverified one. With tags, only keys
+// with these tags are returned.
+func untranslatedKeys(client *phraseapp.Client, projectID, branch, localeID, tags string) ([]*untranslatedKey, error) {
+	keys := []*untranslatedKey{}
+	found := map[string]bool{}
+	for _, search := range []struct{ query, status string }{
+		{"translated:false", "untranslated"},
+		{"unverified:true", "unverified"},
+	} {
+		query := search.query
+		if tags != "" {
+			query += " tags:" + strings.Replace(tags, " ", "", -1)
+		}
+		params := &phraseapp.KeysSearchParams{Q: &query, LocaleID: &localeID}
+		if branch != "" {
+			params.Branch = &branch
+		}
+
+		for page := 1; ; page++ {
+			var result []*phraseapp.TranslationKey
+			err := retryOnRateLimit(func() (err error) {
+				result, err = client.KeysSearch(projectID, page, 100, params)
+				return err
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, key := range result {
+				if !found[key.ID] {
+					found[key.ID] = true
+					keys = append(keys, &untranslatedKey{ID: key.ID, Name: key.Name, Status: search.status})
+				}
+			}
+			if len(result) < 100 {
+				break
+			}
+		}
+	}
+	return keys, nil
+}
+
+// printUntranslatedKeys writes the keys to w as table or JSON, or only their
+// number with count.
+func printUntranslatedKeys(w io.Writer, keys []*untranslatedKey, format string, count bool) error {
+	switch {
+	case count && format == "json":
+		return json.NewEncoder(w).Encode(map[string]int{"count": len(keys)})
+	case count:
+		_, err := fmt.Fprintln(w, len(keys))
+		return err
+	case format == "json":
+		return json.NewEncoder(w).Encode(keys)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "KEY\tSTATUS")
+	for _, key := range keys {
+		fmt.Fprintf(tw, "%s\t%s\n", key.Name, key.Status)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func TestUntranslatedKeys(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/projects/project-id/keys/search" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		params := phraseapp.KeysSearchParams{}
+		json.NewDecoder(r.Body).Decode(&params)
+		if stringValue(params.LocaleID) != "de-locale-id" || stringValue(params.Branch) != "feature" {
+			t.Errorf("expected a search in de-locale-id on feature, got %+v", params)
+		}
+
+		switch stringValue(params.Q) {
+		case "translated:false tags:app,web":
+			io.WriteString(w, `[{"id": "title-id", "name": "app.title"}, {"id": "footer-id", "name": "app.footer"}]`)
+		case "unverified:true tags:app,web":
+			io.WriteString(w, `[{"id": "footer-id", "name": "app.footer"}, {"id": "button-id", "name": "app.button"}]`)
+		default:
+			t.Errorf("unexpected query %q", stringValue(params.Q))
+			io.WriteString(w, `[]`)
+		}
+	}))
+	defer srv.Close()
+
+	client := new(phraseapp.Client)
+	client.Credentials.Host = srv.URL
+	client.Credentials.Token = "some_token"
+
+	keys, err := untranslatedKeys(client, "project-id", "feature", "de-locale-id", "app, web")
+	if err != nil {
+		t.Fatalf("didn't expect an error, got: %s", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := printUntranslatedKeys(buf, keys, "table", false); err != nil {
+		t.Fatal(err)
+	}
+	exp := "KEY         STATUS\napp.title   untranslated\napp.footer  untranslated\napp.button  unverified\n"
+	if buf.String() != exp {
+		t.Errorf("expected table %q, got %q", exp, buf.String())
+	}
+
+	buf.Reset()
+	if err := printUntranslatedKeys(buf, keys, "json", false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), `[{"id":"title-id","name":"app.title","status":"untranslated"},`) {
+		t.Errorf("expected the keys as JSON, got %s", buf.String())
+	}
+
+	buf.Reset()
+	printUntranslatedKeys(buf, keys, "table", true)
+	if buf.String() != "3\n" {
+		t.Errorf("expected only the count, got %q", buf.String())
+	}
+
+	buf.Reset()
+	printUntranslatedKeys(buf, keys, "json", true)
+	if buf.String() != "{\"count\":3}\n" {
+		t.Errorf("expected only the count as JSON, got %q", buf.String())
+	}
+}
@@ -26,6 +26,10 @@ func (cmd *UploadCleanupCommand) Run() error {
 }
 
 func UploadCleanup(client *phraseapp.Client, cmd *UploadCleanupCommand) error {
+	if err := checkWriteAccess(client, nil); err != nil {
+		return err
+	}
+
 	q := "unmentioned_in_upload:" + cmd.ID
 	params := &phraseapp.KeysListParams{Q: &q}
 
@@ -42,6 +46,7 @@ func UploadCleanup(client *phraseapp.Client, cmd *UploadCleanupCommand) error {
 		return nil
 	}
 
+	errs := newErrorCollector(errorPolicyFailFast)
 	for len(keys) != 0 {
 		ids := make([]string, len(keys), len(keys))
 		names := make([]string, len(keys), len(keys))
@@ -73,14 +78,20 @@ func UploadCleanup(client *phraseapp.Client, cmd *UploadCleanupCommand) error {
 		})
 
 		if err != nil {
-			return err
+			if errs.add(fmt.Sprintf("deleting keys of page %d", page), err) {
+				return errs.err()
+			}
+		} else {
+			fmt.Printf("%d key(s) successfully deleted.\n", affected.RecordsAffected)
 		}
 
-		fmt.Printf("%d key(s) successfully deleted.\n", affected.RecordsAffected)
-
 		page++
-		keys, _ = client.KeysList(cmd.Config.DefaultProjectID, page, 25, params)
+		keys, err = client.KeysList(cmd.Config.DefaultProjectID, page, 25, params)
+		if err != nil {
+			errs.add(fmt.Sprintf("listing keys of page %d", page), err)
+			break
+		}
 	}
 
-	return nil
+	return errs.err()
 }
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+// uploadResult is an upload with the non-fatal warnings the server reported
+// for it, e.g. ignored keys. The API client doesn't decode them.
+type uploadResult struct {
+	*phraseapp.Upload
+	Warnings []string
+}
+
+// warningsTransport records the warnings of the responses sent through base.
+type warningsTransport struct {
+	base http.RoundTripper
+
+	mu       sync.Mutex
+	warnings []string
+}
+
+// withWarnings returns a copy of client recording the warnings of its
+// responses in the returned transport.
+func withWarnings(client *phraseapp.Client) (*phraseapp.Client, *warningsTransport) {
+	c := *client
+	t := &warningsTransport{base: c.Transport}
+	c.Transport = t
+	return &c, t
+}
+
+func (t *warningsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp, err
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	if warnings := parseResponseWarnings(body); len(warnings) > 0 {
+		t.mu.Lock()
+		t.warnings = append(t.warnings, warnings...)
+		t.mu.Unlock()
+	}
+	return resp, nil
+}
+
+func (t *warningsTransport) list() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string{}, t.warnings...)
+}
+
+// parseResponseWarnings returns the entries of the warnings array of a JSON
+// response body. Entries are either strings or objects with a message.
+func parseResponseWarnings(body []byte) []string {
+	response := struct {
+		Warnings []json.RawMessage `json:"warnings"`
+	}{}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil
+	}
+
+	warnings := []string{}
+	for _, raw := range response.Warnings {
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			warnings = append(warnings, s)
+			continue
+		}
+
+		var withMessage struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(raw, &withMessage); err == nil && withMessage.Message != "" {
+			warnings = append(warnings, withMessage.Message)
+			continue
+		}
+		warnings = append(warnings, string(raw))
+	}
+	return warnings
+}
+
+// printUploadWarnings prints the warnings of the upload of localeFile.
+func printUploadWarnings(localeFile *LocaleFile, upload *uploadResult) {
+	for _, msg := range upload.Warnings {
+		warn("Upload of %s: %s", localeFile.RelPath(), msg)
+	}
+}
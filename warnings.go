@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/phrase/phraseapp-client/internal/print"
+)
+
+// warnings collects the warnings of a run, so they can be summarized and turn
+// the run into a failure with --fail-on-warnings.
+var warnings = &warningCollector{}
+
+type warningCollector struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+// warn prints a warning and records it.
+func warn(msg string, args ...interface{}) {
+	print.Warning(msg, args...)
+	warnings.add(msg, args...)
+}
+
+// add records a warning without printing it, for warnings which were
+// reported otherwise already.
+func (w *warningCollector) add(msg string, args ...interface{}) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.messages = append(w.messages, fmt.Sprintf(msg, args...))
+}
+
+func (w *warningCollector) list() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]string{}, w.messages...)
+}
+
+// check prints a summary of the warnings and returns an error if there were
+// any and failOnWarnings is set.
+func (w *warningCollector) check(failOnWarnings bool) error {
+	messages := w.list()
+	if len(messages) == 0 {
+		return nil
+	}
+
+	print.Warning("%d warnings:", len(messages))
+	for _, message := range messages {
+		print.Warning("  %s", message)
+	}
+
+	if failOnWarnings {
+		return fmt.Errorf("%d warnings occurred and --fail-on-warnings is set", len(messages))
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/phrase/phraseapp-go/phraseapp"
+)
+
+func TestWarningsCheck(t *testing.T) {
+	defer func(original *warningCollector) { warnings = original }(warnings)
+	warnings = &warningCollector{}
+
+	if err := warnings.check(true); err != nil {
+		t.Errorf("didn't expect an error without warnings, got: %s", err)
+	}
+
+	source := getBaseSource()
+	source.FuzzyLocaleMatch = true
+	source.Params.LocaleID = sPt("<locale_code>")
+	source.RemoteLocales = []*phraseapp.Locale{{ID: "en-id", Name: "en (default)", Code: "en"}}
+	if locale := source.getRemoteLocaleForLocaleFile(&LocaleFile{Code: "en"}); locale == nil {
+		t.Fatalf("expected the fuzzy match to find a locale")
+	}
+
+	if messages := warnings.list(); len(messages) != 1 {
+		t.Fatalf("expected the fuzzy match to be recorded as warning, got %v", messages)
+	}
+	if err := warnings.check(false); err != nil {
+		t.Errorf("didn't expect an error without --fail-on-warnings, got: %s", err)
+	}
+	if err := warnings.check(true); err == nil {
+		t.Errorf("expected an error with --fail-on-warnings")
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+// xliffFormats are the formats the XLIFF options apply to.
+var xliffFormats = map[string]bool{"xlf": true, "xliff_2": true}
+
+// xliffOptions are XLIFF specific format options, which can be set with flags
+// on push and pull instead of their raw keys in format_options:
+//
+//	--xliff-states  include_translation_state  keep the state of translation units
+//	--xliff-notes   include_notes              keep the notes of translation units
+//
+// Options set explicitly in format_options take precedence.
+type xliffOptions struct {
+	States bool
+	Notes  bool
+}
+
+// apply returns the given format options extended by the XLIFF options. The
+// given options are left untouched and returned as is for other formats.
+func (o xliffOptions) apply(format string, options map[string]string) map[string]string {
+	if !xliffFormats[format] || (!o.States && !o.Notes) {
+		return options
+	}
+
+	result := map[string]string{}
+	if o.States {
+		result["include_translation_state"] = "true"
+	}
+	if o.Notes {
+		result["include_notes"] = "true"
+	}
+	for key, value := range options {
+		result[key] = value
+	}
+	return result
+}